@@ -9,6 +9,7 @@ package gateway
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -23,9 +24,11 @@ const (
 
 // Запрос статуса/статистики
 type StatRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Network       string                 `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
-	User          string                 `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Network string                 `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	User    string                 `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	// шаблон отчёта: telegram_html (по умолчанию) или другой из ListReportFormats
+	Format        string `protobuf:"bytes,3,opt,name=format,proto3" json:"format,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -74,10 +77,21 @@ func (x *StatRequest) GetUser() string {
 	return ""
 }
 
+func (x *StatRequest) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
 type StatReply struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
-	Network       string                 `protobuf:"bytes,2,opt,name=network,proto3" json:"network,omitempty"`
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Text    string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	Network string                 `protobuf:"bytes,2,opt,name=network,proto3" json:"network,omitempty"`
+	// прогноз окончания баланса по каждому партнёру сети
+	Forecasts []*PartnerForecast `protobuf:"bytes,3,rep,name=forecasts,proto3" json:"forecasts,omitempty"`
+	// parse_mode Telegram для text; пусто — текст без разметки
+	ParseMode     string `protobuf:"bytes,4,opt,name=parse_mode,json=parseMode,proto3" json:"parse_mode,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -126,19 +140,2116 @@ func (x *StatReply) GetNetwork() string {
 	return ""
 }
 
-var File_balance_proto protoreflect.FileDescriptor
+func (x *StatReply) GetForecasts() []*PartnerForecast {
+	if x != nil {
+		return x.Forecasts
+	}
+	return nil
+}
 
-const file_balance_proto_rawDesc = "" +
-	"\n" +
-	"\rbalance.proto\x12\agateway\";\n" +
-	"\vStatRequest\x12\x18\n" +
-	"\anetwork\x18\x01 \x01(\tR\anetwork\x12\x12\n" +
-	"\x04user\x18\x02 \x01(\tR\x04user\"9\n" +
-	"\tStatReply\x12\x12\n" +
-	"\x04text\x18\x01 \x01(\tR\x04text\x12\x18\n" +
-	"\anetwork\x18\x02 \x01(\tR\anetwork2?\n" +
+func (x *StatReply) GetParseMode() string {
+	if x != nil {
+		return x.ParseMode
+	}
+	return ""
+}
+
+// Прогноз, когда баланс партнёра дойдёт до нуля при текущем расходе
+type PartnerForecast struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Partner     string                 `protobuf:"bytes,1,opt,name=partner,proto3" json:"partner,omitempty"`
+	Balance     float64                `protobuf:"fixed64,2,opt,name=balance,proto3" json:"balance,omitempty"`
+	SpendPerDay float64                `protobuf:"fixed64,3,opt,name=spend_per_day,json=spendPerDay,proto3" json:"spend_per_day,omitempty"`
+	// false, если расход не удалось оценить; остальные поля прогноза тогда пустые
+	Known     bool                   `protobuf:"varint,4,opt,name=known,proto3" json:"known,omitempty"`
+	HoursLeft float64                `protobuf:"fixed64,5,opt,name=hours_left,json=hoursLeft,proto3" json:"hours_left,omitempty"`
+	ZeroAt    *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=zero_at,json=zeroAt,proto3" json:"zero_at,omitempty"`
+	// до какого времени нужно пополнить аккаунт
+	TopUpBy       *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=top_up_by,json=topUpBy,proto3" json:"top_up_by,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PartnerForecast) Reset() {
+	*x = PartnerForecast{}
+	mi := &file_balance_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PartnerForecast) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PartnerForecast) ProtoMessage() {}
+
+func (x *PartnerForecast) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PartnerForecast.ProtoReflect.Descriptor instead.
+func (*PartnerForecast) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PartnerForecast) GetPartner() string {
+	if x != nil {
+		return x.Partner
+	}
+	return ""
+}
+
+func (x *PartnerForecast) GetBalance() float64 {
+	if x != nil {
+		return x.Balance
+	}
+	return 0
+}
+
+func (x *PartnerForecast) GetSpendPerDay() float64 {
+	if x != nil {
+		return x.SpendPerDay
+	}
+	return 0
+}
+
+func (x *PartnerForecast) GetKnown() bool {
+	if x != nil {
+		return x.Known
+	}
+	return false
+}
+
+func (x *PartnerForecast) GetHoursLeft() float64 {
+	if x != nil {
+		return x.HoursLeft
+	}
+	return 0
+}
+
+func (x *PartnerForecast) GetZeroAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ZeroAt
+	}
+	return nil
+}
+
+func (x *PartnerForecast) GetTopUpBy() *timestamppb.Timestamp {
+	if x != nil {
+		return x.TopUpBy
+	}
+	return nil
+}
+
+type NetworkRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Network string                 `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	User    string                 `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	// шаблон отчёта в BalancesReply.text: telegram_html (по умолчанию) или другой из ListReportFormats
+	Format        string `protobuf:"bytes,3,opt,name=format,proto3" json:"format,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NetworkRequest) Reset() {
+	*x = NetworkRequest{}
+	mi := &file_balance_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NetworkRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NetworkRequest) ProtoMessage() {}
+
+func (x *NetworkRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NetworkRequest.ProtoReflect.Descriptor instead.
+func (*NetworkRequest) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *NetworkRequest) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *NetworkRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *NetworkRequest) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+// Состояние одного партнёра
+type PartnerBalance struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Partner string                 `protobuf:"bytes,1,opt,name=partner,proto3" json:"partner,omitempty"`
+	Balance float64                `protobuf:"fixed64,2,opt,name=balance,proto3" json:"balance,omitempty"`
+	// код валюты, пусто — неизвестна
+	Currency string `protobuf:"bytes,3,opt,name=currency,proto3" json:"currency,omitempty"`
+	// когда получен баланс; для снимка из кэша — время его сбора
+	FetchedAt *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=fetched_at,json=fetchedAt,proto3" json:"fetched_at,omitempty"`
+	// ошибка получения баланса; если не пусто, остальные поля не заполнены
+	Error string `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	// true, если поля расхода ниже заполнены (только в GetSpendStats)
+	HasStats    bool    `protobuf:"varint,6,opt,name=has_stats,json=hasStats,proto3" json:"has_stats,omitempty"`
+	SpendPerDay float64 `protobuf:"fixed64,7,opt,name=spend_per_day,json=spendPerDay,proto3" json:"spend_per_day,omitempty"`
+	// порог правила spend_multiplier, 0 если правило выключено
+	Threshold float64 `protobuf:"fixed64,8,opt,name=threshold,proto3" json:"threshold,omitempty"`
+	// сработало хотя бы одно правило тревоги (только в GetSpendStats)
+	Alert    bool             `protobuf:"varint,9,opt,name=alert,proto3" json:"alert,omitempty"`
+	Forecast *PartnerForecast `protobuf:"bytes,10,opt,name=forecast,proto3" json:"forecast,omitempty"`
+	Alerts   []*FiredAlert    `protobuf:"bytes,11,rep,name=alerts,proto3" json:"alerts,omitempty"`
+	// суммы в валюте отчётов; пусто, если пересчёт не настроен или невозможен
+	ReportingCurrency    string  `protobuf:"bytes,12,opt,name=reporting_currency,json=reportingCurrency,proto3" json:"reporting_currency,omitempty"`
+	ReportingBalance     float64 `protobuf:"fixed64,13,opt,name=reporting_balance,json=reportingBalance,proto3" json:"reporting_balance,omitempty"`
+	ReportingSpendPerDay float64 `protobuf:"fixed64,14,opt,name=reporting_spend_per_day,json=reportingSpendPerDay,proto3" json:"reporting_spend_per_day,omitempty"`
+	// цепь партнёра разомкнута: с какого момента он недоступен, баланс не запрашивался
+	UnavailableSince *timestamppb.Timestamp `protobuf:"bytes,15,opt,name=unavailable_since,json=unavailableSince,proto3" json:"unavailable_since,omitempty"`
+	// свежесть собранных данных (только в GetSpendStats): последний удачный сбор
+	// и сколько попыток после него не удалось
+	LastCollectedAt   *timestamppb.Timestamp `protobuf:"bytes,16,opt,name=last_collected_at,json=lastCollectedAt,proto3" json:"last_collected_at,omitempty"`
+	FailedCollections int32                  `protobuf:"varint,17,opt,name=failed_collections,json=failedCollections,proto3" json:"failed_collections,omitempty"`
+	// партнёр не ответил за время, отведённое отчёту; остальные партнёры возвращены как есть
+	TimedOut      bool `protobuf:"varint,18,opt,name=timed_out,json=timedOut,proto3" json:"timed_out,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PartnerBalance) Reset() {
+	*x = PartnerBalance{}
+	mi := &file_balance_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PartnerBalance) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PartnerBalance) ProtoMessage() {}
+
+func (x *PartnerBalance) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PartnerBalance.ProtoReflect.Descriptor instead.
+func (*PartnerBalance) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *PartnerBalance) GetPartner() string {
+	if x != nil {
+		return x.Partner
+	}
+	return ""
+}
+
+func (x *PartnerBalance) GetBalance() float64 {
+	if x != nil {
+		return x.Balance
+	}
+	return 0
+}
+
+func (x *PartnerBalance) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *PartnerBalance) GetFetchedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FetchedAt
+	}
+	return nil
+}
+
+func (x *PartnerBalance) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *PartnerBalance) GetHasStats() bool {
+	if x != nil {
+		return x.HasStats
+	}
+	return false
+}
+
+func (x *PartnerBalance) GetSpendPerDay() float64 {
+	if x != nil {
+		return x.SpendPerDay
+	}
+	return 0
+}
+
+func (x *PartnerBalance) GetThreshold() float64 {
+	if x != nil {
+		return x.Threshold
+	}
+	return 0
+}
+
+func (x *PartnerBalance) GetAlert() bool {
+	if x != nil {
+		return x.Alert
+	}
+	return false
+}
+
+func (x *PartnerBalance) GetForecast() *PartnerForecast {
+	if x != nil {
+		return x.Forecast
+	}
+	return nil
+}
+
+func (x *PartnerBalance) GetAlerts() []*FiredAlert {
+	if x != nil {
+		return x.Alerts
+	}
+	return nil
+}
+
+func (x *PartnerBalance) GetReportingCurrency() string {
+	if x != nil {
+		return x.ReportingCurrency
+	}
+	return ""
+}
+
+func (x *PartnerBalance) GetReportingBalance() float64 {
+	if x != nil {
+		return x.ReportingBalance
+	}
+	return 0
+}
+
+func (x *PartnerBalance) GetReportingSpendPerDay() float64 {
+	if x != nil {
+		return x.ReportingSpendPerDay
+	}
+	return 0
+}
+
+func (x *PartnerBalance) GetUnavailableSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UnavailableSince
+	}
+	return nil
+}
+
+func (x *PartnerBalance) GetLastCollectedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastCollectedAt
+	}
+	return nil
+}
+
+func (x *PartnerBalance) GetFailedCollections() int32 {
+	if x != nil {
+		return x.FailedCollections
+	}
+	return 0
+}
+
+func (x *PartnerBalance) GetTimedOut() bool {
+	if x != nil {
+		return x.TimedOut
+	}
+	return false
+}
+
+// сработавшее правило тревоги
+type FiredAlert struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// min_balance, min_runway_hours, spend_multiplier или drop_percent
+	Rule          string  `protobuf:"bytes,1,opt,name=rule,proto3" json:"rule,omitempty"`
+	Value         float64 `protobuf:"fixed64,2,opt,name=value,proto3" json:"value,omitempty"`
+	Limit         float64 `protobuf:"fixed64,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	Reason        string  `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FiredAlert) Reset() {
+	*x = FiredAlert{}
+	mi := &file_balance_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FiredAlert) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FiredAlert) ProtoMessage() {}
+
+func (x *FiredAlert) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FiredAlert.ProtoReflect.Descriptor instead.
+func (*FiredAlert) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *FiredAlert) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *FiredAlert) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *FiredAlert) GetLimit() float64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *FiredAlert) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type BalancesReply struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Network     string                 `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	GeneratedAt *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=generated_at,json=generatedAt,proto3" json:"generated_at,omitempty"`
+	Partners    []*PartnerBalance      `protobuf:"bytes,3,rep,name=partners,proto3" json:"partners,omitempty"`
+	// отчёт, отрендеренный шаблоном из запроса; пусто — сообщать не о чем
+	Text string `protobuf:"bytes,4,opt,name=text,proto3" json:"text,omitempty"`
+	// parse_mode Telegram для text; пусто — текст без разметки
+	ParseMode     string `protobuf:"bytes,5,opt,name=parse_mode,json=parseMode,proto3" json:"parse_mode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BalancesReply) Reset() {
+	*x = BalancesReply{}
+	mi := &file_balance_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BalancesReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BalancesReply) ProtoMessage() {}
+
+func (x *BalancesReply) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BalancesReply.ProtoReflect.Descriptor instead.
+func (*BalancesReply) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *BalancesReply) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *BalancesReply) GetGeneratedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.GeneratedAt
+	}
+	return nil
+}
+
+func (x *BalancesReply) GetPartners() []*PartnerBalance {
+	if x != nil {
+		return x.Partners
+	}
+	return nil
+}
+
+func (x *BalancesReply) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *BalancesReply) GetParseMode() string {
+	if x != nil {
+		return x.ParseMode
+	}
+	return ""
+}
+
+type ListReportFormatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListReportFormatsRequest) Reset() {
+	*x = ListReportFormatsRequest{}
+	mi := &file_balance_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListReportFormatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListReportFormatsRequest) ProtoMessage() {}
+
+func (x *ListReportFormatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListReportFormatsRequest.ProtoReflect.Descriptor instead.
+func (*ListReportFormatsRequest) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{7}
+}
+
+// формат отчёта и parse_mode Telegram для его текста
+type ReportFormat struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	ParseMode     string                 `protobuf:"bytes,2,opt,name=parse_mode,json=parseMode,proto3" json:"parse_mode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportFormat) Reset() {
+	*x = ReportFormat{}
+	mi := &file_balance_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportFormat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportFormat) ProtoMessage() {}
+
+func (x *ReportFormat) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportFormat.ProtoReflect.Descriptor instead.
+func (*ReportFormat) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ReportFormat) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ReportFormat) GetParseMode() string {
+	if x != nil {
+		return x.ParseMode
+	}
+	return ""
+}
+
+type ListReportFormatsReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Formats       []*ReportFormat        `protobuf:"bytes,1,rep,name=formats,proto3" json:"formats,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListReportFormatsReply) Reset() {
+	*x = ListReportFormatsReply{}
+	mi := &file_balance_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListReportFormatsReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListReportFormatsReply) ProtoMessage() {}
+
+func (x *ListReportFormatsReply) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListReportFormatsReply.ProtoReflect.Descriptor instead.
+func (*ListReportFormatsReply) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListReportFormatsReply) GetFormats() []*ReportFormat {
+	if x != nil {
+		return x.Formats
+	}
+	return nil
+}
+
+type ListNetworksRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListNetworksRequest) Reset() {
+	*x = ListNetworksRequest{}
+	mi := &file_balance_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListNetworksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListNetworksRequest) ProtoMessage() {}
+
+func (x *ListNetworksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListNetworksRequest.ProtoReflect.Descriptor instead.
+func (*ListNetworksRequest) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{10}
+}
+
+type Network struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Partners      []string               `protobuf:"bytes,2,rep,name=partners,proto3" json:"partners,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Network) Reset() {
+	*x = Network{}
+	mi := &file_balance_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Network) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Network) ProtoMessage() {}
+
+func (x *Network) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Network.ProtoReflect.Descriptor instead.
+func (*Network) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Network) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Network) GetPartners() []string {
+	if x != nil {
+		return x.Partners
+	}
+	return nil
+}
+
+type ListNetworksReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Networks      []*Network             `protobuf:"bytes,1,rep,name=networks,proto3" json:"networks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListNetworksReply) Reset() {
+	*x = ListNetworksReply{}
+	mi := &file_balance_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListNetworksReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListNetworksReply) ProtoMessage() {}
+
+func (x *ListNetworksReply) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListNetworksReply.ProtoReflect.Descriptor instead.
+func (*ListNetworksReply) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ListNetworksReply) GetNetworks() []*Network {
+	if x != nil {
+		return x.Networks
+	}
+	return nil
+}
+
+type HistoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Network       string                 `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	Partner       string                 `protobuf:"bytes,2,opt,name=partner,proto3" json:"partner,omitempty"`
+	From          *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=from,proto3" json:"from,omitempty"` // по умолчанию to минус 7 дней
+	To            *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=to,proto3" json:"to,omitempty"`     // по умолчанию текущее время
+	Step          string                 `protobuf:"bytes,5,opt,name=step,proto3" json:"step,omitempty"` // hour, day или week; по умолчанию day
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryRequest) Reset() {
+	*x = HistoryRequest{}
+	mi := &file_balance_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryRequest) ProtoMessage() {}
+
+func (x *HistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryRequest.ProtoReflect.Descriptor instead.
+func (*HistoryRequest) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *HistoryRequest) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *HistoryRequest) GetPartner() string {
+	if x != nil {
+		return x.Partner
+	}
+	return ""
+}
+
+func (x *HistoryRequest) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *HistoryRequest) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *HistoryRequest) GetStep() string {
+	if x != nil {
+		return x.Step
+	}
+	return ""
+}
+
+type HistoryPoint struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"` // начало интервала
+	Min           float64                `protobuf:"fixed64,2,opt,name=min,proto3" json:"min,omitempty"`
+	Max           float64                `protobuf:"fixed64,3,opt,name=max,proto3" json:"max,omitempty"`
+	Last          float64                `protobuf:"fixed64,4,opt,name=last,proto3" json:"last,omitempty"` // последний баланс в интервале
+	Samples       int32                  `protobuf:"varint,5,opt,name=samples,proto3" json:"samples,omitempty"`
+	Spend         float64                `protobuf:"fixed64,6,opt,name=spend,proto3" json:"spend,omitempty"`              // сумма снижений баланса
+	TopUp         float64                `protobuf:"fixed64,7,opt,name=top_up,json=topUp,proto3" json:"top_up,omitempty"` // сумма пополнений
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryPoint) Reset() {
+	*x = HistoryPoint{}
+	mi := &file_balance_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryPoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryPoint) ProtoMessage() {}
+
+func (x *HistoryPoint) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryPoint.ProtoReflect.Descriptor instead.
+func (*HistoryPoint) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *HistoryPoint) GetStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *HistoryPoint) GetMin() float64 {
+	if x != nil {
+		return x.Min
+	}
+	return 0
+}
+
+func (x *HistoryPoint) GetMax() float64 {
+	if x != nil {
+		return x.Max
+	}
+	return 0
+}
+
+func (x *HistoryPoint) GetLast() float64 {
+	if x != nil {
+		return x.Last
+	}
+	return 0
+}
+
+func (x *HistoryPoint) GetSamples() int32 {
+	if x != nil {
+		return x.Samples
+	}
+	return 0
+}
+
+func (x *HistoryPoint) GetSpend() float64 {
+	if x != nil {
+		return x.Spend
+	}
+	return 0
+}
+
+func (x *HistoryPoint) GetTopUp() float64 {
+	if x != nil {
+		return x.TopUp
+	}
+	return 0
+}
+
+type HistoryReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Network       string                 `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	Partner       string                 `protobuf:"bytes,2,opt,name=partner,proto3" json:"partner,omitempty"`
+	Step          string                 `protobuf:"bytes,3,opt,name=step,proto3" json:"step,omitempty"`
+	Points        []*HistoryPoint        `protobuf:"bytes,4,rep,name=points,proto3" json:"points,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryReply) Reset() {
+	*x = HistoryReply{}
+	mi := &file_balance_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryReply) ProtoMessage() {}
+
+func (x *HistoryReply) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryReply.ProtoReflect.Descriptor instead.
+func (*HistoryReply) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *HistoryReply) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *HistoryReply) GetPartner() string {
+	if x != nil {
+		return x.Partner
+	}
+	return ""
+}
+
+func (x *HistoryReply) GetStep() string {
+	if x != nil {
+		return x.Step
+	}
+	return ""
+}
+
+func (x *HistoryReply) GetPoints() []*HistoryPoint {
+	if x != nil {
+		return x.Points
+	}
+	return nil
+}
+
+type SubscribeAlertsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// сети, события которых нужны; пусто — все сети
+	Networks []string `protobuf:"bytes,1,rep,name=networks,proto3" json:"networks,omitempty"`
+	// ID последнего полученного события; 0 — только новые события
+	AfterId       int64 `protobuf:"varint,2,opt,name=after_id,json=afterId,proto3" json:"after_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeAlertsRequest) Reset() {
+	*x = SubscribeAlertsRequest{}
+	mi := &file_balance_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeAlertsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeAlertsRequest) ProtoMessage() {}
+
+func (x *SubscribeAlertsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeAlertsRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeAlertsRequest) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *SubscribeAlertsRequest) GetNetworks() []string {
+	if x != nil {
+		return x.Networks
+	}
+	return nil
+}
+
+func (x *SubscribeAlertsRequest) GetAfterId() int64 {
+	if x != nil {
+		return x.AfterId
+	}
+	return 0
+}
+
+type AlertEvent struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Id      int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Network string                 `protobuf:"bytes,2,opt,name=network,proto3" json:"network,omitempty"`
+	Partner string                 `protobuf:"bytes,3,opt,name=partner,proto3" json:"partner,omitempty"`
+	// min_balance, min_runway_hours, spend_multiplier или drop_percent
+	Rule string `protobuf:"bytes,4,opt,name=rule,proto3" json:"rule,omitempty"`
+	// fire, remind или resolve
+	Kind   string  `protobuf:"bytes,5,opt,name=kind,proto3" json:"kind,omitempty"`
+	Reason string  `protobuf:"bytes,6,opt,name=reason,proto3" json:"reason,omitempty"`
+	Value  float64 `protobuf:"fixed64,7,opt,name=value,proto3" json:"value,omitempty"`
+	Limit  float64 `protobuf:"fixed64,8,opt,name=limit,proto3" json:"limit,omitempty"`
+	// начало срабатывания, к которому относится событие
+	Since         *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=since,proto3" json:"since,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AlertEvent) Reset() {
+	*x = AlertEvent{}
+	mi := &file_balance_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AlertEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AlertEvent) ProtoMessage() {}
+
+func (x *AlertEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AlertEvent.ProtoReflect.Descriptor instead.
+func (*AlertEvent) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *AlertEvent) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *AlertEvent) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *AlertEvent) GetPartner() string {
+	if x != nil {
+		return x.Partner
+	}
+	return ""
+}
+
+func (x *AlertEvent) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *AlertEvent) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *AlertEvent) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *AlertEvent) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *AlertEvent) GetLimit() float64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *AlertEvent) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+func (x *AlertEvent) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type TopUpsRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Network string                 `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	// пусто — все партнёры сети
+	Partner       string                 `protobuf:"bytes,2,opt,name=partner,proto3" json:"partner,omitempty"`
+	From          *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=from,proto3" json:"from,omitempty"` // по умолчанию to минус 7 дней
+	To            *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=to,proto3" json:"to,omitempty"`     // по умолчанию текущее время
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TopUpsRequest) Reset() {
+	*x = TopUpsRequest{}
+	mi := &file_balance_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TopUpsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TopUpsRequest) ProtoMessage() {}
+
+func (x *TopUpsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TopUpsRequest.ProtoReflect.Descriptor instead.
+func (*TopUpsRequest) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *TopUpsRequest) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *TopUpsRequest) GetPartner() string {
+	if x != nil {
+		return x.Partner
+	}
+	return ""
+}
+
+func (x *TopUpsRequest) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *TopUpsRequest) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+type TopUp struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Id      int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Partner string                 `protobuf:"bytes,2,opt,name=partner,proto3" json:"partner,omitempty"`
+	// оценка времени пополнения — середина интервала между замерами
+	At *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=at,proto3" json:"at,omitempty"`
+	// оценка суммы: прирост баланса плюс расход за интервал
+	Amount float64 `protobuf:"fixed64,4,opt,name=amount,proto3" json:"amount,omitempty"`
+	// прирост баланса между замерами
+	Delta         float64                `protobuf:"fixed64,5,opt,name=delta,proto3" json:"delta,omitempty"`
+	PrevBalance   float64                `protobuf:"fixed64,6,opt,name=prev_balance,json=prevBalance,proto3" json:"prev_balance,omitempty"`
+	Balance       float64                `protobuf:"fixed64,7,opt,name=balance,proto3" json:"balance,omitempty"`
+	DetectedAt    *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=detected_at,json=detectedAt,proto3" json:"detected_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TopUp) Reset() {
+	*x = TopUp{}
+	mi := &file_balance_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TopUp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TopUp) ProtoMessage() {}
+
+func (x *TopUp) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TopUp.ProtoReflect.Descriptor instead.
+func (*TopUp) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *TopUp) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *TopUp) GetPartner() string {
+	if x != nil {
+		return x.Partner
+	}
+	return ""
+}
+
+func (x *TopUp) GetAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.At
+	}
+	return nil
+}
+
+func (x *TopUp) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *TopUp) GetDelta() float64 {
+	if x != nil {
+		return x.Delta
+	}
+	return 0
+}
+
+func (x *TopUp) GetPrevBalance() float64 {
+	if x != nil {
+		return x.PrevBalance
+	}
+	return 0
+}
+
+func (x *TopUp) GetBalance() float64 {
+	if x != nil {
+		return x.Balance
+	}
+	return 0
+}
+
+func (x *TopUp) GetDetectedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DetectedAt
+	}
+	return nil
+}
+
+type TopUpSummary struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Partner       string                 `protobuf:"bytes,1,opt,name=partner,proto3" json:"partner,omitempty"`
+	Count         int32                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	Total         float64                `protobuf:"fixed64,3,opt,name=total,proto3" json:"total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TopUpSummary) Reset() {
+	*x = TopUpSummary{}
+	mi := &file_balance_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TopUpSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TopUpSummary) ProtoMessage() {}
+
+func (x *TopUpSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TopUpSummary.ProtoReflect.Descriptor instead.
+func (*TopUpSummary) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *TopUpSummary) GetPartner() string {
+	if x != nil {
+		return x.Partner
+	}
+	return ""
+}
+
+func (x *TopUpSummary) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *TopUpSummary) GetTotal() float64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+type TopUpsReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Network       string                 `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	From          *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To            *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	TopUps        []*TopUp               `protobuf:"bytes,4,rep,name=top_ups,json=topUps,proto3" json:"top_ups,omitempty"`
+	Summaries     []*TopUpSummary        `protobuf:"bytes,5,rep,name=summaries,proto3" json:"summaries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TopUpsReply) Reset() {
+	*x = TopUpsReply{}
+	mi := &file_balance_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TopUpsReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TopUpsReply) ProtoMessage() {}
+
+func (x *TopUpsReply) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TopUpsReply.ProtoReflect.Descriptor instead.
+func (*TopUpsReply) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *TopUpsReply) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *TopUpsReply) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *TopUpsReply) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *TopUpsReply) GetTopUps() []*TopUp {
+	if x != nil {
+		return x.TopUps
+	}
+	return nil
+}
+
+func (x *TopUpsReply) GetSummaries() []*TopUpSummary {
+	if x != nil {
+		return x.Summaries
+	}
+	return nil
+}
+
+type LastCollectionRunRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// последний запуск, в котором собиралась сеть, только с её партнёрами; пусто — последний запуск целиком
+	Network       string `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LastCollectionRunRequest) Reset() {
+	*x = LastCollectionRunRequest{}
+	mi := &file_balance_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LastCollectionRunRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LastCollectionRunRequest) ProtoMessage() {}
+
+func (x *LastCollectionRunRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LastCollectionRunRequest.ProtoReflect.Descriptor instead.
+func (*LastCollectionRunRequest) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *LastCollectionRunRequest) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+// итог сбора баланса одного партнёра
+type CollectionResult struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Network    string                 `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	Partner    string                 `protobuf:"bytes,2,opt,name=partner,proto3" json:"partner,omitempty"`
+	Ok         bool                   `protobuf:"varint,3,opt,name=ok,proto3" json:"ok,omitempty"`
+	Balance    float64                `protobuf:"fixed64,4,opt,name=balance,proto3" json:"balance,omitempty"`
+	DurationMs int64                  `protobuf:"varint,5,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	// класс ошибки: timeout, http, network, parse, circuit_open, ...; пусто при успехе
+	ErrorClass    string `protobuf:"bytes,6,opt,name=error_class,json=errorClass,proto3" json:"error_class,omitempty"`
+	Error         string `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CollectionResult) Reset() {
+	*x = CollectionResult{}
+	mi := &file_balance_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CollectionResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CollectionResult) ProtoMessage() {}
+
+func (x *CollectionResult) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CollectionResult.ProtoReflect.Descriptor instead.
+func (*CollectionResult) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *CollectionResult) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *CollectionResult) GetPartner() string {
+	if x != nil {
+		return x.Partner
+	}
+	return ""
+}
+
+func (x *CollectionResult) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *CollectionResult) GetBalance() float64 {
+	if x != nil {
+		return x.Balance
+	}
+	return 0
+}
+
+func (x *CollectionResult) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *CollectionResult) GetErrorClass() string {
+	if x != nil {
+		return x.ErrorClass
+	}
+	return ""
+}
+
+func (x *CollectionResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type CollectionRun struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// расписание, по которому запущен сбор
+	Schedule      string                 `protobuf:"bytes,2,opt,name=schedule,proto3" json:"schedule,omitempty"`
+	StartedAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	FinishedAt    *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`
+	Succeeded     int32                  `protobuf:"varint,5,opt,name=succeeded,proto3" json:"succeeded,omitempty"`
+	Failed        int32                  `protobuf:"varint,6,opt,name=failed,proto3" json:"failed,omitempty"`
+	Results       []*CollectionResult    `protobuf:"bytes,7,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CollectionRun) Reset() {
+	*x = CollectionRun{}
+	mi := &file_balance_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CollectionRun) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CollectionRun) ProtoMessage() {}
+
+func (x *CollectionRun) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CollectionRun.ProtoReflect.Descriptor instead.
+func (*CollectionRun) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *CollectionRun) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *CollectionRun) GetSchedule() string {
+	if x != nil {
+		return x.Schedule
+	}
+	return ""
+}
+
+func (x *CollectionRun) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *CollectionRun) GetFinishedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FinishedAt
+	}
+	return nil
+}
+
+func (x *CollectionRun) GetSucceeded() int32 {
+	if x != nil {
+		return x.Succeeded
+	}
+	return 0
+}
+
+func (x *CollectionRun) GetFailed() int32 {
+	if x != nil {
+		return x.Failed
+	}
+	return 0
+}
+
+func (x *CollectionRun) GetResults() []*CollectionResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type PartnerHealthRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// оставить в ответе только партнёров сети; пусто — все
+	Network       string `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PartnerHealthRequest) Reset() {
+	*x = PartnerHealthRequest{}
+	mi := &file_balance_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PartnerHealthRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PartnerHealthRequest) ProtoMessage() {}
+
+func (x *PartnerHealthRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PartnerHealthRequest.ProtoReflect.Descriptor instead.
+func (*PartnerHealthRequest) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *PartnerHealthRequest) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+// здоровье API партнёра по последним запросам баланса; незаданное время — события не было
+type PartnerHealth struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Network string                 `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	Partner string                 `protobuf:"bytes,2,opt,name=partner,proto3" json:"partner,omitempty"`
+	// состояние размыкателя цепи: closed, open, half_open
+	State               string                 `protobuf:"bytes,3,opt,name=state,proto3" json:"state,omitempty"`
+	LastSuccess         *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=last_success,json=lastSuccess,proto3" json:"last_success,omitempty"`
+	LastFailure         *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=last_failure,json=lastFailure,proto3" json:"last_failure,omitempty"`
+	LastError           string                 `protobuf:"bytes,6,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	ConsecutiveFailures int32                  `protobuf:"varint,7,opt,name=consecutive_failures,json=consecutiveFailures,proto3" json:"consecutive_failures,omitempty"`
+	// первая ошибка текущей серии
+	FailingSince *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=failing_since,json=failingSince,proto3" json:"failing_since,omitempty"`
+	// когда цепь разомкнулась в последний раз
+	OpenedAt      *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=opened_at,json=openedAt,proto3" json:"opened_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PartnerHealth) Reset() {
+	*x = PartnerHealth{}
+	mi := &file_balance_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PartnerHealth) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PartnerHealth) ProtoMessage() {}
+
+func (x *PartnerHealth) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PartnerHealth.ProtoReflect.Descriptor instead.
+func (*PartnerHealth) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *PartnerHealth) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *PartnerHealth) GetPartner() string {
+	if x != nil {
+		return x.Partner
+	}
+	return ""
+}
+
+func (x *PartnerHealth) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *PartnerHealth) GetLastSuccess() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastSuccess
+	}
+	return nil
+}
+
+func (x *PartnerHealth) GetLastFailure() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastFailure
+	}
+	return nil
+}
+
+func (x *PartnerHealth) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+func (x *PartnerHealth) GetConsecutiveFailures() int32 {
+	if x != nil {
+		return x.ConsecutiveFailures
+	}
+	return 0
+}
+
+func (x *PartnerHealth) GetFailingSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FailingSince
+	}
+	return nil
+}
+
+func (x *PartnerHealth) GetOpenedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OpenedAt
+	}
+	return nil
+}
+
+type PartnerHealthReply struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// только партнёры, к которым были запросы с момента старта сервиса
+	Partners      []*PartnerHealth `protobuf:"bytes,1,rep,name=partners,proto3" json:"partners,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PartnerHealthReply) Reset() {
+	*x = PartnerHealthReply{}
+	mi := &file_balance_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PartnerHealthReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PartnerHealthReply) ProtoMessage() {}
+
+func (x *PartnerHealthReply) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PartnerHealthReply.ProtoReflect.Descriptor instead.
+func (*PartnerHealthReply) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *PartnerHealthReply) GetPartners() []*PartnerHealth {
+	if x != nil {
+		return x.Partners
+	}
+	return nil
+}
+
+var File_balance_proto protoreflect.FileDescriptor
+
+const file_balance_proto_rawDesc = "" +
+	"\n" +
+	"\rbalance.proto\x12\agateway\x1a\x1fgoogle/protobuf/timestamp.proto\"S\n" +
+	"\vStatRequest\x12\x18\n" +
+	"\anetwork\x18\x01 \x01(\tR\anetwork\x12\x12\n" +
+	"\x04user\x18\x02 \x01(\tR\x04user\x12\x16\n" +
+	"\x06format\x18\x03 \x01(\tR\x06format\"\x90\x01\n" +
+	"\tStatReply\x12\x12\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\x12\x18\n" +
+	"\anetwork\x18\x02 \x01(\tR\anetwork\x126\n" +
+	"\tforecasts\x18\x03 \x03(\v2\x18.gateway.PartnerForecastR\tforecasts\x12\x1d\n" +
+	"\n" +
+	"parse_mode\x18\x04 \x01(\tR\tparseMode\"\x8b\x02\n" +
+	"\x0fPartnerForecast\x12\x18\n" +
+	"\apartner\x18\x01 \x01(\tR\apartner\x12\x18\n" +
+	"\abalance\x18\x02 \x01(\x01R\abalance\x12\"\n" +
+	"\rspend_per_day\x18\x03 \x01(\x01R\vspendPerDay\x12\x14\n" +
+	"\x05known\x18\x04 \x01(\bR\x05known\x12\x1d\n" +
+	"\n" +
+	"hours_left\x18\x05 \x01(\x01R\thoursLeft\x123\n" +
+	"\azero_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\x06zeroAt\x126\n" +
+	"\ttop_up_by\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\atopUpBy\"V\n" +
+	"\x0eNetworkRequest\x12\x18\n" +
+	"\anetwork\x18\x01 \x01(\tR\anetwork\x12\x12\n" +
+	"\x04user\x18\x02 \x01(\tR\x04user\x12\x16\n" +
+	"\x06format\x18\x03 \x01(\tR\x06format\"\xf9\x05\n" +
+	"\x0ePartnerBalance\x12\x18\n" +
+	"\apartner\x18\x01 \x01(\tR\apartner\x12\x18\n" +
+	"\abalance\x18\x02 \x01(\x01R\abalance\x12\x1a\n" +
+	"\bcurrency\x18\x03 \x01(\tR\bcurrency\x129\n" +
+	"\n" +
+	"fetched_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tfetchedAt\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\x12\x1b\n" +
+	"\thas_stats\x18\x06 \x01(\bR\bhasStats\x12\"\n" +
+	"\rspend_per_day\x18\a \x01(\x01R\vspendPerDay\x12\x1c\n" +
+	"\tthreshold\x18\b \x01(\x01R\tthreshold\x12\x14\n" +
+	"\x05alert\x18\t \x01(\bR\x05alert\x124\n" +
+	"\bforecast\x18\n" +
+	" \x01(\v2\x18.gateway.PartnerForecastR\bforecast\x12+\n" +
+	"\x06alerts\x18\v \x03(\v2\x13.gateway.FiredAlertR\x06alerts\x12-\n" +
+	"\x12reporting_currency\x18\f \x01(\tR\x11reportingCurrency\x12+\n" +
+	"\x11reporting_balance\x18\r \x01(\x01R\x10reportingBalance\x125\n" +
+	"\x17reporting_spend_per_day\x18\x0e \x01(\x01R\x14reportingSpendPerDay\x12G\n" +
+	"\x11unavailable_since\x18\x0f \x01(\v2\x1a.google.protobuf.TimestampR\x10unavailableSince\x12F\n" +
+	"\x11last_collected_at\x18\x10 \x01(\v2\x1a.google.protobuf.TimestampR\x0flastCollectedAt\x12-\n" +
+	"\x12failed_collections\x18\x11 \x01(\x05R\x11failedCollections\x12\x1b\n" +
+	"\ttimed_out\x18\x12 \x01(\bR\btimedOut\"d\n" +
+	"\n" +
+	"FiredAlert\x12\x12\n" +
+	"\x04rule\x18\x01 \x01(\tR\x04rule\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x01R\x05limit\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\"\xd0\x01\n" +
+	"\rBalancesReply\x12\x18\n" +
+	"\anetwork\x18\x01 \x01(\tR\anetwork\x12=\n" +
+	"\fgenerated_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\vgeneratedAt\x123\n" +
+	"\bpartners\x18\x03 \x03(\v2\x17.gateway.PartnerBalanceR\bpartners\x12\x12\n" +
+	"\x04text\x18\x04 \x01(\tR\x04text\x12\x1d\n" +
+	"\n" +
+	"parse_mode\x18\x05 \x01(\tR\tparseMode\"\x1a\n" +
+	"\x18ListReportFormatsRequest\"A\n" +
+	"\fReportFormat\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1d\n" +
+	"\n" +
+	"parse_mode\x18\x02 \x01(\tR\tparseMode\"I\n" +
+	"\x16ListReportFormatsReply\x12/\n" +
+	"\aformats\x18\x01 \x03(\v2\x15.gateway.ReportFormatR\aformats\"\x15\n" +
+	"\x13ListNetworksRequest\"9\n" +
+	"\aNetwork\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1a\n" +
+	"\bpartners\x18\x02 \x03(\tR\bpartners\"A\n" +
+	"\x11ListNetworksReply\x12,\n" +
+	"\bnetworks\x18\x01 \x03(\v2\x10.gateway.NetworkR\bnetworks\"\xb4\x01\n" +
+	"\x0eHistoryRequest\x12\x18\n" +
+	"\anetwork\x18\x01 \x01(\tR\anetwork\x12\x18\n" +
+	"\apartner\x18\x02 \x01(\tR\apartner\x12.\n" +
+	"\x04from\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x12\x12\n" +
+	"\x04step\x18\x05 \x01(\tR\x04step\"\xbf\x01\n" +
+	"\fHistoryPoint\x120\n" +
+	"\x05start\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x05start\x12\x10\n" +
+	"\x03min\x18\x02 \x01(\x01R\x03min\x12\x10\n" +
+	"\x03max\x18\x03 \x01(\x01R\x03max\x12\x12\n" +
+	"\x04last\x18\x04 \x01(\x01R\x04last\x12\x18\n" +
+	"\asamples\x18\x05 \x01(\x05R\asamples\x12\x14\n" +
+	"\x05spend\x18\x06 \x01(\x01R\x05spend\x12\x15\n" +
+	"\x06top_up\x18\a \x01(\x01R\x05topUp\"\x85\x01\n" +
+	"\fHistoryReply\x12\x18\n" +
+	"\anetwork\x18\x01 \x01(\tR\anetwork\x12\x18\n" +
+	"\apartner\x18\x02 \x01(\tR\apartner\x12\x12\n" +
+	"\x04step\x18\x03 \x01(\tR\x04step\x12-\n" +
+	"\x06points\x18\x04 \x03(\v2\x15.gateway.HistoryPointR\x06points\"O\n" +
+	"\x16SubscribeAlertsRequest\x12\x1a\n" +
+	"\bnetworks\x18\x01 \x03(\tR\bnetworks\x12\x19\n" +
+	"\bafter_id\x18\x02 \x01(\x03R\aafterId\"\xa9\x02\n" +
+	"\n" +
+	"AlertEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x18\n" +
+	"\anetwork\x18\x02 \x01(\tR\anetwork\x12\x18\n" +
+	"\apartner\x18\x03 \x01(\tR\apartner\x12\x12\n" +
+	"\x04rule\x18\x04 \x01(\tR\x04rule\x12\x12\n" +
+	"\x04kind\x18\x05 \x01(\tR\x04kind\x12\x16\n" +
+	"\x06reason\x18\x06 \x01(\tR\x06reason\x12\x14\n" +
+	"\x05value\x18\a \x01(\x01R\x05value\x12\x14\n" +
+	"\x05limit\x18\b \x01(\x01R\x05limit\x120\n" +
+	"\x05since\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\x05since\x129\n" +
+	"\n" +
+	"created_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"\x9f\x01\n" +
+	"\rTopUpsRequest\x12\x18\n" +
+	"\anetwork\x18\x01 \x01(\tR\anetwork\x12\x18\n" +
+	"\apartner\x18\x02 \x01(\tR\apartner\x12.\n" +
+	"\x04from\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\"\x85\x02\n" +
+	"\x05TopUp\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x18\n" +
+	"\apartner\x18\x02 \x01(\tR\apartner\x12*\n" +
+	"\x02at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x02at\x12\x16\n" +
+	"\x06amount\x18\x04 \x01(\x01R\x06amount\x12\x14\n" +
+	"\x05delta\x18\x05 \x01(\x01R\x05delta\x12!\n" +
+	"\fprev_balance\x18\x06 \x01(\x01R\vprevBalance\x12\x18\n" +
+	"\abalance\x18\a \x01(\x01R\abalance\x12;\n" +
+	"\vdetected_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"detectedAt\"T\n" +
+	"\fTopUpSummary\x12\x18\n" +
+	"\apartner\x18\x01 \x01(\tR\apartner\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x05R\x05count\x12\x14\n" +
+	"\x05total\x18\x03 \x01(\x01R\x05total\"\xe1\x01\n" +
+	"\vTopUpsReply\x12\x18\n" +
+	"\anetwork\x18\x01 \x01(\tR\anetwork\x12.\n" +
+	"\x04from\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x12'\n" +
+	"\atop_ups\x18\x04 \x03(\v2\x0e.gateway.TopUpR\x06topUps\x123\n" +
+	"\tsummaries\x18\x05 \x03(\v2\x15.gateway.TopUpSummaryR\tsummaries\"4\n" +
+	"\x18LastCollectionRunRequest\x12\x18\n" +
+	"\anetwork\x18\x01 \x01(\tR\anetwork\"\xc8\x01\n" +
+	"\x10CollectionResult\x12\x18\n" +
+	"\anetwork\x18\x01 \x01(\tR\anetwork\x12\x18\n" +
+	"\apartner\x18\x02 \x01(\tR\apartner\x12\x0e\n" +
+	"\x02ok\x18\x03 \x01(\bR\x02ok\x12\x18\n" +
+	"\abalance\x18\x04 \x01(\x01R\abalance\x12\x1f\n" +
+	"\vduration_ms\x18\x05 \x01(\x03R\n" +
+	"durationMs\x12\x1f\n" +
+	"\verror_class\x18\x06 \x01(\tR\n" +
+	"errorClass\x12\x14\n" +
+	"\x05error\x18\a \x01(\tR\x05error\"\x9e\x02\n" +
+	"\rCollectionRun\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\bschedule\x18\x02 \x01(\tR\bschedule\x129\n" +
+	"\n" +
+	"started_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x12;\n" +
+	"\vfinished_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"finishedAt\x12\x1c\n" +
+	"\tsucceeded\x18\x05 \x01(\x05R\tsucceeded\x12\x16\n" +
+	"\x06failed\x18\x06 \x01(\x05R\x06failed\x123\n" +
+	"\aresults\x18\a \x03(\v2\x19.gateway.CollectionResultR\aresults\"0\n" +
+	"\x14PartnerHealthRequest\x12\x18\n" +
+	"\anetwork\x18\x01 \x01(\tR\anetwork\"\xa3\x03\n" +
+	"\rPartnerHealth\x12\x18\n" +
+	"\anetwork\x18\x01 \x01(\tR\anetwork\x12\x18\n" +
+	"\apartner\x18\x02 \x01(\tR\apartner\x12\x14\n" +
+	"\x05state\x18\x03 \x01(\tR\x05state\x12=\n" +
+	"\flast_success\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\vlastSuccess\x12=\n" +
+	"\flast_failure\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\vlastFailure\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\x06 \x01(\tR\tlastError\x121\n" +
+	"\x14consecutive_failures\x18\a \x01(\x05R\x13consecutiveFailures\x12?\n" +
+	"\rfailing_since\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\ffailingSince\x127\n" +
+	"\topened_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\bopenedAt\"H\n" +
+	"\x12PartnerHealthReply\x122\n" +
+	"\bpartners\x18\x01 \x03(\v2\x16.gateway.PartnerHealthR\bpartners2\xd2\x05\n" +
 	"\vStatService\x120\n" +
-	"\x04Stat\x12\x14.gateway.StatRequest\x1a\x12.gateway.StatReplyB\x12Z\x10/gateway;gatewayb\x06proto3"
+	"\x04Stat\x12\x14.gateway.StatRequest\x1a\x12.gateway.StatReply\x12>\n" +
+	"\vGetBalances\x12\x17.gateway.NetworkRequest\x1a\x16.gateway.BalancesReply\x12@\n" +
+	"\rGetSpendStats\x12\x17.gateway.NetworkRequest\x1a\x16.gateway.BalancesReply\x12H\n" +
+	"\fListNetworks\x12\x1c.gateway.ListNetworksRequest\x1a\x1a.gateway.ListNetworksReply\x12C\n" +
+	"\x11GetBalanceHistory\x12\x17.gateway.HistoryRequest\x1a\x15.gateway.HistoryReply\x12I\n" +
+	"\x0fSubscribeAlerts\x12\x1f.gateway.SubscribeAlertsRequest\x1a\x13.gateway.AlertEvent0\x01\x129\n" +
+	"\tGetTopUps\x12\x16.gateway.TopUpsRequest\x1a\x14.gateway.TopUpsReply\x12Q\n" +
+	"\x14GetLastCollectionRun\x12!.gateway.LastCollectionRunRequest\x1a\x16.gateway.CollectionRun\x12N\n" +
+	"\x10GetPartnerHealth\x12\x1d.gateway.PartnerHealthRequest\x1a\x1b.gateway.PartnerHealthReply\x12W\n" +
+	"\x11ListReportFormats\x12!.gateway.ListReportFormatsRequest\x1a\x1f.gateway.ListReportFormatsReplyB\x12Z\x10/gateway;gatewayb\x06proto3"
 
 var (
 	file_balance_proto_rawDescOnce sync.Once
@@ -152,19 +2263,98 @@ func file_balance_proto_rawDescGZIP() []byte {
 	return file_balance_proto_rawDescData
 }
 
-var file_balance_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_balance_proto_msgTypes = make([]protoimpl.MessageInfo, 28)
 var file_balance_proto_goTypes = []any{
-	(*StatRequest)(nil), // 0: gateway.StatRequest
-	(*StatReply)(nil),   // 1: gateway.StatReply
+	(*StatRequest)(nil),              // 0: gateway.StatRequest
+	(*StatReply)(nil),                // 1: gateway.StatReply
+	(*PartnerForecast)(nil),          // 2: gateway.PartnerForecast
+	(*NetworkRequest)(nil),           // 3: gateway.NetworkRequest
+	(*PartnerBalance)(nil),           // 4: gateway.PartnerBalance
+	(*FiredAlert)(nil),               // 5: gateway.FiredAlert
+	(*BalancesReply)(nil),            // 6: gateway.BalancesReply
+	(*ListReportFormatsRequest)(nil), // 7: gateway.ListReportFormatsRequest
+	(*ReportFormat)(nil),             // 8: gateway.ReportFormat
+	(*ListReportFormatsReply)(nil),   // 9: gateway.ListReportFormatsReply
+	(*ListNetworksRequest)(nil),      // 10: gateway.ListNetworksRequest
+	(*Network)(nil),                  // 11: gateway.Network
+	(*ListNetworksReply)(nil),        // 12: gateway.ListNetworksReply
+	(*HistoryRequest)(nil),           // 13: gateway.HistoryRequest
+	(*HistoryPoint)(nil),             // 14: gateway.HistoryPoint
+	(*HistoryReply)(nil),             // 15: gateway.HistoryReply
+	(*SubscribeAlertsRequest)(nil),   // 16: gateway.SubscribeAlertsRequest
+	(*AlertEvent)(nil),               // 17: gateway.AlertEvent
+	(*TopUpsRequest)(nil),            // 18: gateway.TopUpsRequest
+	(*TopUp)(nil),                    // 19: gateway.TopUp
+	(*TopUpSummary)(nil),             // 20: gateway.TopUpSummary
+	(*TopUpsReply)(nil),              // 21: gateway.TopUpsReply
+	(*LastCollectionRunRequest)(nil), // 22: gateway.LastCollectionRunRequest
+	(*CollectionResult)(nil),         // 23: gateway.CollectionResult
+	(*CollectionRun)(nil),            // 24: gateway.CollectionRun
+	(*PartnerHealthRequest)(nil),     // 25: gateway.PartnerHealthRequest
+	(*PartnerHealth)(nil),            // 26: gateway.PartnerHealth
+	(*PartnerHealthReply)(nil),       // 27: gateway.PartnerHealthReply
+	(*timestamppb.Timestamp)(nil),    // 28: google.protobuf.Timestamp
 }
 var file_balance_proto_depIdxs = []int32{
-	0, // 0: gateway.StatService.Stat:input_type -> gateway.StatRequest
-	1, // 1: gateway.StatService.Stat:output_type -> gateway.StatReply
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	2,  // 0: gateway.StatReply.forecasts:type_name -> gateway.PartnerForecast
+	28, // 1: gateway.PartnerForecast.zero_at:type_name -> google.protobuf.Timestamp
+	28, // 2: gateway.PartnerForecast.top_up_by:type_name -> google.protobuf.Timestamp
+	28, // 3: gateway.PartnerBalance.fetched_at:type_name -> google.protobuf.Timestamp
+	2,  // 4: gateway.PartnerBalance.forecast:type_name -> gateway.PartnerForecast
+	5,  // 5: gateway.PartnerBalance.alerts:type_name -> gateway.FiredAlert
+	28, // 6: gateway.PartnerBalance.unavailable_since:type_name -> google.protobuf.Timestamp
+	28, // 7: gateway.PartnerBalance.last_collected_at:type_name -> google.protobuf.Timestamp
+	28, // 8: gateway.BalancesReply.generated_at:type_name -> google.protobuf.Timestamp
+	4,  // 9: gateway.BalancesReply.partners:type_name -> gateway.PartnerBalance
+	8,  // 10: gateway.ListReportFormatsReply.formats:type_name -> gateway.ReportFormat
+	11, // 11: gateway.ListNetworksReply.networks:type_name -> gateway.Network
+	28, // 12: gateway.HistoryRequest.from:type_name -> google.protobuf.Timestamp
+	28, // 13: gateway.HistoryRequest.to:type_name -> google.protobuf.Timestamp
+	28, // 14: gateway.HistoryPoint.start:type_name -> google.protobuf.Timestamp
+	14, // 15: gateway.HistoryReply.points:type_name -> gateway.HistoryPoint
+	28, // 16: gateway.AlertEvent.since:type_name -> google.protobuf.Timestamp
+	28, // 17: gateway.AlertEvent.created_at:type_name -> google.protobuf.Timestamp
+	28, // 18: gateway.TopUpsRequest.from:type_name -> google.protobuf.Timestamp
+	28, // 19: gateway.TopUpsRequest.to:type_name -> google.protobuf.Timestamp
+	28, // 20: gateway.TopUp.at:type_name -> google.protobuf.Timestamp
+	28, // 21: gateway.TopUp.detected_at:type_name -> google.protobuf.Timestamp
+	28, // 22: gateway.TopUpsReply.from:type_name -> google.protobuf.Timestamp
+	28, // 23: gateway.TopUpsReply.to:type_name -> google.protobuf.Timestamp
+	19, // 24: gateway.TopUpsReply.top_ups:type_name -> gateway.TopUp
+	20, // 25: gateway.TopUpsReply.summaries:type_name -> gateway.TopUpSummary
+	28, // 26: gateway.CollectionRun.started_at:type_name -> google.protobuf.Timestamp
+	28, // 27: gateway.CollectionRun.finished_at:type_name -> google.protobuf.Timestamp
+	23, // 28: gateway.CollectionRun.results:type_name -> gateway.CollectionResult
+	28, // 29: gateway.PartnerHealth.last_success:type_name -> google.protobuf.Timestamp
+	28, // 30: gateway.PartnerHealth.last_failure:type_name -> google.protobuf.Timestamp
+	28, // 31: gateway.PartnerHealth.failing_since:type_name -> google.protobuf.Timestamp
+	28, // 32: gateway.PartnerHealth.opened_at:type_name -> google.protobuf.Timestamp
+	26, // 33: gateway.PartnerHealthReply.partners:type_name -> gateway.PartnerHealth
+	0,  // 34: gateway.StatService.Stat:input_type -> gateway.StatRequest
+	3,  // 35: gateway.StatService.GetBalances:input_type -> gateway.NetworkRequest
+	3,  // 36: gateway.StatService.GetSpendStats:input_type -> gateway.NetworkRequest
+	10, // 37: gateway.StatService.ListNetworks:input_type -> gateway.ListNetworksRequest
+	13, // 38: gateway.StatService.GetBalanceHistory:input_type -> gateway.HistoryRequest
+	16, // 39: gateway.StatService.SubscribeAlerts:input_type -> gateway.SubscribeAlertsRequest
+	18, // 40: gateway.StatService.GetTopUps:input_type -> gateway.TopUpsRequest
+	22, // 41: gateway.StatService.GetLastCollectionRun:input_type -> gateway.LastCollectionRunRequest
+	25, // 42: gateway.StatService.GetPartnerHealth:input_type -> gateway.PartnerHealthRequest
+	7,  // 43: gateway.StatService.ListReportFormats:input_type -> gateway.ListReportFormatsRequest
+	1,  // 44: gateway.StatService.Stat:output_type -> gateway.StatReply
+	6,  // 45: gateway.StatService.GetBalances:output_type -> gateway.BalancesReply
+	6,  // 46: gateway.StatService.GetSpendStats:output_type -> gateway.BalancesReply
+	12, // 47: gateway.StatService.ListNetworks:output_type -> gateway.ListNetworksReply
+	15, // 48: gateway.StatService.GetBalanceHistory:output_type -> gateway.HistoryReply
+	17, // 49: gateway.StatService.SubscribeAlerts:output_type -> gateway.AlertEvent
+	21, // 50: gateway.StatService.GetTopUps:output_type -> gateway.TopUpsReply
+	24, // 51: gateway.StatService.GetLastCollectionRun:output_type -> gateway.CollectionRun
+	27, // 52: gateway.StatService.GetPartnerHealth:output_type -> gateway.PartnerHealthReply
+	9,  // 53: gateway.StatService.ListReportFormats:output_type -> gateway.ListReportFormatsReply
+	44, // [44:54] is the sub-list for method output_type
+	34, // [34:44] is the sub-list for method input_type
+	34, // [34:34] is the sub-list for extension type_name
+	34, // [34:34] is the sub-list for extension extendee
+	0,  // [0:34] is the sub-list for field type_name
 }
 
 func init() { file_balance_proto_init() }
@@ -178,7 +2368,7 @@ func file_balance_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_balance_proto_rawDesc), len(file_balance_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   28,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
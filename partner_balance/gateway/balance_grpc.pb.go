@@ -19,16 +19,44 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	StatService_Stat_FullMethodName = "/gateway.StatService/Stat"
+	StatService_Stat_FullMethodName                 = "/gateway.StatService/Stat"
+	StatService_GetBalances_FullMethodName          = "/gateway.StatService/GetBalances"
+	StatService_GetSpendStats_FullMethodName        = "/gateway.StatService/GetSpendStats"
+	StatService_ListNetworks_FullMethodName         = "/gateway.StatService/ListNetworks"
+	StatService_GetBalanceHistory_FullMethodName    = "/gateway.StatService/GetBalanceHistory"
+	StatService_SubscribeAlerts_FullMethodName      = "/gateway.StatService/SubscribeAlerts"
+	StatService_GetTopUps_FullMethodName            = "/gateway.StatService/GetTopUps"
+	StatService_GetLastCollectionRun_FullMethodName = "/gateway.StatService/GetLastCollectionRun"
+	StatService_GetPartnerHealth_FullMethodName     = "/gateway.StatService/GetPartnerHealth"
+	StatService_ListReportFormats_FullMethodName    = "/gateway.StatService/ListReportFormats"
 )
 
 // StatServiceClient is the client API for StatService service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 //
-// получение статы
+// получение статистики
 type StatServiceClient interface {
+	// готовый HTML-отчёт для Telegram, оставлен для совместимости
 	Stat(ctx context.Context, in *StatRequest, opts ...grpc.CallOption) (*StatReply, error)
+	// текущие балансы активных партнёров сети
+	GetBalances(ctx context.Context, in *NetworkRequest, opts ...grpc.CallOption) (*BalancesReply, error)
+	// текущие балансы вместе со средним расходом, порогом и прогнозом
+	GetSpendStats(ctx context.Context, in *NetworkRequest, opts ...grpc.CallOption) (*BalancesReply, error)
+	// сети из конфига и их активные партнёры
+	ListNetworks(ctx context.Context, in *ListNetworksRequest, opts ...grpc.CallOption) (*ListNetworksReply, error)
+	// история баланса и расхода партнёра, сгруппированная по часам, дням или неделям
+	GetBalanceHistory(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (*HistoryReply, error)
+	// поток событий тревог (fire, remind, resolve) по мере их появления
+	SubscribeAlerts(ctx context.Context, in *SubscribeAlertsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AlertEvent], error)
+	// пополнения партнёров сети за период и итоги по партнёрам
+	GetTopUps(ctx context.Context, in *TopUpsRequest, opts ...grpc.CallOption) (*TopUpsReply, error)
+	// итог последнего запуска сбора балансов по расписанию
+	GetLastCollectionRun(ctx context.Context, in *LastCollectionRunRequest, opts ...grpc.CallOption) (*CollectionRun, error)
+	// здоровье API партнёров и состояние их размыкателей цепи
+	GetPartnerHealth(ctx context.Context, in *PartnerHealthRequest, opts ...grpc.CallOption) (*PartnerHealthReply, error)
+	// форматы отчётов, которые понимают Stat, GetBalances и GetSpendStats
+	ListReportFormats(ctx context.Context, in *ListReportFormatsRequest, opts ...grpc.CallOption) (*ListReportFormatsReply, error)
 }
 
 type statServiceClient struct {
@@ -49,13 +77,131 @@ func (c *statServiceClient) Stat(ctx context.Context, in *StatRequest, opts ...g
 	return out, nil
 }
 
+func (c *statServiceClient) GetBalances(ctx context.Context, in *NetworkRequest, opts ...grpc.CallOption) (*BalancesReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BalancesReply)
+	err := c.cc.Invoke(ctx, StatService_GetBalances_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *statServiceClient) GetSpendStats(ctx context.Context, in *NetworkRequest, opts ...grpc.CallOption) (*BalancesReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BalancesReply)
+	err := c.cc.Invoke(ctx, StatService_GetSpendStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *statServiceClient) ListNetworks(ctx context.Context, in *ListNetworksRequest, opts ...grpc.CallOption) (*ListNetworksReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListNetworksReply)
+	err := c.cc.Invoke(ctx, StatService_ListNetworks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *statServiceClient) GetBalanceHistory(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (*HistoryReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HistoryReply)
+	err := c.cc.Invoke(ctx, StatService_GetBalanceHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *statServiceClient) SubscribeAlerts(ctx context.Context, in *SubscribeAlertsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AlertEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &StatService_ServiceDesc.Streams[0], StatService_SubscribeAlerts_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeAlertsRequest, AlertEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StatService_SubscribeAlertsClient = grpc.ServerStreamingClient[AlertEvent]
+
+func (c *statServiceClient) GetTopUps(ctx context.Context, in *TopUpsRequest, opts ...grpc.CallOption) (*TopUpsReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TopUpsReply)
+	err := c.cc.Invoke(ctx, StatService_GetTopUps_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *statServiceClient) GetLastCollectionRun(ctx context.Context, in *LastCollectionRunRequest, opts ...grpc.CallOption) (*CollectionRun, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CollectionRun)
+	err := c.cc.Invoke(ctx, StatService_GetLastCollectionRun_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *statServiceClient) GetPartnerHealth(ctx context.Context, in *PartnerHealthRequest, opts ...grpc.CallOption) (*PartnerHealthReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PartnerHealthReply)
+	err := c.cc.Invoke(ctx, StatService_GetPartnerHealth_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *statServiceClient) ListReportFormats(ctx context.Context, in *ListReportFormatsRequest, opts ...grpc.CallOption) (*ListReportFormatsReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListReportFormatsReply)
+	err := c.cc.Invoke(ctx, StatService_ListReportFormats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // StatServiceServer is the server API for StatService service.
 // All implementations must embed UnimplementedStatServiceServer
 // for forward compatibility.
 //
-// получение статы
+// получение статистики
 type StatServiceServer interface {
+	// готовый HTML-отчёт для Telegram, оставлен для совместимости
 	Stat(context.Context, *StatRequest) (*StatReply, error)
+	// текущие балансы активных партнёров сети
+	GetBalances(context.Context, *NetworkRequest) (*BalancesReply, error)
+	// текущие балансы вместе со средним расходом, порогом и прогнозом
+	GetSpendStats(context.Context, *NetworkRequest) (*BalancesReply, error)
+	// сети из конфига и их активные партнёры
+	ListNetworks(context.Context, *ListNetworksRequest) (*ListNetworksReply, error)
+	// история баланса и расхода партнёра, сгруппированная по часам, дням или неделям
+	GetBalanceHistory(context.Context, *HistoryRequest) (*HistoryReply, error)
+	// поток событий тревог (fire, remind, resolve) по мере их появления
+	SubscribeAlerts(*SubscribeAlertsRequest, grpc.ServerStreamingServer[AlertEvent]) error
+	// пополнения партнёров сети за период и итоги по партнёрам
+	GetTopUps(context.Context, *TopUpsRequest) (*TopUpsReply, error)
+	// итог последнего запуска сбора балансов по расписанию
+	GetLastCollectionRun(context.Context, *LastCollectionRunRequest) (*CollectionRun, error)
+	// здоровье API партнёров и состояние их размыкателей цепи
+	GetPartnerHealth(context.Context, *PartnerHealthRequest) (*PartnerHealthReply, error)
+	// форматы отчётов, которые понимают Stat, GetBalances и GetSpendStats
+	ListReportFormats(context.Context, *ListReportFormatsRequest) (*ListReportFormatsReply, error)
 	mustEmbedUnimplementedStatServiceServer()
 }
 
@@ -69,6 +215,33 @@ type UnimplementedStatServiceServer struct{}
 func (UnimplementedStatServiceServer) Stat(context.Context, *StatRequest) (*StatReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Stat not implemented")
 }
+func (UnimplementedStatServiceServer) GetBalances(context.Context, *NetworkRequest) (*BalancesReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBalances not implemented")
+}
+func (UnimplementedStatServiceServer) GetSpendStats(context.Context, *NetworkRequest) (*BalancesReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSpendStats not implemented")
+}
+func (UnimplementedStatServiceServer) ListNetworks(context.Context, *ListNetworksRequest) (*ListNetworksReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListNetworks not implemented")
+}
+func (UnimplementedStatServiceServer) GetBalanceHistory(context.Context, *HistoryRequest) (*HistoryReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBalanceHistory not implemented")
+}
+func (UnimplementedStatServiceServer) SubscribeAlerts(*SubscribeAlertsRequest, grpc.ServerStreamingServer[AlertEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeAlerts not implemented")
+}
+func (UnimplementedStatServiceServer) GetTopUps(context.Context, *TopUpsRequest) (*TopUpsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTopUps not implemented")
+}
+func (UnimplementedStatServiceServer) GetLastCollectionRun(context.Context, *LastCollectionRunRequest) (*CollectionRun, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLastCollectionRun not implemented")
+}
+func (UnimplementedStatServiceServer) GetPartnerHealth(context.Context, *PartnerHealthRequest) (*PartnerHealthReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPartnerHealth not implemented")
+}
+func (UnimplementedStatServiceServer) ListReportFormats(context.Context, *ListReportFormatsRequest) (*ListReportFormatsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListReportFormats not implemented")
+}
 func (UnimplementedStatServiceServer) mustEmbedUnimplementedStatServiceServer() {}
 func (UnimplementedStatServiceServer) testEmbeddedByValue()                     {}
 
@@ -108,6 +281,161 @@ func _StatService_Stat_Handler(srv interface{}, ctx context.Context, dec func(in
 	return interceptor(ctx, in, info, handler)
 }
 
+func _StatService_GetBalances_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NetworkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatServiceServer).GetBalances(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StatService_GetBalances_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatServiceServer).GetBalances(ctx, req.(*NetworkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StatService_GetSpendStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NetworkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatServiceServer).GetSpendStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StatService_GetSpendStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatServiceServer).GetSpendStats(ctx, req.(*NetworkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StatService_ListNetworks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListNetworksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatServiceServer).ListNetworks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StatService_ListNetworks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatServiceServer).ListNetworks(ctx, req.(*ListNetworksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StatService_GetBalanceHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatServiceServer).GetBalanceHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StatService_GetBalanceHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatServiceServer).GetBalanceHistory(ctx, req.(*HistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StatService_SubscribeAlerts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeAlertsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StatServiceServer).SubscribeAlerts(m, &grpc.GenericServerStream[SubscribeAlertsRequest, AlertEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StatService_SubscribeAlertsServer = grpc.ServerStreamingServer[AlertEvent]
+
+func _StatService_GetTopUps_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TopUpsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatServiceServer).GetTopUps(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StatService_GetTopUps_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatServiceServer).GetTopUps(ctx, req.(*TopUpsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StatService_GetLastCollectionRun_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LastCollectionRunRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatServiceServer).GetLastCollectionRun(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StatService_GetLastCollectionRun_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatServiceServer).GetLastCollectionRun(ctx, req.(*LastCollectionRunRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StatService_GetPartnerHealth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PartnerHealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatServiceServer).GetPartnerHealth(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StatService_GetPartnerHealth_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatServiceServer).GetPartnerHealth(ctx, req.(*PartnerHealthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StatService_ListReportFormats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListReportFormatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatServiceServer).ListReportFormats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StatService_ListReportFormats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatServiceServer).ListReportFormats(ctx, req.(*ListReportFormatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // StatService_ServiceDesc is the grpc.ServiceDesc for StatService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -119,7 +447,45 @@ var StatService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Stat",
 			Handler:    _StatService_Stat_Handler,
 		},
+		{
+			MethodName: "GetBalances",
+			Handler:    _StatService_GetBalances_Handler,
+		},
+		{
+			MethodName: "GetSpendStats",
+			Handler:    _StatService_GetSpendStats_Handler,
+		},
+		{
+			MethodName: "ListNetworks",
+			Handler:    _StatService_ListNetworks_Handler,
+		},
+		{
+			MethodName: "GetBalanceHistory",
+			Handler:    _StatService_GetBalanceHistory_Handler,
+		},
+		{
+			MethodName: "GetTopUps",
+			Handler:    _StatService_GetTopUps_Handler,
+		},
+		{
+			MethodName: "GetLastCollectionRun",
+			Handler:    _StatService_GetLastCollectionRun_Handler,
+		},
+		{
+			MethodName: "GetPartnerHealth",
+			Handler:    _StatService_GetPartnerHealth_Handler,
+		},
+		{
+			MethodName: "ListReportFormats",
+			Handler:    _StatService_ListReportFormats_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeAlerts",
+			Handler:       _StatService_SubscribeAlerts_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "balance.proto",
 }
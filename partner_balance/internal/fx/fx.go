@@ -0,0 +1,83 @@
+// Package fx пересчитывает суммы между валютами. Источники курсов подключаются через реестр,
+// как провайдеры балансов в internal/req.
+package fx
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"partner_balance/internal/utils"
+)
+
+// Source — источник курсов валют
+type Source interface {
+	// Rate возвращает, сколько единиц валюты to стоит одна единица валюты from.
+	Rate(from, to string) (float64, error)
+}
+
+// Factory создаёт источник курсов по настройкам из конфига
+type Factory func(cfg utils.CurrencyConfig) (Source, error)
+
+var (
+	mu        sync.RWMutex
+	factories = make(map[string]Factory)
+)
+
+// Register регистрирует источник курсов под указанным именем.
+// Повторная регистрация имени — ошибка программиста.
+func Register(name string, f Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	if f == nil {
+		panic("fx.Register: фабрика не может быть nil")
+	}
+	if _, dup := factories[name]; dup {
+		panic(fmt.Sprintf("fx.Register: источник курсов %q уже зарегистрирован", name))
+	}
+	factories[name] = f
+}
+
+// Sources возвращает отсортированный список зарегистрированных источников курсов.
+func Sources() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New создаёт источник курсов, указанный в конфиге. Если источник не задан, возвращает nil:
+// суммы тогда показываются только в исходной валюте.
+func New(cfg utils.CurrencyConfig) (Source, error) {
+	if cfg.FXSource == "" {
+		return nil, nil
+	}
+	mu.RLock()
+	f, ok := factories[cfg.FXSource]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("неизвестный источник курсов: %q", cfg.FXSource)
+	}
+	return f(cfg)
+}
+
+// Convert пересчитывает сумму из валюты from в валюту to
+func Convert(src Source, amount float64, from, to string) (float64, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return amount, nil
+	}
+	if src == nil {
+		return 0, fmt.Errorf("источник курсов не настроен")
+	}
+	rate, err := src.Rate(from, to)
+	if err != nil {
+		return 0, err
+	}
+	return amount * rate, nil
+}
@@ -0,0 +1,71 @@
+package fx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"partner_balance/internal/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeRates(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "rates.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestStatic_Rate(t *testing.T) {
+	s, err := LoadStatic(writeRates(t, "base: usd\nrates:\n  eur: 0.8\n  RUB: 100\n"))
+	require.NoError(t, err)
+
+	rate, err := s.Rate("USD", "EUR")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.8, rate, 1e-9)
+
+	// кросс-курс через базовую валюту
+	rate, err = s.Rate("EUR", "RUB")
+	require.NoError(t, err)
+	assert.InDelta(t, 125.0, rate, 1e-9)
+
+	_, err = s.Rate("USD", "GBP")
+	assert.Error(t, err)
+}
+
+func TestLoadStatic_Invalid(t *testing.T) {
+	_, err := LoadStatic("")
+	assert.Error(t, err)
+
+	_, err = LoadStatic(writeRates(t, "rates:\n  EUR: 0.8\n"))
+	assert.Error(t, err)
+
+	_, err = LoadStatic(writeRates(t, "base: USD\nrates:\n  EUR: 0\n"))
+	assert.Error(t, err)
+}
+
+func TestNewAndConvert(t *testing.T) {
+	src, err := New(utils.CurrencyConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, src)
+
+	_, err = New(utils.CurrencyConfig{FXSource: "unknown"})
+	assert.Error(t, err)
+	assert.Contains(t, Sources(), "static")
+
+	src, err = New(utils.CurrencyConfig{FXSource: "static", RatesFile: writeRates(t, "base: USD\nrates:\n  RUB: 100\n")})
+	require.NoError(t, err)
+
+	amount, err := Convert(src, 5000, "rub", "USD")
+	require.NoError(t, err)
+	assert.InDelta(t, 50.0, amount, 1e-9)
+
+	// одинаковые валюты не требуют источника
+	amount, err = Convert(nil, 10, "EUR", "eur")
+	require.NoError(t, err)
+	assert.Equal(t, 10.0, amount)
+
+	_, err = Convert(nil, 10, "EUR", "USD")
+	assert.Error(t, err)
+}
@@ -0,0 +1,71 @@
+package fx
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"partner_balance/internal/utils"
+
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	Register("static", func(cfg utils.CurrencyConfig) (Source, error) {
+		return LoadStatic(cfg.RatesFile)
+	})
+}
+
+// Static — курсы из файла относительно базовой валюты, для работы без внешних сервисов.
+//
+//	base: USD
+//	rates:
+//	  EUR: 0.92   # 1 USD = 0.92 EUR
+//	  RUB: 91.5
+type Static struct {
+	Base  string             `yaml:"base"`
+	Rates map[string]float64 `yaml:"rates"`
+}
+
+// LoadStatic читает файл курсов
+func LoadStatic(path string) (*Static, error) {
+	if path == "" {
+		return nil, fmt.Errorf("не задан файл курсов rates_file")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла курсов: %v", err)
+	}
+	var s Static
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("ошибка разбора файла курсов %s: %v", path, err)
+	}
+	if s.Base == "" {
+		return nil, fmt.Errorf("в файле курсов %s не задана базовая валюта base", path)
+	}
+
+	rates := make(map[string]float64, len(s.Rates)+1)
+	for code, rate := range s.Rates {
+		if rate <= 0 {
+			return nil, fmt.Errorf("курс %s в файле %s должен быть положительным", code, path)
+		}
+		rates[strings.ToUpper(code)] = rate
+	}
+	s.Base = strings.ToUpper(s.Base)
+	rates[s.Base] = 1
+	s.Rates = rates
+	return &s, nil
+}
+
+// Rate пересчитывает курс через базовую валюту
+func (s *Static) Rate(from, to string) (float64, error) {
+	fromRate, ok := s.Rates[strings.ToUpper(from)]
+	if !ok {
+		return 0, fmt.Errorf("нет курса для валюты %q", from)
+	}
+	toRate, ok := s.Rates[strings.ToUpper(to)]
+	if !ok {
+		return 0, fmt.Errorf("нет курса для валюты %q", to)
+	}
+	return toRate / fromRate, nil
+}
@@ -0,0 +1,29 @@
+package logger
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+type runIDKey struct{}
+
+// WithRunID возвращает контекст, все записи FromContext которого помечаются run_id.
+func WithRunID(ctx context.Context, runID string) context.Context {
+	return context.WithValue(ctx, runIDKey{}, runID)
+}
+
+// RunID возвращает идентификатор запуска из контекста, пусто — не задан.
+func RunID(ctx context.Context) string {
+	id, _ := ctx.Value(runIDKey{}).(string)
+	return id
+}
+
+// FromContext возвращает запись лога с полями контекста (run_id, если задан).
+func FromContext(ctx context.Context) *logrus.Entry {
+	entry := logrus.NewEntry(Log)
+	if id := RunID(ctx); id != "" {
+		entry = entry.WithField("run_id", id)
+	}
+	return entry
+}
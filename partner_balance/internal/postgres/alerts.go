@@ -0,0 +1,136 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"partner_balance/internal/storage"
+
+	"github.com/lib/pq"
+)
+
+func (s *Store) GetAlertStates(network string) ([]storage.AlertState, error) {
+	rows, err := s.db.Query(`
+		SELECT p.partner, a.rule, a.firing, a.since, a.last_notified_at, a.reason
+		FROM alert_state a
+		JOIN partners p ON p.id = a.partner_id
+		JOIN networks n ON n.id = p.network_id
+		WHERE n.name = $1
+		ORDER BY p.partner, a.rule
+	`, network)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса состояний тревог сети %s: %v", network, err)
+	}
+	defer rows.Close()
+
+	var states []storage.AlertState
+	for rows.Next() {
+		st := storage.AlertState{Network: network}
+		var notified sql.NullTime
+		if err := rows.Scan(&st.Partner, &st.Rule, &st.Firing, &st.Since, &notified, &st.Reason); err != nil {
+			return nil, fmt.Errorf("ошибка чтения состояния тревоги: %v", err)
+		}
+		st.LastNotified = notified.Time
+		states = append(states, st)
+	}
+	return states, rows.Err()
+}
+
+func (s *Store) SaveAlert(state storage.AlertState, event *storage.AlertEvent) error {
+	partnerID, err := s.partnerID(state.Partner, state.Network)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	var notified sql.NullTime
+	if !state.LastNotified.IsZero() {
+		notified = sql.NullTime{Time: state.LastNotified, Valid: true}
+	}
+	_, err = tx.Exec(`
+		INSERT INTO alert_state (partner_id, rule, firing, since, last_notified_at, reason)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (partner_id, rule) DO UPDATE
+			SET firing = EXCLUDED.firing,
+				since = EXCLUDED.since,
+				last_notified_at = EXCLUDED.last_notified_at,
+				reason = EXCLUDED.reason
+	`, partnerID, state.Rule, state.Firing, state.Since, notified, state.Reason)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения состояния тревоги: %v", err)
+	}
+
+	if event != nil {
+		if err := insertAlertEvent(tx, partnerID, event); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка фиксации транзакции: %v", err)
+	}
+	return nil
+}
+
+// alertEventsLock — ключ pg_advisory_xact_lock, под которым пишутся события тревог
+const alertEventsLock = 0x616c657274 // "alert"
+
+// insertAlertEvent записывает событие тревоги в транзакции tx, заполняя его ID и время.
+// Подписчики продолжают поток с ID последнего полученного события, поэтому ID должны
+// становиться видимыми по возрастанию: BIGSERIAL выдаётся при вставке, а не при фиксации,
+// и без блокировки до конца транзакции событие с меньшим ID могло бы зафиксироваться позже.
+func insertAlertEvent(tx *sql.Tx, partnerID int, event *storage.AlertEvent) error {
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, alertEventsLock); err != nil {
+		return fmt.Errorf("ошибка блокировки журнала тревог: %v", err)
+	}
+	err := tx.QueryRow(`
+		INSERT INTO alert_events (partner_id, rule, kind, reason, value, limit_value, since)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`, partnerID, event.Rule, string(event.Kind), event.Reason, event.Value, event.Limit, event.Since).
+		Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("ошибка записи события тревоги: %v", err)
+	}
+	return nil
+}
+
+func (s *Store) GetAlertEvents(networks []string, afterID int64, limit int) ([]storage.AlertEvent, error) {
+	rows, err := s.db.Query(`
+		SELECT e.id, n.name, p.partner, e.rule, e.kind, e.reason, e.value, e.limit_value, e.since, e.created_at
+		FROM alert_events e
+		JOIN partners p ON p.id = e.partner_id
+		JOIN networks n ON n.id = p.network_id
+		WHERE e.id > $1 AND (cardinality($2::text[]) = 0 OR n.name = ANY($2))
+		ORDER BY e.id
+		LIMIT $3
+	`, afterID, pq.Array(networks), limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса событий тревог: %v", err)
+	}
+	defer rows.Close()
+
+	var events []storage.AlertEvent
+	for rows.Next() {
+		var e storage.AlertEvent
+		var kind string
+		if err := rows.Scan(&e.ID, &e.Network, &e.Partner, &e.Rule, &kind, &e.Reason, &e.Value, &e.Limit, &e.Since, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("ошибка чтения события тревоги: %v", err)
+		}
+		e.Kind = storage.AlertEventKind(kind)
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+func (s *Store) LastAlertEventID() (int64, error) {
+	var id int64
+	if err := s.db.QueryRow(`SELECT COALESCE(MAX(id), 0) FROM alert_events`).Scan(&id); err != nil {
+		return 0, fmt.Errorf("ошибка запроса последнего события тревоги: %v", err)
+	}
+	return id, nil
+}
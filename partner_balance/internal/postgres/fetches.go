@@ -0,0 +1,81 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"partner_balance/internal/storage"
+	"time"
+)
+
+func (s *Store) InsertFetchAttempt(attempt *storage.FetchAttempt) error {
+	partnerID, err := s.partnerID(attempt.Partner, attempt.Network)
+	if err != nil {
+		return err
+	}
+
+	var balance sql.NullFloat64
+	if attempt.OK {
+		balance = sql.NullFloat64{Float64: attempt.Balance, Valid: true}
+	}
+	httpStatus := sql.NullInt64{Int64: int64(attempt.HTTPStatus), Valid: attempt.HTTPStatus != 0}
+
+	err = s.db.QueryRow(`
+		INSERT INTO fetch_attempts (partner_id, started_at, latency_ms, ok, balance, error_class, http_status, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`, partnerID, attempt.StartedAt, attempt.Latency.Milliseconds(), attempt.OK, balance,
+		attempt.ErrorClass, httpStatus, attempt.Error).Scan(&attempt.ID)
+	if err != nil {
+		return fmt.Errorf("ошибка записи попытки сбора: %v", err)
+	}
+	return nil
+}
+
+func (s *Store) GetFetchAttempts(network string, partnerName string, since time.Time) ([]storage.FetchAttempt, error) {
+	rows, err := s.db.Query(`
+		SELECT a.id, p.partner, a.started_at, a.latency_ms, a.ok, a.balance, a.error_class, a.http_status, a.error
+		FROM fetch_attempts a
+		JOIN partners p ON p.id = a.partner_id
+		JOIN networks n ON n.id = p.network_id
+		WHERE n.name = $1 AND ($2 = '' OR p.partner = $2) AND a.started_at >= $3
+		ORDER BY a.started_at, a.id
+	`, network, partnerName, since)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса попыток сбора: %v", err)
+	}
+	defer rows.Close()
+
+	var attempts []storage.FetchAttempt
+	for rows.Next() {
+		a := storage.FetchAttempt{Network: network}
+		var (
+			latencyMs  int64
+			balance    sql.NullFloat64
+			httpStatus sql.NullInt64
+		)
+		if err := rows.Scan(&a.ID, &a.Partner, &a.StartedAt, &latencyMs, &a.OK, &balance, &a.ErrorClass, &httpStatus, &a.Error); err != nil {
+			return nil, fmt.Errorf("ошибка чтения попытки сбора: %v", err)
+		}
+		a.Latency = time.Duration(latencyMs) * time.Millisecond
+		a.Balance = balance.Float64
+		a.HTTPStatus = int(httpStatus.Int64)
+		attempts = append(attempts, a)
+	}
+	return attempts, rows.Err()
+}
+
+func (s *Store) DeleteFetchAttemptsBefore(network string, before time.Time) (int64, error) {
+	res, err := s.db.Exec(`
+		DELETE FROM fetch_attempts a
+		USING partners p, networks n
+		WHERE a.partner_id = p.id
+			AND p.network_id = n.id
+			AND n.name = $1
+			AND a.started_at < $2
+	`, network, before)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка удаления попыток сбора: %v", err)
+	}
+	deleted, _ := res.RowsAffected()
+	return deleted, nil
+}
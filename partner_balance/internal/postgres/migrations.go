@@ -0,0 +1,199 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/lib/pq"
+)
+
+//go:embed migrations
+var migrationsFS embed.FS
+
+// ключ advisory lock, чтобы два экземпляра сервиса не применяли миграции одновременно
+const migrationsLockKey = 7302514
+
+var (
+	migrationFileRe = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.sql$`)
+	networkNameRe   = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+)
+
+type migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// loadMigrations читает и сортирует по версии миграции из подкаталога migrations/<dir>
+func loadMigrations(dir string) ([]migration, error) {
+	root := path.Join("migrations", dir)
+	entries, err := fs.ReadDir(migrationsFS, root)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения каталога миграций %s: %v", root, err)
+	}
+
+	var result []migration
+	seen := make(map[int]string)
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		m := migrationFileRe.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("некорректное имя файла миграции: %s", e.Name())
+		}
+		version, _ := strconv.Atoi(m[1])
+		if prev, dup := seen[version]; dup {
+			return nil, fmt.Errorf("версия миграции %d повторяется: %s и %s", version, prev, e.Name())
+		}
+		seen[version] = e.Name()
+
+		body, err := fs.ReadFile(migrationsFS, path.Join(root, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения миграции %s: %v", e.Name(), err)
+		}
+		result = append(result, migration{Version: version, Name: m[2], SQL: string(body)})
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	return result, nil
+}
+
+// SchemaName возвращает имя схемы сети в том виде, в котором его видит Postgres
+// (имена без кавычек приводятся к нижнему регистру).
+func SchemaName(network string) string {
+	return strings.ToLower("balance_" + network)
+}
+
+// renderMigration подставляет в шаблон миграции экранированные имя схемы сети и имя сети.
+// Для общих миграций network пустой, и шаблоны в них не используются.
+func renderMigration(m migration, network string) (string, error) {
+	tmpl, err := template.New(m.Name).Option("missingkey=error").Parse(m.SQL)
+	if err != nil {
+		return "", fmt.Errorf("ошибка разбора миграции %d_%s: %v", m.Version, m.Name, err)
+	}
+	var sb strings.Builder
+	data := struct{ Schema, Network string }{
+		Schema:  pq.QuoteIdentifier(SchemaName(network)),
+		Network: pq.QuoteLiteral(network),
+	}
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("ошибка заполнения миграции %d_%s: %v", m.Version, m.Name, err)
+	}
+	return sb.String(), nil
+}
+
+// Migrate создаёт таблицу учёта миграций и применяет недостающие миграции:
+// сначала общие (migrations/global, в schema_migrations записываются с пустой сетью),
+// затем для каждой сети из списка (migrations/network).
+// Каждая миграция выполняется в своей транзакции.
+func (s *Store) Migrate(networks []string) error {
+	ctx := context.Background()
+
+	global, err := loadMigrations("global")
+	if err != nil {
+		return err
+	}
+	perNetwork, err := loadMigrations("network")
+	if err != nil {
+		return err
+	}
+
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("ошибка получения соединения для миграций: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationsLockKey); err != nil {
+		return fmt.Errorf("ошибка блокировки миграций: %v", err)
+	}
+	defer conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, migrationsLockKey)
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS public.schema_migrations (
+			network    TEXT        NOT NULL,
+			version    INTEGER     NOT NULL,
+			name       TEXT        NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (network, version)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("ошибка создания таблицы schema_migrations: %v", err)
+	}
+
+	for _, m := range global {
+		if err := applyMigration(ctx, conn, "", m); err != nil {
+			return err
+		}
+	}
+
+	for _, network := range networks {
+		if !networkNameRe.MatchString(network) {
+			return fmt.Errorf("недопустимое имя сети %q: разрешены латинские буквы, цифры и _", network)
+		}
+		for _, m := range perNetwork {
+			if err := applyMigration(ctx, conn, network, m); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// applyMigration применяет миграцию для сети, если она ещё не была применена
+func applyMigration(ctx context.Context, conn *sql.Conn, network string, m migration) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции миграции: %v", err)
+	}
+	defer tx.Rollback()
+
+	var applied bool
+	err = tx.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM public.schema_migrations WHERE network = $1 AND version = $2)`,
+		network, m.Version,
+	).Scan(&applied)
+	if err != nil {
+		return fmt.Errorf("ошибка проверки миграции %d для сети %s: %v", m.Version, network, err)
+	}
+	if applied {
+		return nil
+	}
+
+	query, err := renderMigration(m, network)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("ошибка применения миграции %d_%s для сети %s: %v", m.Version, m.Name, network, err)
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO public.schema_migrations (network, version, name) VALUES ($1, $2, $3)`,
+		network, m.Version, m.Name,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка записи версии миграции %d для сети %s: %v", m.Version, network, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка фиксации миграции %d для сети %s: %v", m.Version, network, err)
+	}
+	if network == "" {
+		log.Printf("Применена общая миграция %d_%s", m.Version, m.Name)
+	} else {
+		log.Printf("Применена миграция %d_%s для сети %s", m.Version, m.Name, network)
+	}
+	return nil
+}
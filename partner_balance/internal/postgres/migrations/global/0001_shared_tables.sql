@@ -0,0 +1,23 @@
+-- Общая модель данных: все сети в одних таблицах, связь по network_id.
+CREATE TABLE IF NOT EXISTS public.networks (
+    id   SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS public.partners (
+    id         SERIAL PRIMARY KEY,
+    network_id INTEGER NOT NULL REFERENCES public.networks (id) ON DELETE CASCADE,
+    partner    TEXT    NOT NULL,
+    is_active  BOOLEAN NOT NULL DEFAULT TRUE,
+    UNIQUE (network_id, partner)
+);
+
+CREATE TABLE IF NOT EXISTS public.balances (
+    id         BIGSERIAL PRIMARY KEY,
+    partner_id INTEGER        NOT NULL REFERENCES public.partners (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    balance    NUMERIC(10, 2) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS balances_partner_created_idx
+    ON public.balances (partner_id, created_at);
@@ -0,0 +1,10 @@
+-- Дневные агрегаты балансов для долгого хранения после удаления сырых замеров.
+CREATE TABLE IF NOT EXISTS public.balance_daily (
+    partner_id   INTEGER        NOT NULL REFERENCES public.partners (id) ON DELETE CASCADE,
+    day          DATE           NOT NULL,
+    min_balance  NUMERIC(10, 2) NOT NULL,
+    max_balance  NUMERIC(10, 2) NOT NULL,
+    last_balance NUMERIC(10, 2) NOT NULL,
+    samples      INTEGER        NOT NULL,
+    PRIMARY KEY (partner_id, day)
+);
@@ -0,0 +1,25 @@
+-- Состояние правил тревоги и журнал событий срабатывания/восстановления.
+CREATE TABLE IF NOT EXISTS public.alert_state (
+    partner_id       INTEGER     NOT NULL REFERENCES public.partners (id) ON DELETE CASCADE,
+    rule             TEXT        NOT NULL,
+    firing           BOOLEAN     NOT NULL,
+    since            TIMESTAMPTZ NOT NULL,
+    last_notified_at TIMESTAMPTZ,
+    reason           TEXT        NOT NULL DEFAULT '',
+    PRIMARY KEY (partner_id, rule)
+);
+
+CREATE TABLE IF NOT EXISTS public.alert_events (
+    id          BIGSERIAL PRIMARY KEY,
+    partner_id  INTEGER          NOT NULL REFERENCES public.partners (id) ON DELETE CASCADE,
+    rule        TEXT             NOT NULL,
+    kind        TEXT             NOT NULL,
+    reason      TEXT             NOT NULL DEFAULT '',
+    value       DOUBLE PRECISION NOT NULL DEFAULT 0,
+    limit_value DOUBLE PRECISION NOT NULL DEFAULT 0,
+    since       TIMESTAMPTZ      NOT NULL,
+    created_at  TIMESTAMPTZ      NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS alert_events_partner_idx
+    ON public.alert_events (partner_id, id);
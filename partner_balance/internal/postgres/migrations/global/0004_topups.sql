@@ -0,0 +1,18 @@
+-- Журнал пополнений, обнаруженных по росту баланса между замерами.
+CREATE TABLE IF NOT EXISTS public.topups (
+    id           BIGSERIAL PRIMARY KEY,
+    partner_id   INTEGER        NOT NULL REFERENCES public.partners (id) ON DELETE CASCADE,
+    topup_at     TIMESTAMPTZ    NOT NULL,
+    amount       NUMERIC(12, 2) NOT NULL,
+    delta        NUMERIC(12, 2) NOT NULL,
+    prev_balance NUMERIC(10, 2) NOT NULL,
+    balance      NUMERIC(10, 2) NOT NULL,
+    detected_at  TIMESTAMPTZ    NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS topups_partner_at_idx
+    ON public.topups (partner_id, topup_at);
+
+-- Не больше одного пополнения на замер партнёра: повторная проверка замера его не дублирует.
+CREATE UNIQUE INDEX IF NOT EXISTS topups_partner_detected_uniq
+    ON public.topups (partner_id, detected_at);
@@ -0,0 +1,3 @@
+-- Код валюты замера (ISO 4217), пустая строка — валюта неизвестна.
+ALTER TABLE public.balances
+    ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,7 @@
+-- Составляющие баланса и тело ответа партнёра для разбора спорных ситуаций.
+ALTER TABLE public.balances
+    ADD COLUMN IF NOT EXISTS reserved     NUMERIC(12, 2),
+    ADD COLUMN IF NOT EXISTS credit_limit NUMERIC(12, 2),
+    ADD COLUMN IF NOT EXISTS bonus        NUMERIC(12, 2),
+    ADD COLUMN IF NOT EXISTS extra        JSONB,
+    ADD COLUMN IF NOT EXISTS raw          JSONB;
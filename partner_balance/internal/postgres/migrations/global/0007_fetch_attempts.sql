@@ -0,0 +1,15 @@
+-- Журнал попыток сбора балансов: успешные и неудачные, с классом ошибки и временем ответа.
+CREATE TABLE IF NOT EXISTS public.fetch_attempts (
+    id          BIGSERIAL PRIMARY KEY,
+    partner_id  INTEGER        NOT NULL REFERENCES public.partners (id) ON DELETE CASCADE,
+    started_at  TIMESTAMPTZ    NOT NULL,
+    latency_ms  INTEGER        NOT NULL,
+    ok          BOOLEAN        NOT NULL,
+    balance     NUMERIC(12, 2),
+    error_class TEXT           NOT NULL DEFAULT '',
+    http_status INTEGER,
+    error       TEXT           NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS fetch_attempts_partner_started_idx
+    ON public.fetch_attempts (partner_id, started_at);
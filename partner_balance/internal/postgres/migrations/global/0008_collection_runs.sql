@@ -0,0 +1,16 @@
+-- Отчёты о запусках сбора балансов: итог по каждому партнёру хранится в results.
+CREATE TABLE IF NOT EXISTS public.collection_runs (
+    id          TEXT        PRIMARY KEY,
+    schedule    TEXT        NOT NULL DEFAULT '',
+    started_at  TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ NOT NULL,
+    failed      INTEGER     NOT NULL,
+    results     JSONB       NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS collection_runs_started_idx
+    ON public.collection_runs (started_at);
+
+-- поиск последнего запуска, в котором собиралась сеть: results @> '[{"network": ...}]'
+CREATE INDEX IF NOT EXISTS collection_runs_results_idx
+    ON public.collection_runs USING gin (results jsonb_path_ops);
@@ -0,0 +1,19 @@
+-- Схема сети и базовые таблицы партнёров и балансов.
+-- {{.Schema}} подставляется мигратором как экранированное имя схемы balance_<network>.
+CREATE SCHEMA IF NOT EXISTS {{.Schema}};
+
+CREATE TABLE IF NOT EXISTS {{.Schema}}.partners (
+    id        SERIAL PRIMARY KEY,
+    partner   TEXT    NOT NULL UNIQUE,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+CREATE TABLE IF NOT EXISTS {{.Schema}}.balances (
+    id         SERIAL PRIMARY KEY,
+    partner_id INTEGER        NOT NULL REFERENCES {{.Schema}}.partners (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    balance    NUMERIC(10, 2) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS balances_partner_created_idx
+    ON {{.Schema}}.balances (partner_id, created_at);
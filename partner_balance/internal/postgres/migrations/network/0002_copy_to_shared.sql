@@ -0,0 +1,18 @@
+-- Перенос данных сети из схемы balance_<network> в общие таблицы.
+-- {{.Network}} — экранированный строковый литерал с именем сети из конфига.
+INSERT INTO public.networks (name)
+VALUES ({{.Network}})
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO public.partners (network_id, partner, is_active)
+SELECT n.id, p.partner, p.is_active
+FROM {{.Schema}}.partners p
+JOIN public.networks n ON n.name = {{.Network}}
+ON CONFLICT (network_id, partner) DO NOTHING;
+
+INSERT INTO public.balances (partner_id, created_at, balance)
+SELECT sp.id, b.created_at, b.balance
+FROM {{.Schema}}.balances b
+JOIN {{.Schema}}.partners p ON p.id = b.partner_id
+JOIN public.networks n ON n.name = {{.Network}}
+JOIN public.partners sp ON sp.network_id = n.id AND sp.partner = p.partner;
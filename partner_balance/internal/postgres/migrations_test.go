@@ -0,0 +1,41 @@
+package db
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	for _, dir := range []string{"global", "network"} {
+		migrations, err := loadMigrations(dir)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, migrations)
+
+		for i, m := range migrations {
+			assert.Equal(t, i+1, m.Version, "версии миграций должны идти подряд")
+			assert.NotEmpty(t, m.SQL)
+		}
+	}
+}
+
+func TestRenderMigration(t *testing.T) {
+	migrations, err := loadMigrations("network")
+	assert.NoError(t, err)
+
+	query, err := renderMigration(migrations[0], "AdMoney")
+	assert.NoError(t, err)
+	assert.Contains(t, query, `CREATE SCHEMA IF NOT EXISTS "balance_admoney"`)
+	assert.False(t, strings.Contains(query, "{{"), "в миграции остались неподставленные шаблоны")
+}
+
+func TestRenderMigration_QuotesNetwork(t *testing.T) {
+	migrations, err := loadMigrations("network")
+	assert.NoError(t, err)
+
+	query, err := renderMigration(migrations[1], "AdMoney")
+	assert.NoError(t, err)
+	assert.Contains(t, query, `n.name = 'AdMoney'`)
+	assert.Contains(t, query, `FROM "balance_admoney".balances b`)
+}
@@ -2,10 +2,13 @@ package db
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
-	"os"
-	"log"
 	_ "github.com/lib/pq"
+	"log"
+	"os"
+	"partner_balance/internal/storage"
+	"time"
 )
 
 var DB *sql.DB
@@ -32,59 +35,65 @@ func InitDB() error {
 	return nil
 }
 
-func InsertBalance(partnerName string, balance float64, network string) error {
-	var partnerID int
-	selectQuery := fmt.Sprintf(`SELECT id FROM balance_%s.partners WHERE partner = $1`, network)
-
-	err := DB.QueryRow(selectQuery, partnerName).Scan(&partnerID)
-	if err != nil {
-		return fmt.Errorf("партнёр %s не найден: %v", partnerName, err)
-	}
+// Store — реализация storage.BalanceStore поверх Postgres
+type Store struct {
+	db *sql.DB
+}
 
-	insertQuery := fmt.Sprintf(`
-        INSERT INTO balance_%s.balances (partner_id, created_at, balance)
-        VALUES ($1, CURRENT_TIMESTAMP, $2)
-	`, network)
+var _ storage.BalanceStore = (*Store)(nil)
 
-	_, err = DB.Exec(insertQuery, partnerID, balance)
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
 
+// partnerID ищет id партнёра в общей таблице partners по имени сети и партнёра
+func (s *Store) partnerID(partnerName string, network string) (int, error) {
+	var id int
+	err := s.db.QueryRow(`
+		SELECT p.id
+		FROM partners p
+		JOIN networks n ON n.id = p.network_id
+		WHERE n.name = $1 AND p.partner = $2
+	`, network, partnerName).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%w: %s в сети %s", storage.ErrPartnerNotFound, partnerName, network)
+	}
 	if err != nil {
-		return fmt.Errorf("ошибка вставки баланса: %v", err)
+		return 0, fmt.Errorf("ошибка поиска партнёра %s в сети %s: %v", partnerName, network, err)
 	}
+	return id, nil
+}
 
-	return nil
+func (s *Store) InsertBalance(partnerName string, balance float64, network string) error {
+	return s.InsertBalanceWithCurrency(partnerName, balance, "", network)
 }
 
-func GetBalances(partnerName string, network string) ([]float64, error) {
-	
-	var partnerID int
-	selectQuery := fmt.Sprintf(`SELECT id FROM balance_%s.partners WHERE partner = $1`, network)
+func (s *Store) InsertBalanceWithCurrency(partnerName string, balance float64, currency string, network string) error {
+	return s.InsertSnapshot(partnerName, network, storage.Snapshot{Balance: balance, Currency: currency})
+}
 
-	err := DB.QueryRow(selectQuery, partnerName).Scan(&partnerID)
+func (s *Store) GetBalances(partnerName string, network string) ([]float64, error) {
+	partnerID, err := s.partnerID(partnerName, network)
 	if err != nil {
-		return nil, fmt.Errorf("партнёр %s не найден: %v", partnerName, err)
+		return nil, err
 	}
 
-	balanceQuery := fmt.Sprintf(`
+	rows, err := s.db.Query(`
 		SELECT 
 			b.balance
-		FROM balance_%s.balances b
-		JOIN balance_%s.partners p 
-			ON b.partner_id = p.id
+		FROM balances b
 		WHERE 
 			b.partner_id = $1
 			AND b.created_at >= CURRENT_DATE - INTERVAL '3 days'
 		ORDER BY 
 			b.created_at DESC
-	`, network, network)
-
-	rows, err := DB.Query(balanceQuery, partnerID)
-	if err != nil {			
+	`, partnerID)
+	if err != nil {
 		return nil, fmt.Errorf("ошибка запроса балансов: %v", err)
 	}
 
 	defer rows.Close()
-	
+
 	var balances []float64
 
 	for rows.Next() {
@@ -96,36 +105,207 @@ func GetBalances(partnerName string, network string) ([]float64, error) {
 			continue
 		}
 
-	balances = append(balances, balance)
+		balances = append(balances, balance)
 	}
-	return balances, nil
+	return balances, rows.Err()
 }
 
-func DeleteOldData(network string) error {
-	query := fmt.Sprintf(`
-	DELETE FROM balance_%s.balances
-	WHERE balance_%s.balances.created_at < CURRENT_DATE - INTERVAL '7 days'
-	`, network, network)
+func (s *Store) GetSamples(partnerName string, network string, since time.Time) ([]storage.Sample, error) {
+	partnerID, err := s.partnerID(partnerName, network)
+	if err != nil {
+		return nil, err
+	}
 
-	res, err := DB.Exec(query)
+	rows, err := s.db.Query(`
+		SELECT b.created_at, b.balance, b.currency
+		FROM balances b
+		WHERE b.partner_id = $1 AND b.created_at >= $2
+		ORDER BY b.created_at
+	`, partnerID, since)
 	if err != nil {
-		log.Printf("Ошибка запроса на удаление: %v", err)
-		return err
+		return nil, fmt.Errorf("ошибка запроса замеров: %v", err)
+	}
+	defer rows.Close()
+
+	var samples []storage.Sample
+	for rows.Next() {
+		var smp storage.Sample
+		if err := rows.Scan(&smp.CreatedAt, &smp.Balance, &smp.Currency); err != nil {
+			return nil, fmt.Errorf("ошибка чтения замера: %v", err)
+		}
+		samples = append(samples, smp)
+	}
+	return samples, rows.Err()
+}
+
+func (s *Store) BalanceHistory(partnerName string, network string, from, to time.Time, step storage.Step) ([]storage.HistoryBucket, error) {
+	if !step.Valid() {
+		return nil, fmt.Errorf("неизвестный шаг истории: %q", step)
+	}
+	partnerID, err := s.partnerID(partnerName, network)
+	if err != nil {
+		return nil, err
+	}
+
+	// date_trunc получает шаг как параметр, поэтому допустимые значения проверены выше
+	rows, err := s.db.Query(`
+		WITH s AS (
+			SELECT
+				b.created_at,
+				b.balance,
+				LAG(b.balance) OVER (ORDER BY b.created_at) AS prev
+			FROM balances b
+			WHERE b.partner_id = $1 AND b.created_at >= $2 AND b.created_at < $3
+		)
+		SELECT
+			date_trunc($4, s.created_at AT TIME ZONE $5) AS bucket,
+			MIN(s.balance),
+			MAX(s.balance),
+			(ARRAY_AGG(s.balance ORDER BY s.created_at DESC))[1],
+			COUNT(*),
+			COALESCE(SUM(GREATEST(s.prev - s.balance, 0)), 0),
+			COALESCE(SUM(GREATEST(s.balance - s.prev, 0)), 0)
+		FROM s
+		GROUP BY bucket
+		ORDER BY bucket
+	`, partnerID, from, to, string(step), pgTimeZone(from))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса истории балансов: %v", err)
+	}
+	defer rows.Close()
+
+	loc := from.Location()
+	var buckets []storage.HistoryBucket
+	for rows.Next() {
+		var b storage.HistoryBucket
+		var wall time.Time
+		if err := rows.Scan(&wall, &b.Min, &b.Max, &b.Last, &b.Samples, &b.Spend, &b.TopUp); err != nil {
+			return nil, fmt.Errorf("ошибка чтения интервала истории: %v", err)
+		}
+		// date_trunc возвращает местное время без пояса, восстанавливаем его
+		b.Start = time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), 0, 0, 0, loc)
+		buckets = append(buckets, b)
+	}
+	return buckets, rows.Err()
+}
+
+// pgTimeZone возвращает имя часового пояса, понятное Postgres.
+// time.Local не имеет IANA-имени, для него используется UTC.
+func pgTimeZone(t time.Time) string {
+	name := t.Location().String()
+	if name == "" || name == "Local" {
+		return "UTC"
+	}
+	return name
+}
+
+func (s *Store) RollupDaily(network string, before time.Time) error {
+	_, err := s.db.Exec(`
+		INSERT INTO balance_daily (partner_id, day, min_balance, max_balance, last_balance, samples)
+		SELECT
+			b.partner_id,
+			(b.created_at AT TIME ZONE $2)::date AS day,
+			MIN(b.balance),
+			MAX(b.balance),
+			(ARRAY_AGG(b.balance ORDER BY b.created_at DESC))[1],
+			COUNT(*)
+		FROM balances b
+		JOIN partners p ON p.id = b.partner_id
+		JOIN networks n ON n.id = p.network_id
+		WHERE n.name = $1 AND b.created_at < $3
+		GROUP BY b.partner_id, day
+		ON CONFLICT (partner_id, day) DO UPDATE
+			SET min_balance  = EXCLUDED.min_balance,
+				max_balance  = EXCLUDED.max_balance,
+				last_balance = EXCLUDED.last_balance,
+				samples      = EXCLUDED.samples
+	`, network, pgTimeZone(before), before)
+	if err != nil {
+		return fmt.Errorf("ошибка свёртки балансов сети %s: %v", network, err)
 	}
-	log.Printf("Успешно выполнено удаление старых данных для %s: %v", network, res)
 	return nil
 }
 
-func InsertPartner(partnerName string, network string, isActive bool) error {
-    insertQuery := fmt.Sprintf(`
-        INSERT INTO balance_%s.partners (partner, is_active)
-        VALUES ($1, $2)
-        ON CONFLICT (partner) DO UPDATE
-          SET is_active = EXCLUDED.is_active
-    `, network)
-
-    if _, err := DB.Exec(insertQuery, partnerName, isActive); err != nil {
-        return fmt.Errorf("ошибка вставки партнёра '%s' в сеть '%s': %v", partnerName, network, err)
-    }
-    return nil
-}
\ No newline at end of file
+func (s *Store) GetDailyRollups(partnerName string, network string, from time.Time) ([]storage.DailyRollup, error) {
+	partnerID, err := s.partnerID(partnerName, network)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT day, min_balance, max_balance, last_balance, samples
+		FROM balance_daily
+		WHERE partner_id = $1 AND day >= $2::date
+		ORDER BY day
+	`, partnerID, from.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса дневных агрегатов: %v", err)
+	}
+	defer rows.Close()
+
+	var rollups []storage.DailyRollup
+	for rows.Next() {
+		var r storage.DailyRollup
+		if err := rows.Scan(&r.Day, &r.Min, &r.Max, &r.Last, &r.Samples); err != nil {
+			return nil, fmt.Errorf("ошибка чтения дневного агрегата: %v", err)
+		}
+		rollups = append(rollups, r)
+	}
+	return rollups, rows.Err()
+}
+
+func (s *Store) DeleteBalancesBefore(network string, before time.Time) (int64, error) {
+	res, err := s.db.Exec(`
+		DELETE FROM balances b
+		USING partners p, networks n
+		WHERE b.partner_id = p.id
+			AND p.network_id = n.id
+			AND n.name = $1
+			AND b.created_at < $2
+	`, network, before)
+	if err != nil {
+		log.Printf("Ошибка запроса на удаление: %v", err)
+		return 0, err
+	}
+	deleted, _ := res.RowsAffected()
+	log.Printf("Успешно выполнено удаление старых данных для %s: удалено строк %d", network, deleted)
+	return deleted, nil
+}
+
+func (s *Store) DeleteRollupsBefore(network string, before time.Time) (int64, error) {
+	res, err := s.db.Exec(`
+		DELETE FROM balance_daily d
+		USING partners p, networks n
+		WHERE d.partner_id = p.id
+			AND p.network_id = n.id
+			AND n.name = $1
+			AND d.day < $2::date
+	`, network, before.Format("2006-01-02"))
+	if err != nil {
+		log.Printf("Ошибка запроса на удаление дневных агрегатов: %v", err)
+		return 0, err
+	}
+	deleted, _ := res.RowsAffected()
+	log.Printf("Удалены старые дневные агрегаты для %s: удалено строк %d", network, deleted)
+	return deleted, nil
+}
+
+// InsertPartner добавляет партнёра (и при необходимости сеть) или обновляет его активность
+func (s *Store) InsertPartner(partnerName string, network string, isActive bool) error {
+	_, err := s.db.Exec(`
+		WITH n AS (
+			INSERT INTO networks (name)
+			VALUES ($1)
+			ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+			RETURNING id
+		)
+		INSERT INTO partners (network_id, partner, is_active)
+		SELECT n.id, $2, $3 FROM n
+		ON CONFLICT (network_id, partner) DO UPDATE
+			SET is_active = EXCLUDED.is_active
+	`, network, partnerName, isActive)
+	if err != nil {
+		return fmt.Errorf("ошибка вставки партнёра '%s' в сеть '%s': %v", partnerName, network, err)
+	}
+	return nil
+}
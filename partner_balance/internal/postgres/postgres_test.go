@@ -1,10 +1,15 @@
 package db
 
 import (
+	"fmt"
 	"os"
+	"partner_balance/internal/storage"
+	"partner_balance/internal/storage/storagetest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestInitDB(t *testing.T) {
@@ -41,7 +46,7 @@ func TestInsert(t *testing.T) {
 	err := InitDB()
 	assert.NoError(t, err, "Ошибка подключения к БД")
 
-	err = InsertBalance("UebanAds", 1200, "admeking")
+	err = NewStore(DB).InsertBalance("UebanAds", 1200, "admeking")
 	assert.NoError(t, err, "Данные записаны")
 }
 
@@ -56,9 +61,78 @@ func TestGetBalances(t *testing.T) {
 	err := InitDB()
 	assert.NoError(t, err, "Ошибка подключения к БД")
 
-	data, err := GetBalances("OctoTest", "admeking")
+	data, err := NewStore(DB).GetBalances("OctoTest", "admeking")
 	assert.NoError(t, err)
 
 	testData := []float64{1000, 1200, 1400}
 	assert.Equal(t, testData, data)
 }
+
+// migratedStore подключается к тестовой базе и применяет миграции; без базы тест пропускается
+func migratedStore(t *testing.T) *Store {
+	os.Setenv("PG_HOST", "localhost")
+	os.Setenv("PG_PORT", "5432")
+	os.Setenv("PG_USER", "user")
+	os.Setenv("PG_PASSWORD", "password")
+	os.Setenv("PG_DBNAME", "blocker")
+
+	if err := InitDB(); err != nil {
+		t.Skipf("Postgres недоступен: %v", err)
+	}
+	store := NewStore(DB)
+	if err := store.Migrate(nil); err != nil {
+		t.Fatalf("ошибка миграций: %v", err)
+	}
+	return store
+}
+
+func TestStoreConformance(t *testing.T) {
+	store := migratedStore(t)
+	storagetest.Run(t, func(t *testing.T) storage.BalanceStore {
+		return store
+	})
+}
+
+// Событие с меньшим ID не должно стать видимым после события с большим:
+// второй писатель ждёт фиксации первого, а подписчик не видит ни одного из них раньше времени.
+func TestSaveAlert_EventsVisibleInIDOrder(t *testing.T) {
+	store := migratedStore(t)
+	network := fmt.Sprintf("alert_order_%d", time.Now().UnixNano())
+	require.NoError(t, store.InsertPartner("First", network, true))
+	require.NoError(t, store.InsertPartner("Second", network, true))
+	after, err := store.LastAlertEventID()
+	require.NoError(t, err)
+
+	firstID, err := store.partnerID("First", network)
+	require.NoError(t, err)
+	tx, err := store.db.Begin()
+	require.NoError(t, err)
+	defer tx.Rollback()
+	first := &storage.AlertEvent{Rule: "min_balance", Kind: storage.AlertFire, Since: time.Now()}
+	require.NoError(t, insertAlertEvent(tx, firstID, first))
+
+	secondDone := make(chan error, 1)
+	second := &storage.AlertEvent{Rule: "min_balance", Kind: storage.AlertFire, Since: time.Now()}
+	go func() {
+		state := storage.AlertState{Network: network, Partner: "Second", Rule: "min_balance", Firing: true, Since: second.Since}
+		secondDone <- store.SaveAlert(state, second)
+	}()
+
+	select {
+	case err := <-secondDone:
+		t.Fatalf("второй писатель не дождался фиксации первого: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	events, err := store.GetAlertEvents([]string{network}, after, 10)
+	require.NoError(t, err)
+	assert.Empty(t, events, "незафиксированные события не видны")
+
+	require.NoError(t, tx.Commit())
+	require.NoError(t, <-secondDone)
+	assert.Greater(t, second.ID, first.ID)
+
+	events, err = store.GetAlertEvents([]string{network}, after, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, []int64{first.ID, second.ID}, []int64{events[0].ID, events[1].ID})
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"partner_balance/internal/storage"
+)
+
+func (s *Store) SaveCollectionRun(run storage.CollectionRun) error {
+	results, err := json.Marshal(run.Results)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации итогов сбора: %v", err)
+	}
+	_, err = s.db.Exec(`
+		INSERT INTO collection_runs (id, schedule, started_at, finished_at, failed, results)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (id) DO UPDATE
+		SET schedule = EXCLUDED.schedule,
+			started_at = EXCLUDED.started_at,
+			finished_at = EXCLUDED.finished_at,
+			failed = EXCLUDED.failed,
+			results = EXCLUDED.results
+	`, run.ID, run.Schedule, run.StartedAt, run.FinishedAt, run.Failed(), results)
+	if err != nil {
+		return fmt.Errorf("ошибка записи запуска сбора: %v", err)
+	}
+	return nil
+}
+
+func (s *Store) LastCollectionRun(network string) (storage.CollectionRun, error) {
+	var (
+		run     storage.CollectionRun
+		results []byte
+	)
+	err := s.db.QueryRow(`
+		SELECT id, schedule, started_at, finished_at, results
+		FROM collection_runs
+		WHERE $1 = '' OR results @> jsonb_build_array(jsonb_build_object('network', $1::text))
+		ORDER BY started_at DESC, id DESC
+		LIMIT 1
+	`, network).Scan(&run.ID, &run.Schedule, &run.StartedAt, &run.FinishedAt, &results)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.CollectionRun{}, storage.ErrNoCollectionRuns
+	}
+	if err != nil {
+		return storage.CollectionRun{}, fmt.Errorf("ошибка запроса последнего запуска сбора: %v", err)
+	}
+	if err := json.Unmarshal(results, &run.Results); err != nil {
+		return storage.CollectionRun{}, fmt.Errorf("ошибка чтения итогов сбора: %v", err)
+	}
+	return run, nil
+}
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"partner_balance/internal/storage"
+	"time"
+)
+
+func (s *Store) InsertSnapshot(partnerName string, network string, snap storage.Snapshot) error {
+	partnerID, err := s.partnerID(partnerName, network)
+	if err != nil {
+		return err
+	}
+
+	extra, err := extraJSON(snap.Extra)
+	if err != nil {
+		return err
+	}
+	raw, err := rawJSON(snap.Raw)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO balances (partner_id, created_at, balance, currency, reserved, credit_limit, bonus, extra, raw)
+		VALUES ($1, CURRENT_TIMESTAMP, $2, $3, $4, $5, $6, $7, $8)
+	`, partnerID, snap.Balance, snap.Currency, snap.Reserved, snap.CreditLimit, snap.Bonus, extra, raw)
+	if err != nil {
+		return fmt.Errorf("ошибка вставки баланса: %v", err)
+	}
+	return nil
+}
+
+func (s *Store) GetSnapshots(partnerName string, network string, since time.Time) ([]storage.Snapshot, error) {
+	partnerID, err := s.partnerID(partnerName, network)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT b.created_at, b.balance, b.currency, b.reserved, b.credit_limit, b.bonus, b.extra, b.raw
+		FROM balances b
+		WHERE b.partner_id = $1 AND b.created_at >= $2
+		ORDER BY b.created_at
+	`, partnerID, since)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса снимков баланса: %v", err)
+	}
+	defer rows.Close()
+
+	var snaps []storage.Snapshot
+	for rows.Next() {
+		var snap storage.Snapshot
+		var reserved, creditLimit, bonus sql.NullFloat64
+		var extra []byte
+		if err := rows.Scan(&snap.CreatedAt, &snap.Balance, &snap.Currency, &reserved, &creditLimit, &bonus, &extra, &snap.Raw); err != nil {
+			return nil, fmt.Errorf("ошибка чтения снимка баланса: %v", err)
+		}
+		snap.Reserved = nullFloat(reserved)
+		snap.CreditLimit = nullFloat(creditLimit)
+		snap.Bonus = nullFloat(bonus)
+		if extra != nil {
+			if err := json.Unmarshal(extra, &snap.Extra); err != nil {
+				return nil, fmt.Errorf("ошибка разбора показателей снимка: %v", err)
+			}
+		}
+		snaps = append(snaps, snap)
+	}
+	return snaps, rows.Err()
+}
+
+// jsonbValue возвращает значение для колонки jsonb: пустой JSON — нетипизированный nil,
+// который lib/pq передаёт как NULL. Пустой []byte ушёл бы пустой строкой, и Postgres
+// отверг бы её как некорректный JSON.
+func jsonbValue(encoded []byte) any {
+	if len(encoded) == 0 {
+		return nil
+	}
+	return encoded
+}
+
+// extraJSON готовит дополнительные показатели снимка для jsonb: пустые — NULL
+func extraJSON(extra map[string]float64) (any, error) {
+	if len(extra) == 0 {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(extra)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка сериализации показателей: %v", err)
+	}
+	return jsonbValue(encoded), nil
+}
+
+// rawJSON готовит тело ответа для jsonb: пустое — NULL, не-JSON сохраняется как JSON-строка
+func rawJSON(raw []byte) (any, error) {
+	if len(raw) == 0 || json.Valid(raw) {
+		return jsonbValue(raw), nil
+	}
+	quoted, err := json.Marshal(string(raw))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка сериализации тела ответа: %v", err)
+	}
+	return jsonbValue(quoted), nil
+}
+
+func nullFloat(v sql.NullFloat64) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Float64
+}
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jsonbNull проверяет, что значение дойдёт до драйвера как NULL, а не как пустой []byte
+func jsonbNull(t *testing.T, v any) {
+	t.Helper()
+	converted, err := driver.DefaultParameterConverter.ConvertValue(v)
+	require.NoError(t, err)
+	assert.True(t, converted == nil, "ожидался NULL, получено %T", converted)
+}
+
+func TestExtraJSON(t *testing.T) {
+	extra, err := extraJSON(nil)
+	require.NoError(t, err)
+	jsonbNull(t, extra)
+
+	extra, err = extraJSON(map[string]float64{"hold": 12.5})
+	require.NoError(t, err)
+	assert.Equal(t, []byte(`{"hold":12.5}`), extra)
+}
+
+func TestRawJSON(t *testing.T) {
+	for _, raw := range [][]byte{nil, {}} {
+		v, err := rawJSON(raw)
+		require.NoError(t, err)
+		jsonbNull(t, v)
+	}
+
+	v, err := rawJSON([]byte(`{"balance":1}`))
+	require.NoError(t, err)
+	assert.Equal(t, []byte(`{"balance":1}`), v)
+
+	v, err = rawJSON([]byte("Bad Gateway"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte(`"Bad Gateway"`), v)
+}
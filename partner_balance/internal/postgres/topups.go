@@ -0,0 +1,56 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"partner_balance/internal/storage"
+	"time"
+)
+
+func (s *Store) InsertTopUp(topUp *storage.TopUp) error {
+	partnerID, err := s.partnerID(topUp.Partner, topUp.Network)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.QueryRow(`
+		INSERT INTO topups (partner_id, topup_at, amount, delta, prev_balance, balance, detected_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (partner_id, detected_at) DO NOTHING
+		RETURNING id
+	`, partnerID, topUp.At, topUp.Amount, topUp.Delta, topUp.PrevBalance, topUp.Balance, topUp.DetectedAt).Scan(&topUp.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.ErrTopUpExists
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка записи пополнения: %v", err)
+	}
+	return nil
+}
+
+func (s *Store) GetTopUps(network string, partnerName string, from, to time.Time) ([]storage.TopUp, error) {
+	rows, err := s.db.Query(`
+		SELECT t.id, p.partner, t.topup_at, t.amount, t.delta, t.prev_balance, t.balance, t.detected_at
+		FROM topups t
+		JOIN partners p ON p.id = t.partner_id
+		JOIN networks n ON n.id = p.network_id
+		WHERE n.name = $1 AND ($2 = '' OR p.partner = $2)
+			AND t.topup_at >= $3 AND t.topup_at < $4
+		ORDER BY t.topup_at, t.id
+	`, network, partnerName, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса пополнений: %v", err)
+	}
+	defer rows.Close()
+
+	var topUps []storage.TopUp
+	for rows.Next() {
+		t := storage.TopUp{Network: network}
+		if err := rows.Scan(&t.ID, &t.Partner, &t.At, &t.Amount, &t.Delta, &t.PrevBalance, &t.Balance, &t.DetectedAt); err != nil {
+			return nil, fmt.Errorf("ошибка чтения пополнения: %v", err)
+		}
+		topUps = append(topUps, t)
+	}
+	return topUps, rows.Err()
+}
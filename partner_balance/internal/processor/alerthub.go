@@ -0,0 +1,56 @@
+package processor
+
+import (
+	"partner_balance/internal/storage"
+	"sync"
+)
+
+// alertHub будит подписчиков, когда в хранилище появляются новые события тревог.
+// Сами события подписчики читают из хранилища, поэтому пропущенный сигнал не теряет данных.
+type alertHub struct {
+	mu   sync.Mutex
+	subs map[chan struct{}]struct{}
+}
+
+func (h *alertHub) subscribe() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	h.mu.Lock()
+	if h.subs == nil {
+		h.subs = make(map[chan struct{}]struct{})
+	}
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subs, ch)
+		h.mu.Unlock()
+	}
+}
+
+func (h *alertHub) notify() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		select {
+		case ch <- struct{}{}:
+		default: // подписчик ещё не обработал прошлый сигнал
+		}
+	}
+}
+
+// SubscribeAlerts возвращает канал, в который приходит сигнал после записи новых событий тревог,
+// и функцию отписки.
+func (p *Processor) SubscribeAlerts() (<-chan struct{}, func()) {
+	return p.hub.subscribe()
+}
+
+// AlertEvents возвращает события тревог сетей networks после afterID
+func (p *Processor) AlertEvents(networks []string, afterID int64, limit int) ([]storage.AlertEvent, error) {
+	return p.store.GetAlertEvents(networks, afterID, limit)
+}
+
+// LastAlertEventID возвращает ID последнего события тревоги
+func (p *Processor) LastAlertEventID() (int64, error) {
+	return p.store.LastAlertEventID()
+}
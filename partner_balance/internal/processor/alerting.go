@@ -0,0 +1,124 @@
+package processor
+
+import (
+	"context"
+	"partner_balance/internal/logger"
+	"partner_balance/internal/storage"
+	"partner_balance/internal/utils"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// allRules — все типы правил в порядке проверки
+var allRules = []AlertRule{RuleMinBalance, RuleMinRunway, RuleSpendMultiplier, RuleDropPercent}
+
+// CheckAlerts проверяет правила тревоги партнёров сети по последним замерам из хранилища
+// и сохраняет переходы состояний. События возвращаются только при смене состояния
+// (fire, resolve) и в виде напоминаний (remind), пока тревога открыта дольше remind_every_hours.
+// Записи в лог помечаются run_id из ctx.
+func (p *Processor) CheckAlerts(ctx context.Context, network string, partners []string, now time.Time) ([]storage.AlertEvent, error) {
+	saved, err := p.store.GetAlertStates(network)
+	if err != nil {
+		return nil, err
+	}
+	states := make(map[string]map[AlertRule]storage.AlertState)
+	for _, st := range saved {
+		if states[st.Partner] == nil {
+			states[st.Partner] = make(map[AlertRule]storage.AlertState)
+		}
+		states[st.Partner][AlertRule(st.Rule)] = st
+	}
+
+	log := logger.FromContext(ctx)
+	var events []storage.AlertEvent
+	defer func() {
+		if len(events) > 0 {
+			p.hub.notify()
+		}
+	}()
+	for _, partner := range partners {
+		in, ok := p.alertInput(network, partner, now)
+		if !ok {
+			continue
+		}
+		fired := make(map[AlertRule]FiredAlert)
+		for _, a := range EvaluateAlerts(utils.AppConfig.AlertRulesFor(network, partner), in) {
+			fired[a.Rule] = a
+		}
+
+		for _, rule := range allRules {
+			a, firing := fired[rule]
+			st, known := states[partner][rule]
+			if !known {
+				st = storage.AlertState{Network: network, Partner: partner, Rule: string(rule)}
+			}
+			event := transition(&st, a, firing, now, utils.AppConfig.Alerts.RemindEvery())
+			if event == nil {
+				continue
+			}
+			if err := p.store.SaveAlert(st, event); err != nil {
+				return events, err
+			}
+			logAlertEvent(log, *event)
+			events = append(events, *event)
+		}
+	}
+	return events, nil
+}
+
+// alertInput собирает данные для проверки правил по последним замерам партнёра
+func (p *Processor) alertInput(network, partner string, now time.Time) (AlertInput, bool) {
+	history, err := p.store.GetBalances(partner, network)
+	if err != nil || len(history) == 0 {
+		return AlertInput{}, false
+	}
+	in := AlertInput{Balance: history[0]}
+	if len(history) > 1 {
+		in.HasPrev, in.PrevBalance = true, history[1]
+	}
+	if spend, err := p.GetSpendStats(network, partner); err == nil {
+		in.HasStats = true
+		in.SpendPerDay = RoundTo(spend.PerDay, 2)
+		in.Runway = Forecast(partner, in.Balance, in.SpendPerDay, now, utils.AppConfig.TopUpLead())
+	}
+	return in, true
+}
+
+// transition меняет состояние правила и возвращает событие, если о нём нужно уведомить
+func transition(st *storage.AlertState, a FiredAlert, firing bool, now time.Time, remindEvery time.Duration) *storage.AlertEvent {
+	event := &storage.AlertEvent{
+		Network: st.Network,
+		Partner: st.Partner,
+		Rule:    st.Rule,
+		Reason:  a.Reason,
+		Value:   a.Value,
+		Limit:   a.Limit,
+	}
+	switch {
+	case firing && !st.Firing:
+		st.Firing, st.Since, st.LastNotified, st.Reason = true, now, now, a.Reason
+		event.Kind = storage.AlertFire
+	case firing && remindEvery > 0 && now.Sub(st.LastNotified) >= remindEvery:
+		st.LastNotified, st.Reason = now, a.Reason
+		event.Kind = storage.AlertRemind
+	case !firing && st.Firing:
+		// событие восстановления относится к срабатыванию, которое закончилось
+		event.Kind, event.Reason, event.Since = storage.AlertResolve, st.Reason, st.Since
+		st.Firing, st.Since = false, now
+		return event
+	default:
+		return nil
+	}
+	event.Since = st.Since
+	return event
+}
+
+func logAlertEvent(log *logrus.Entry, e storage.AlertEvent) {
+	switch e.Kind {
+	case storage.AlertResolve:
+		log.Infof("Тревога %s партнёра %s (%s) снята", e.Rule, e.Partner, e.Network)
+	default:
+		log.Warnf("Тревога %s партнёра %s (%s) [%s]: %s", e.Rule, e.Partner, e.Network, e.Kind, e.Reason)
+	}
+}
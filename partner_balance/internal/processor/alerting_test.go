@@ -0,0 +1,146 @@
+package processor
+
+import (
+	"context"
+	"partner_balance/internal/logger"
+	"partner_balance/internal/req"
+	"partner_balance/internal/storage"
+	"partner_balance/internal/storage/memory"
+	"partner_balance/internal/utils"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupAlerting настраивает сеть с одним партнёром и правилом min_balance 100
+func setupAlerting(t *testing.T, provider string) (*Processor, *memory.Store) {
+	utils.AppConfig = utils.Config{
+		Networks: map[string]map[string]utils.PartnerConfig{
+			"TestNet": {"Partner1": {Token: "t", IsActive: true, Provider: provider}},
+		},
+		Alerts: utils.AlertsConfig{
+			AlertRules:       utils.AlertRules{MinBalance: ptr(100), SpendMultiplier: ptr(0)},
+			RemindEveryHours: 6,
+		},
+	}
+	t.Cleanup(func() { utils.AppConfig = utils.Config{} })
+
+	store := memory.New()
+	require.NoError(t, store.InsertPartner("Partner1", "TestNet", true))
+	return New(store), store
+}
+
+func eventKinds(events []storage.AlertEvent) []storage.AlertEventKind {
+	var kinds []storage.AlertEventKind
+	for _, e := range events {
+		kinds = append(kinds, e.Kind)
+	}
+	return kinds
+}
+
+func TestCheckAlerts_Transitions(t *testing.T) {
+	p, store := setupAlerting(t, "partner1")
+	t0 := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+	check := func(at time.Time) []storage.AlertEvent {
+		events, err := p.CheckAlerts(context.Background(), "TestNet", []string{"Partner1"}, at)
+		require.NoError(t, err)
+		return events
+	}
+
+	require.NoError(t, store.InsertBalance("Partner1", 500, "TestNet"))
+	assert.Empty(t, check(t0))
+
+	require.NoError(t, store.InsertBalance("Partner1", 50, "TestNet"))
+	fired := check(t0)
+	require.Len(t, fired, 1)
+	assert.Equal(t, storage.AlertFire, fired[0].Kind)
+	assert.Equal(t, string(RuleMinBalance), fired[0].Rule)
+	assert.Equal(t, "баланс 50.00 ниже минимума 100.00", fired[0].Reason)
+	assert.NotZero(t, fired[0].ID)
+
+	// повторная проверка до интервала напоминания — без событий
+	assert.Empty(t, check(t0.Add(time.Hour)))
+	assert.Equal(t, []storage.AlertEventKind{storage.AlertRemind}, eventKinds(check(t0.Add(7*time.Hour))))
+	assert.Empty(t, check(t0.Add(8*time.Hour)))
+
+	require.NoError(t, store.InsertBalance("Partner1", 500, "TestNet"))
+	resolved := check(t0.Add(9 * time.Hour))
+	require.Len(t, resolved, 1)
+	assert.Equal(t, storage.AlertResolve, resolved[0].Kind)
+	assert.Equal(t, t0, resolved[0].Since)
+	assert.Greater(t, resolved[0].ID, fired[0].ID)
+	assert.Empty(t, check(t0.Add(10*time.Hour)))
+
+	states, err := store.GetAlertStates("TestNet")
+	require.NoError(t, err)
+	require.Len(t, states, 1)
+	assert.False(t, states[0].Firing)
+	assert.Equal(t, t0.Add(9*time.Hour), states[0].Since)
+}
+
+func TestBalanceInsert_ChecksAlerts(t *testing.T) {
+	req.Register("alerting-low", req.ProviderFunc(func(ctx context.Context, cfg utils.PartnerConfig) (float64, error) {
+		return 50, nil
+	}))
+	p, store := setupAlerting(t, "alerting-low")
+
+	_, err := p.BalanceInsert(context.Background(), PartnerList())
+	assert.NoError(t, err)
+
+	states, err := store.GetAlertStates("TestNet")
+	require.NoError(t, err)
+	require.Len(t, states, 1)
+	assert.True(t, states[0].Firing)
+	assert.Equal(t, string(RuleMinBalance), states[0].Rule)
+}
+
+func TestSubscribeAlerts_NotifiesOnEvents(t *testing.T) {
+	p, store := setupAlerting(t, "partner1")
+	notify, cancel := p.SubscribeAlerts()
+	defer cancel()
+
+	require.NoError(t, store.InsertBalance("Partner1", 500, "TestNet"))
+	_, err := p.CheckAlerts(context.Background(), "TestNet", []string{"Partner1"}, time.Now())
+	require.NoError(t, err)
+	select {
+	case <-notify:
+		t.Fatal("сигнал без новых событий")
+	default:
+	}
+
+	require.NoError(t, store.InsertBalance("Partner1", 50, "TestNet"))
+	_, err = p.CheckAlerts(context.Background(), "TestNet", []string{"Partner1"}, time.Now())
+	require.NoError(t, err)
+	select {
+	case <-notify:
+	default:
+		t.Fatal("нет сигнала о новом событии")
+	}
+
+	events, err := p.AlertEvents([]string{"TestNet"}, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	last, err := p.LastAlertEventID()
+	require.NoError(t, err)
+	assert.Equal(t, events[0].ID, last)
+}
+
+func TestCheckAlerts_LogsRunID(t *testing.T) {
+	p, store := setupAlerting(t, "partner1")
+	hook := logtest.NewLocal(logger.Log)
+	t.Cleanup(func() { logger.Log.ReplaceHooks(make(logrus.LevelHooks)) })
+
+	require.NoError(t, store.InsertBalance("Partner1", 50, "TestNet"))
+	ctx := logger.WithRunID(context.Background(), "run-42")
+	_, err := p.CheckAlerts(ctx, "TestNet", []string{"Partner1"}, time.Now())
+	require.NoError(t, err)
+
+	entry := hook.LastEntry()
+	require.NotNil(t, entry)
+	assert.Contains(t, entry.Message, "Тревога min_balance партнёра Partner1")
+	assert.Equal(t, "run-42", entry.Data["run_id"])
+}
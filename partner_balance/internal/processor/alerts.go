@@ -0,0 +1,90 @@
+package processor
+
+import (
+	"fmt"
+	"partner_balance/internal/utils"
+)
+
+// AlertRule — тип правила тревоги
+type AlertRule string
+
+const (
+	RuleMinBalance      AlertRule = "min_balance"
+	RuleMinRunway       AlertRule = "min_runway_hours"
+	RuleSpendMultiplier AlertRule = "spend_multiplier"
+	RuleDropPercent     AlertRule = "drop_percent"
+)
+
+// FiredAlert — сработавшее правило тревоги и причина
+type FiredAlert struct {
+	Rule   AlertRule
+	Value  float64 // проверяемое значение: баланс, часы до нуля или процент падения
+	Limit  float64 // порог правила в тех же единицах
+	Reason string  // описание для отчёта
+}
+
+// AlertInput — данные партнёра, по которым проверяются правила
+type AlertInput struct {
+	Balance     float64
+	HasStats    bool // расход посчитан, SpendPerDay и Runway заполнены
+	SpendPerDay float64
+	Runway      Runway
+	HasPrev     bool    // есть предыдущий замер
+	PrevBalance float64 // баланс предыдущего замера
+}
+
+// EvaluateAlerts проверяет все заданные правила и возвращает сработавшие.
+// Правила, которым не хватает данных (нет расхода или прошлого замера), пропускаются.
+func EvaluateAlerts(rules utils.AlertRules, in AlertInput) []FiredAlert {
+	var fired []FiredAlert
+
+	if limit, ok := ruleValue(rules.MinBalance); ok && in.Balance < limit {
+		fired = append(fired, FiredAlert{
+			Rule: RuleMinBalance, Value: in.Balance, Limit: limit,
+			Reason: fmt.Sprintf("баланс %.2f ниже минимума %.2f", in.Balance, limit),
+		})
+	}
+
+	if limit, ok := ruleValue(rules.MinRunwayHours); ok && in.HasStats && in.Runway.Known && in.Runway.HoursLeft < limit {
+		fired = append(fired, FiredAlert{
+			Rule: RuleMinRunway, Value: in.Runway.HoursLeft, Limit: limit,
+			Reason: fmt.Sprintf("баланса хватит на %.0f ч, минимум %.0f ч", in.Runway.HoursLeft, limit),
+		})
+	}
+
+	if multiplier, ok := ruleValue(rules.SpendMultiplier); ok && in.HasStats {
+		if threshold := in.SpendPerDay * multiplier; in.Balance < threshold {
+			fired = append(fired, FiredAlert{
+				Rule: RuleSpendMultiplier, Value: in.Balance, Limit: threshold,
+				Reason: fmt.Sprintf("баланс %.2f ниже расхода %.2f × %g = %.2f", in.Balance, in.SpendPerDay, multiplier, threshold),
+			})
+		}
+	}
+
+	if limit, ok := ruleValue(rules.DropPercent); ok && in.HasPrev && in.PrevBalance > 0 {
+		if drop := (in.PrevBalance - in.Balance) / in.PrevBalance * 100; drop >= limit {
+			fired = append(fired, FiredAlert{
+				Rule: RuleDropPercent, Value: drop, Limit: limit,
+				Reason: fmt.Sprintf("баланс упал на %.1f%% с %.2f, порог %g%%", drop, in.PrevBalance, limit),
+			})
+		}
+	}
+
+	return fired
+}
+
+// SpendThreshold — порог правила spend_multiplier, 0 если правило выключено
+func SpendThreshold(rules utils.AlertRules, spendPerDay float64) float64 {
+	if multiplier, ok := ruleValue(rules.SpendMultiplier); ok {
+		return spendPerDay * multiplier
+	}
+	return 0
+}
+
+// ruleValue возвращает порог правила, если оно задано и не выключено нулём
+func ruleValue(v *float64) (float64, bool) {
+	if v == nil || *v <= 0 {
+		return 0, false
+	}
+	return *v, true
+}
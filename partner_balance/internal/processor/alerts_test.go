@@ -0,0 +1,116 @@
+package processor
+
+import (
+	"partner_balance/internal/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ptr(v float64) *float64 { return &v }
+
+func TestEvaluateAlerts(t *testing.T) {
+	withStats := AlertInput{
+		Balance:     150,
+		HasStats:    true,
+		SpendPerDay: 400,
+		Runway:      Runway{Known: true, HoursLeft: 9},
+		HasPrev:     true,
+		PrevBalance: 300,
+	}
+
+	tests := []struct {
+		name  string
+		rules utils.AlertRules
+		in    AlertInput
+		fired []AlertRule
+	}{
+		{
+			name:  "no rules",
+			in:    withStats,
+			fired: nil,
+		},
+		{
+			name: "all rules fire",
+			rules: utils.AlertRules{
+				MinBalance:      ptr(200),
+				MinRunwayHours:  ptr(24),
+				SpendMultiplier: ptr(1.3),
+				DropPercent:     ptr(50),
+			},
+			in:    withStats,
+			fired: []AlertRule{RuleMinBalance, RuleMinRunway, RuleSpendMultiplier, RuleDropPercent},
+		},
+		{
+			name: "nothing fires above limits",
+			rules: utils.AlertRules{
+				MinBalance:      ptr(100),
+				MinRunwayHours:  ptr(6),
+				SpendMultiplier: ptr(0.3),
+				DropPercent:     ptr(60),
+			},
+			in:    withStats,
+			fired: nil,
+		},
+		{
+			name:  "zero disables rule",
+			rules: utils.AlertRules{MinBalance: ptr(0), SpendMultiplier: ptr(0)},
+			in:    withStats,
+			fired: nil,
+		},
+		{
+			name: "rules without data are skipped",
+			rules: utils.AlertRules{
+				MinBalance:      ptr(200),
+				MinRunwayHours:  ptr(24),
+				SpendMultiplier: ptr(1.3),
+				DropPercent:     ptr(10),
+			},
+			in:    AlertInput{Balance: 150},
+			fired: []AlertRule{RuleMinBalance},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fired []AlertRule
+			for _, a := range EvaluateAlerts(tt.rules, tt.in) {
+				assert.NotEmpty(t, a.Reason)
+				fired = append(fired, a.Rule)
+			}
+			assert.Equal(t, tt.fired, fired)
+		})
+	}
+}
+
+func TestEvaluateAlerts_Reasons(t *testing.T) {
+	alerts := EvaluateAlerts(utils.AlertRules{SpendMultiplier: ptr(1.3), DropPercent: ptr(50)}, AlertInput{
+		Balance: 150, HasStats: true, SpendPerDay: 400, HasPrev: true, PrevBalance: 300,
+	})
+	assert.Len(t, alerts, 2)
+	assert.Equal(t, "баланс 150.00 ниже расхода 400.00 × 1.3 = 520.00", alerts[0].Reason)
+	assert.InDelta(t, 520.0, alerts[0].Limit, 1e-9)
+	assert.Equal(t, "баланс упал на 50.0% с 300.00, порог 50%", alerts[1].Reason)
+}
+
+func TestAlertRulesFor(t *testing.T) {
+	cfg := utils.Config{
+		Alerts: utils.AlertsConfig{AlertRules: utils.AlertRules{MinBalance: ptr(100)}},
+		Networks: map[string]map[string]utils.PartnerConfig{
+			"TestNet": {
+				"Partner1": {Alerts: utils.AlertRules{MinBalance: ptr(500), DropPercent: ptr(30)}},
+				"Partner2": {Alerts: utils.AlertRules{SpendMultiplier: ptr(0)}},
+			},
+		},
+	}
+
+	p1 := cfg.AlertRulesFor("TestNet", "Partner1")
+	assert.Equal(t, 500.0, *p1.MinBalance)
+	assert.Equal(t, 30.0, *p1.DropPercent)
+	assert.Equal(t, utils.DefaultSpendMultiplier, *p1.SpendMultiplier)
+	assert.Nil(t, p1.MinRunwayHours)
+
+	p2 := cfg.AlertRulesFor("TestNet", "Partner2")
+	assert.Equal(t, 100.0, *p2.MinBalance)
+	assert.Equal(t, 0.0, SpendThreshold(p2, 400))
+}
@@ -0,0 +1,94 @@
+package processor
+
+import (
+	"context"
+	"partner_balance/internal/req"
+	"partner_balance/internal/utils"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// cachedSnapshot — снимок баланса партнёра и время его получения
+type cachedSnapshot struct {
+	snap req.BalanceSnapshot
+	at   time.Time
+}
+
+// snapshotCache хранит последние снимки балансов партнёров. Его наполняют сбор по расписанию
+// и обновления по запросу, одновременные обновления одного партнёра объединяются.
+type snapshotCache struct {
+	mu        sync.Mutex
+	snapshots map[healthKey]cachedSnapshot
+	refresh   singleflight.Group
+}
+
+func newSnapshotCache() *snapshotCache {
+	return &snapshotCache{snapshots: make(map[healthKey]cachedSnapshot)}
+}
+
+func (c *snapshotCache) get(key healthKey) (cachedSnapshot, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached, ok := c.snapshots[key]
+	return cached, ok
+}
+
+// put запоминает снимок, если он не старше уже сохранённого
+func (c *snapshotCache) put(key healthKey, snap req.BalanceSnapshot, at time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cached, ok := c.snapshots[key]; ok && cached.at.After(at) {
+		return
+	}
+	c.snapshots[key] = cachedSnapshot{snap: snap, at: at}
+}
+
+// snapshot возвращает снимок баланса партнёра и время его получения. Снимок моложе
+// cache.max_age_minutes берётся из кэша, иначе партнёр опрашивается заново; одновременные
+// запросы одного партнёра ждут одного обновления. Ошибки не кэшируются.
+// Отмена ctx прекращает только ожидание: общее обновление продолжается не дольше
+// report.fetch_timeout_seconds, и его результат попадает в кэш для следующих отчётов.
+func (p *Processor) snapshot(ctx context.Context, partner Partner) (req.BalanceSnapshot, time.Time, error) {
+	key := healthKey{network: partner.Network, partner: partner.Name}
+	maxAge := utils.AppConfig.CacheMaxAgeFor(partner.Network, partner.Name)
+	if cached, ok := p.cache.get(key); ok && utils.LocalNow().Sub(cached.at) < maxAge {
+		return cached.snap, cached.at, nil
+	}
+
+	ch := p.cache.refresh.DoChan(partner.Network+"\x00"+partner.Name, func() (any, error) {
+		// обновление общее для всех ожидающих, поэтому отмена одного из них его не прерывает,
+		// но и в фоне запрос к партнёру со всеми повторами живёт не дольше отчёта
+		refreshCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), utils.AppConfig.Report.FetchTimeout())
+		defer cancel()
+		snap, err := RouterSnapshot(refreshCtx, partner)
+		if err != nil {
+			return nil, err
+		}
+		at := utils.LocalNow()
+		p.cache.put(key, snap, at)
+		return cachedSnapshot{snap: snap, at: at}, nil
+	})
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return req.BalanceSnapshot{}, time.Time{}, res.Err
+		}
+		fresh := res.Val.(cachedSnapshot)
+		return fresh.snap, fresh.at, nil
+	case <-ctx.Done():
+		return req.BalanceSnapshot{}, time.Time{}, ctx.Err()
+	}
+}
+
+// asOfAfter — с какого возраста снимка отчёт показывает, на какое время баланс
+const asOfAfter = time.Minute
+
+// AsOf возвращает, на какое время снят баланс из кэша; нулевое — баланс получен только что
+func AsOf(status PartnerStatus, now time.Time) time.Time {
+	if status.FetchedAt.IsZero() || now.Sub(status.FetchedAt) < asOfAfter {
+		return time.Time{}
+	}
+	return status.FetchedAt
+}
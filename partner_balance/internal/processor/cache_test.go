@@ -0,0 +1,130 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"partner_balance/internal/req"
+	"partner_balance/internal/storage"
+	"partner_balance/internal/storage/memory"
+	"partner_balance/internal/utils"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	cacheCalls atomic.Int32
+	cacheGate  chan struct{} // пока не nil, провайдер cache-count ждёт его закрытия
+)
+
+func init() {
+	req.Register("cache-count", req.ProviderFunc(func(ctx context.Context, cfg utils.PartnerConfig) (float64, error) {
+		if cacheGate != nil {
+			<-cacheGate
+		}
+		return float64(100 * cacheCalls.Add(1)), nil
+	}))
+}
+
+// setupCache настраивает сеть CacheNet с одним партнёром и сбрасывает счётчик запросов
+func setupCache(t *testing.T, cache utils.CacheConfig, partnerCache utils.CacheConfig) *Processor {
+	freshHealth(t)
+	cacheCalls.Store(0)
+	utils.AppConfig = utils.Config{
+		Networks: map[string]map[string]utils.PartnerConfig{
+			"CacheNet": {"Cached": {Token: "t", IsActive: true, Provider: "cache-count", Cache: partnerCache}},
+		},
+		Cache: cache,
+	}
+	t.Cleanup(func() { utils.AppConfig = utils.Config{} })
+
+	store := memory.New()
+	require.NoError(t, store.InsertPartner("Cached", "CacheNet", true))
+	return New(store)
+}
+
+func TestBalances_ServedFromCollectorCache(t *testing.T) {
+	p := setupCache(t, utils.CacheConfig{}, utils.CacheConfig{})
+
+	_, err := p.BalanceInsert(context.Background(), PartnerList())
+	require.NoError(t, err)
+	require.Equal(t, int32(1), cacheCalls.Load())
+
+	for range 3 {
+		st := p.Balances(context.Background(), "CacheNet")
+		require.Len(t, st, 1)
+		assert.Equal(t, 100.0, st[0].Balance)
+	}
+	assert.Equal(t, int32(1), cacheCalls.Load(), "свежий снимок сбора не запрашивается заново")
+}
+
+// failingSnapshots — хранилище, в которое не удаётся записать снимок баланса
+type failingSnapshots struct {
+	*memory.Store
+}
+
+func (failingSnapshots) InsertSnapshot(string, string, storage.Snapshot) error {
+	return errors.New("db down")
+}
+
+func TestBalanceInsert_UnsavedSnapshotNotCached(t *testing.T) {
+	p := setupCache(t, utils.CacheConfig{}, utils.CacheConfig{})
+	p.store = failingSnapshots{p.store.(*memory.Store)}
+
+	run, err := p.BalanceInsert(context.Background(), PartnerList())
+	require.Error(t, err)
+	assert.Equal(t, ErrorClassStorage, run.Results[0].ErrorClass)
+	_, cached := p.cache.get(healthKey{network: "CacheNet", partner: "Cached"})
+	assert.False(t, cached, "несохранённый баланс не отдаётся отчётам из кэша")
+}
+
+func TestBalances_RefreshesStale(t *testing.T) {
+	p := setupCache(t, utils.CacheConfig{MaxAgeMinutes: 60}, utils.CacheConfig{MaxAgeMinutes: 5})
+	key := healthKey{network: "CacheNet", partner: "Cached"}
+	old := utils.LocalNow().Add(-10 * time.Minute)
+	p.cache.put(key, req.BalanceSnapshot{Available: 42}, old)
+
+	st := p.Balances(context.Background(), "CacheNet")
+	require.Len(t, st, 1)
+	assert.Equal(t, 100.0, st[0].Balance, "max_age партнёра перекрывает секцию cache")
+	assert.True(t, st[0].FetchedAt.After(old))
+	assert.Equal(t, int32(1), cacheCalls.Load())
+
+	p.cache.put(key, req.BalanceSnapshot{Available: 42}, old)
+	assert.Equal(t, 100.0, p.Balances(context.Background(), "CacheNet")[0].Balance, "старый снимок не вытесняет новый")
+}
+
+func TestBalances_DeduplicatesRefresh(t *testing.T) {
+	p := setupCache(t, utils.CacheConfig{}, utils.CacheConfig{})
+	cacheGate = make(chan struct{})
+	t.Cleanup(func() { cacheGate = nil })
+
+	var wg sync.WaitGroup
+	results := make([]float64, 5)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = p.Balances(context.Background(), "CacheNet")[0].Balance
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(cacheGate)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), cacheCalls.Load(), "одновременные запросы ждут одного обновления")
+	for _, b := range results {
+		assert.Equal(t, 100.0, b)
+	}
+}
+
+func TestAsOf(t *testing.T) {
+	now := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+	assert.True(t, AsOf(PartnerStatus{FetchedAt: now.Add(-10 * time.Second)}, now).IsZero())
+	assert.Equal(t, now.Add(-30*time.Minute), AsOf(PartnerStatus{FetchedAt: now.Add(-30 * time.Minute)}, now))
+	assert.True(t, AsOf(PartnerStatus{}, now).IsZero())
+}
@@ -0,0 +1,158 @@
+package processor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"partner_balance/internal/logger"
+	"partner_balance/internal/req"
+	"partner_balance/internal/storage"
+	"partner_balance/internal/utils"
+	"sort"
+	"sync"
+	"time"
+)
+
+// newRunID возвращает идентификатор запуска сбора: время начала и случайный суффикс
+func newRunID(started time.Time) string {
+	suffix := make([]byte, 3)
+	_, _ = rand.Read(suffix)
+	return started.Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// BalanceInsert собирает и сохраняет балансы партнёров переданных групп, опрашивая не больше
+// schedule.max_concurrent партнёров одновременно. После вставки по сохранённым замерам ищутся
+// пополнения и проверяются правила тревоги партнёров, баланс которых получен.
+// Возвращает отчёт о запуске; ошибка — если хотя бы один баланс не собран или ctx отменён.
+// Идентификатор запуска берётся из ctx (logger.WithRunID) или создаётся и попадает во все записи лога.
+func (p *Processor) BalanceInsert(ctx context.Context, groups []NetworkGroup) (storage.CollectionRun, error) {
+	run := storage.CollectionRun{ID: logger.RunID(ctx), StartedAt: utils.LocalNow()}
+	if run.ID == "" {
+		run.ID = newRunID(run.StartedAt)
+		ctx = logger.WithRunID(ctx, run.ID)
+	}
+	log := logger.FromContext(ctx)
+
+	type job struct {
+		network string
+		partner Partner
+	}
+	var jobs []job
+	for _, g := range groups {
+		for _, partner := range g.Partners {
+			jobs = append(jobs, job{network: g.GroupName, partner: partner})
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].network != jobs[j].network {
+			return jobs[i].network < jobs[j].network
+		}
+		return jobs[i].partner.Name < jobs[j].partner.Name
+	})
+	log.Infof("Начат сбор балансов: партнёров %d", len(jobs))
+
+	run.Results = make([]storage.CollectionResult, len(jobs))
+	slots := make(chan struct{}, utils.AppConfig.Schedule.Concurrency())
+	var wg sync.WaitGroup
+	for i, j := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+				run.Results[i] = p.collect(ctx, j.network, j.partner)
+			case <-ctx.Done():
+				run.Results[i] = storage.CollectionResult{
+					Network: j.network, Partner: j.partner.Name,
+					ErrorClass: req.ErrorClassCanceled, Error: ctx.Err().Error(),
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	run.FinishedAt = utils.LocalNow()
+
+	inserted := make(map[string][]string)
+	for _, res := range run.Results {
+		if res.OK {
+			inserted[res.Network] = append(inserted[res.Network], res.Partner)
+		}
+	}
+	for network, partners := range inserted {
+		for _, partner := range partners {
+			if _, err := p.DetectTopUp(ctx, network, partner); err != nil {
+				log.Errorf("Ошибка поиска пополнения партнёра %s (%s): %v", partner, network, err)
+			}
+		}
+		if _, err := p.CheckAlerts(ctx, network, partners, run.FinishedAt); err != nil {
+			log.Errorf("Ошибка проверки тревог сети %s: %v", network, err)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return run, fmt.Errorf("сбор %s прерван: %w", run.ID, err)
+	}
+	if failed := run.Failed(); failed > 0 {
+		return run, fmt.Errorf("сбор %s: не собраны балансы %d из %d партнёров", run.ID, failed, len(run.Results))
+	}
+	return run, nil
+}
+
+// collect получает и сохраняет баланс одного партнёра и записывает попытку сбора
+func (p *Processor) collect(ctx context.Context, network string, partner Partner) storage.CollectionResult {
+	log := logger.FromContext(ctx)
+	started := utils.LocalNow()
+
+	snap, err := RouterSnapshot(ctx, partner)
+	if err != nil {
+		log.Errorf("Ошибка получения баланса партнера %s (группа %s): %v", partner.Name, network, err)
+	} else {
+		fetchedAt := utils.LocalNow()
+		if err = p.store.InsertSnapshot(partner.Name, network, toStorageSnapshot(snap, network, partner.Name)); err != nil {
+			log.Errorf("Ошибка вставки баланса партнера %s (группа %s): %v", partner.Name, network, err)
+			err = &storageError{err}
+		} else {
+			log.Debugf("Успешно вставлен баланс партнера %s (группа %s): %.2f", partner.Name, network, snap.Available)
+			// отчёты по запросу возьмут свежий баланс из кэша, не опрашивая партнёра;
+			// несохранённый баланс в кэш не попадает, чтобы отчёты не расходились с историей
+			p.cache.put(healthKey{network: network, partner: partner.Name}, snap, fetchedAt)
+		}
+	}
+
+	attempt := p.recordAttempt(ctx, network, partner.Name, started, snap.Available, err)
+	return storage.CollectionResult{
+		Network:    network,
+		Partner:    partner.Name,
+		OK:         attempt.OK,
+		Balance:    attempt.Balance,
+		Duration:   attempt.Latency,
+		ErrorClass: attempt.ErrorClass,
+		Error:      attempt.Error,
+	}
+}
+
+// SaveRun пишет итог запуска сбора в лог и сохраняет его как последний запуск
+func (p *Processor) SaveRun(run storage.CollectionRun) error {
+	log := logger.FromContext(logger.WithRunID(context.Background(), run.ID))
+	failed := run.Failed()
+	log.Infof("Сбор %q завершён за %s: собрано %d, ошибок %d",
+		run.Schedule, run.FinishedAt.Sub(run.StartedAt).Round(time.Millisecond), len(run.Results)-failed, failed)
+	for _, res := range run.Results {
+		if !res.OK {
+			log.Warnf("Не собран баланс партнёра %s (%s) за %s: [%s] %s",
+				res.Partner, res.Network, res.Duration.Round(time.Millisecond), res.ErrorClass, res.Error)
+		}
+	}
+	if err := p.store.SaveCollectionRun(run); err != nil {
+		return fmt.Errorf("ошибка сохранения запуска сбора %s: %w", run.ID, err)
+	}
+	return nil
+}
+
+// LastRun возвращает последний запуск сбора, в котором собиралась сеть network (пусто — любой),
+// storage.ErrNoCollectionRuns — если таких запусков не было
+func (p *Processor) LastRun(network string) (storage.CollectionRun, error) {
+	return p.store.LastCollectionRun(network)
+}
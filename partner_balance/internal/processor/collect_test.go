@@ -0,0 +1,137 @@
+package processor
+
+import (
+	"context"
+	"partner_balance/internal/logger"
+	"partner_balance/internal/req"
+	"partner_balance/internal/storage"
+	"partner_balance/internal/storage/memory"
+	"partner_balance/internal/utils"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	req.Register("collect-ok", req.ProviderFunc(func(ctx context.Context, cfg utils.PartnerConfig) (float64, error) {
+		return 1500, nil
+	}))
+	req.Register("collect-fail", req.ProviderFunc(func(ctx context.Context, cfg utils.PartnerConfig) (float64, error) {
+		return 0, &req.StatusError{StatusCode: 502, Status: "502 Bad Gateway"}
+	}))
+	// провайдер, уважающий отмену контекста
+	req.Register("collect-ctx", req.ProviderFunc(func(ctx context.Context, cfg utils.PartnerConfig) (float64, error) {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		return 1500, nil
+	}))
+}
+
+// setupCollect настраивает сеть CollectNet из партнёров с указанными провайдерами
+func setupCollect(t *testing.T, maxConcurrent int, providers map[string]string) (*Processor, *memory.Store) {
+	freshHealth(t)
+	partners := make(map[string]utils.PartnerConfig)
+	for name, provider := range providers {
+		partners[name] = utils.PartnerConfig{Token: name, IsActive: true, Provider: provider}
+	}
+	utils.AppConfig = utils.Config{
+		Networks: map[string]map[string]utils.PartnerConfig{"CollectNet": partners},
+		Schedule: utils.ScheduleConfig{MaxConcurrent: maxConcurrent},
+	}
+	t.Cleanup(func() { utils.AppConfig = utils.Config{} })
+
+	store := memory.New()
+	for name := range providers {
+		require.NoError(t, store.InsertPartner(name, "CollectNet", true))
+	}
+	return New(store), store
+}
+
+func TestBalanceInsert_RunReport(t *testing.T) {
+	p, _ := setupCollect(t, 0, map[string]string{"Bad": "collect-fail", "Good": "collect-ok"})
+
+	run, err := p.BalanceInsert(context.Background(), PartnerList())
+	require.Error(t, err, "один партнёр не собран")
+	assert.NotEmpty(t, run.ID)
+	assert.False(t, run.FinishedAt.Before(run.StartedAt))
+	require.Len(t, run.Results, 2)
+	assert.Equal(t, 1, run.Failed())
+
+	bad, good := run.Results[0], run.Results[1]
+	assert.Equal(t, "Bad", bad.Partner)
+	assert.False(t, bad.OK)
+	assert.Equal(t, req.ErrorClassHTTP, bad.ErrorClass)
+	assert.Contains(t, bad.Error, "502 Bad Gateway")
+	assert.Equal(t, "Good", good.Partner)
+	assert.True(t, good.OK)
+	assert.Equal(t, 1500.0, good.Balance)
+	assert.Empty(t, good.ErrorClass)
+}
+
+func TestBalanceInsert_BoundedConcurrency(t *testing.T) {
+	var running, peak atomic.Int32
+	req.Register("collect-slow", req.ProviderFunc(func(ctx context.Context, cfg utils.PartnerConfig) (float64, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		return 100, nil
+	}))
+	providers := make(map[string]string)
+	for _, name := range []string{"A", "B", "C", "D", "E", "F"} {
+		providers[name] = "collect-slow"
+	}
+	p, _ := setupCollect(t, 2, providers)
+
+	run, err := p.BalanceInsert(context.Background(), PartnerList())
+	require.NoError(t, err)
+	assert.Len(t, run.Results, 6)
+	assert.Equal(t, int32(2), peak.Load(), "одновременно не больше schedule.max_concurrent")
+}
+
+func TestBalanceInsert_RunIDFromContext(t *testing.T) {
+	p, _ := setupCollect(t, 0, map[string]string{"Good": "collect-ok"})
+
+	run, err := p.BalanceInsert(logger.WithRunID(context.Background(), "run-42"), PartnerList())
+	require.NoError(t, err)
+	assert.Equal(t, "run-42", run.ID)
+}
+
+func TestBalanceInsert_Canceled(t *testing.T) {
+	p, _ := setupCollect(t, 1, map[string]string{"Good": "collect-ctx"})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	run, err := p.BalanceInsert(ctx, PartnerList())
+	require.ErrorIs(t, err, context.Canceled)
+	require.Len(t, run.Results, 1)
+	assert.False(t, run.Results[0].OK)
+	assert.Equal(t, req.ErrorClassCanceled, run.Results[0].ErrorClass)
+}
+
+func TestSaveRun_LastRun(t *testing.T) {
+	p, _ := setupCollect(t, 0, nil)
+	_, err := p.LastRun("")
+	require.ErrorIs(t, err, storage.ErrNoCollectionRuns)
+
+	t0 := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+	run := storage.CollectionRun{
+		ID: "run-1", Schedule: "0 */6 * * *", StartedAt: t0, FinishedAt: t0.Add(time.Second),
+		Results: []storage.CollectionResult{{Network: "CollectNet", Partner: "Bad", ErrorClass: req.ErrorClassTimeout, Error: "timeout"}},
+	}
+	require.NoError(t, p.SaveRun(run))
+
+	last, err := p.LastRun("")
+	require.NoError(t, err)
+	assert.Equal(t, "run-1", last.ID)
+	assert.Equal(t, 1, last.Failed())
+}
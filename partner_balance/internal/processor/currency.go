@@ -0,0 +1,47 @@
+package processor
+
+import (
+	"partner_balance/internal/fx"
+	"partner_balance/internal/logger"
+	"partner_balance/internal/report"
+	"partner_balance/internal/utils"
+)
+
+// SetFX задаёт источник курсов для пересчёта сумм в валюту отчётов; nil — не пересчитывать
+func (p *Processor) SetFX(src fx.Source) {
+	p.fx = src
+}
+
+// applyReporting заполняет суммы статуса в валюте отчётов, если их можно посчитать;
+// баланс пересчитывается, даже если не удалось пересчитать расход
+func (p *Processor) applyReporting(status *PartnerStatus) {
+	reporting := utils.AppConfig.Currency.Reporting
+	if reporting == "" || status.Currency == "" || p.fx == nil {
+		return
+	}
+	balance, err := fx.Convert(p.fx, status.Balance, status.Currency, reporting)
+	if err != nil {
+		logger.Log.Warnf("Не удалось пересчитать баланс партнёра %s в %s: %v", status.Partner, reporting, err)
+		return
+	}
+	status.ReportingCurrency = reporting
+	status.ReportingBalance = RoundTo(balance, 2)
+
+	spend, err := fx.Convert(p.fx, status.SpendPerDay, status.Currency, reporting)
+	if err != nil {
+		logger.Log.Warnf("Не удалось пересчитать расход партнёра %s в %s: %v", status.Partner, reporting, err)
+		return
+	}
+	status.ReportingSpendPerDay = RoundTo(spend, 2)
+}
+
+// FormatAmount форматирует баланс статуса: сумма, валюта и пересчёт в валюту отчётов,
+// например "5000.00 RUB ≈ 54.64 USD"
+func FormatAmount(status PartnerStatus) string {
+	return report.Row{
+		Balance:           status.Balance,
+		Currency:          status.Currency,
+		ReportingBalance:  status.ReportingBalance,
+		ReportingCurrency: status.ReportingCurrency,
+	}.Amount()
+}
@@ -0,0 +1,133 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"partner_balance/internal/req"
+	"partner_balance/internal/storage/memory"
+	"partner_balance/internal/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rubToUSD — источник курсов с единственным курсом 100 RUB = 1 USD
+type rubToUSD struct{}
+
+func (rubToUSD) Rate(from, to string) (float64, error) {
+	if from == "RUB" && to == "USD" {
+		return 0.01, nil
+	}
+	return 0, fmt.Errorf("нет курса %s/%s", from, to)
+}
+
+func TestSpendStatus_ReportingCurrency(t *testing.T) {
+	req.Register("currency-rub", req.ProviderFunc(func(ctx context.Context, cfg utils.PartnerConfig) (float64, error) {
+		return 5000, nil
+	}))
+	p := setupProcessor(t, "currency-rub", []float64{8000, 7000, 6000})
+	utils.AppConfig.Currency = utils.CurrencyConfig{Default: "rub", Reporting: "USD"}
+	p.SetFX(rubToUSD{})
+
+	statuses := p.SpendStatus(context.Background(), "TestNet")
+	require.Len(t, statuses, 1)
+	st := statuses[0]
+	assert.Equal(t, "RUB", st.Currency)
+	assert.Equal(t, "USD", st.ReportingCurrency)
+	assert.Equal(t, 50.0, st.ReportingBalance)
+	assert.Equal(t, 40.0, st.ReportingSpendPerDay)
+	assert.Equal(t, "5000.00 RUB ≈ 50.00 USD", FormatAmount(st))
+
+	// без курса показывается только исходная валюта
+	utils.AppConfig.Currency.Reporting = "EUR"
+	st = p.SpendStatus(context.Background(), "TestNet")[0]
+	assert.Empty(t, st.ReportingCurrency)
+	assert.Equal(t, "5000.00 RUB", FormatAmount(st))
+}
+
+// firstRateOnly — источник курсов, который отвечает только на первый запрос
+type firstRateOnly struct{ calls int }
+
+func (s *firstRateOnly) Rate(from, to string) (float64, error) {
+	s.calls++
+	if s.calls > 1 {
+		return 0, fmt.Errorf("источник курсов недоступен")
+	}
+	return 0.01, nil
+}
+
+func TestApplyReporting_SpendConversionFails(t *testing.T) {
+	utils.AppConfig = utils.Config{Currency: utils.CurrencyConfig{Reporting: "USD"}}
+	t.Cleanup(func() { utils.AppConfig = utils.Config{} })
+	p := New(memory.New())
+	p.SetFX(&firstRateOnly{})
+
+	st := PartnerStatus{Partner: "Partner1", Balance: 5000, Currency: "RUB", SpendPerDay: 4000}
+	p.applyReporting(&st)
+	assert.Equal(t, "USD", st.ReportingCurrency)
+	assert.Equal(t, 50.0, st.ReportingBalance, "баланс пересчитан, хотя расход — нет")
+	assert.Zero(t, st.ReportingSpendPerDay)
+}
+
+func TestBalanceInsert_StoresCurrency(t *testing.T) {
+	req.Register("currency-eur", req.ProviderFunc(func(ctx context.Context, cfg utils.PartnerConfig) (float64, error) {
+		return 100, nil
+	}))
+	utils.AppConfig = utils.Config{
+		Networks: map[string]map[string]utils.PartnerConfig{
+			"TestNet": {"Partner1": {Token: "t", IsActive: true, Provider: "currency-eur", Currency: "eur"}},
+		},
+		Currency: utils.CurrencyConfig{Default: "RUB"},
+	}
+	t.Cleanup(func() { utils.AppConfig = utils.Config{} })
+
+	store := memory.New()
+	require.NoError(t, store.InsertPartner("Partner1", "TestNet", true))
+	_, err := New(store).BalanceInsert(context.Background(), PartnerList())
+	require.NoError(t, err)
+
+	samples, err := store.GetSamples("Partner1", "TestNet", time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, samples, 1)
+	assert.Equal(t, "EUR", samples[0].Currency)
+}
+
+func TestBalanceInsert_StoresSnapshot(t *testing.T) {
+	req.Register("snapshot-usd", req.SnapshotFunc(func(ctx context.Context, cfg utils.PartnerConfig) (req.BalanceSnapshot, error) {
+		return req.BalanceSnapshot{
+			Available: 100,
+			Reserved:  req.Float(20),
+			Extra:     map[string]float64{"balanceReal": 80},
+			Currency:  "usd",
+			Raw:       []byte(`{"balance":100}`),
+		}, nil
+	}))
+	utils.AppConfig = utils.Config{
+		Networks: map[string]map[string]utils.PartnerConfig{
+			"TestNet": {"Partner1": {Token: "t", IsActive: true, Provider: "snapshot-usd"}},
+		},
+		Currency: utils.CurrencyConfig{Default: "RUB"},
+	}
+	t.Cleanup(func() { utils.AppConfig = utils.Config{} })
+
+	store := memory.New()
+	require.NoError(t, store.InsertPartner("Partner1", "TestNet", true))
+	p := New(store)
+	_, err := p.BalanceInsert(context.Background(), PartnerList())
+	require.NoError(t, err)
+
+	snaps, err := store.GetSnapshots("Partner1", "TestNet", time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, snaps, 1)
+	assert.Equal(t, 100.0, snaps[0].Balance)
+	assert.Equal(t, "USD", snaps[0].Currency) // валюта из ответа важнее конфига
+	assert.Equal(t, 20.0, *snaps[0].Reserved)
+	assert.Equal(t, 80.0, snaps[0].Extra["balanceReal"])
+	assert.Equal(t, `{"balance":100}`, string(snaps[0].Raw))
+
+	statuses := p.Balances(context.Background(), "TestNet")
+	require.Len(t, statuses, 1)
+	assert.Equal(t, "USD", statuses[0].Currency)
+}
@@ -0,0 +1,110 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"partner_balance/internal/logger"
+	"partner_balance/internal/req"
+	"partner_balance/internal/storage"
+	"partner_balance/internal/utils"
+	"time"
+)
+
+// Классы ошибок сбора, которые определяет сам processor; остальные — см. req.Classify
+const (
+	ErrorClassCircuitOpen = "circuit_open" // цепь партнёра разомкнута, запрос не выполнялся
+	ErrorClassConfig      = "config"       // провайдер партнёра не зарегистрирован
+	ErrorClassStorage     = "storage"      // баланс получен, но не сохранён
+)
+
+// storageError — баланс получен, но хранилище его не приняло
+type storageError struct{ error }
+
+func (e *storageError) Unwrap() error { return e.error }
+
+// classifyFetchError определяет класс ошибки сбора и HTTP-статус ответа партнёра
+func classifyFetchError(err error) (class string, httpStatus int) {
+	var (
+		open   *CircuitOpenError
+		stored *storageError
+	)
+	switch {
+	case errors.As(err, &stored):
+		return ErrorClassStorage, 0
+	case errors.As(err, &open):
+		return ErrorClassCircuitOpen, 0
+	case errors.Is(err, req.ErrUnknownProvider):
+		return ErrorClassConfig, 0
+	}
+	return req.Classify(err)
+}
+
+// recordAttempt сохраняет попытку сбора и возвращает её; ошибка записи только логируется,
+// чтобы не мешать сбору
+func (p *Processor) recordAttempt(ctx context.Context, network, partner string, started time.Time, balance float64, err error) storage.FetchAttempt {
+	attempt := storage.FetchAttempt{
+		Network:   network,
+		Partner:   partner,
+		StartedAt: started,
+		Latency:   utils.LocalNow().Sub(started),
+		OK:        err == nil,
+	}
+	if err == nil {
+		attempt.Balance = balance
+	} else {
+		attempt.ErrorClass, attempt.HTTPStatus = classifyFetchError(err)
+		attempt.Error = err.Error()
+	}
+	if err := p.store.InsertFetchAttempt(&attempt); err != nil {
+		logger.FromContext(ctx).Errorf("Ошибка записи попытки сбора партнёра %s (%s): %v", partner, network, err)
+	}
+	return attempt
+}
+
+// Freshness — свежесть данных партнёра по журналу попыток сбора
+type Freshness struct {
+	LastSuccess    time.Time // последний успешный сбор, нулевое — в окне не было
+	LastAttempt    time.Time // последняя попытка, нулевое — попыток в окне не было
+	FailedInRow    int       // неудачных попыток подряд после LastSuccess
+	LastErrorClass string    // класс последней ошибки, если последняя попытка неудачна
+}
+
+// freshness сводит попытки сбора (от старых к новым) в свежесть данных
+func freshness(attempts []storage.FetchAttempt) Freshness {
+	var f Freshness
+	for _, a := range attempts {
+		f.LastAttempt = a.StartedAt
+		if a.OK {
+			f.LastSuccess = a.StartedAt
+			f.FailedInRow = 0
+			f.LastErrorClass = ""
+		} else {
+			f.FailedInRow++
+			f.LastErrorClass = a.ErrorClass
+		}
+	}
+	return f
+}
+
+// failedAt возвращает моменты неудачных попыток — явные пропуски в замерах
+func failedAt(attempts []storage.FetchAttempt) []time.Time {
+	var times []time.Time
+	for _, a := range attempts {
+		if !a.OK {
+			times = append(times, a.StartedAt)
+		}
+	}
+	return times
+}
+
+// FormatFreshness — строка отчёта о неудачных сборах подряд; пусто, если последний сбор удался
+func FormatFreshness(f Freshness) string {
+	if f.FailedInRow == 0 {
+		return ""
+	}
+	if f.LastSuccess.IsZero() {
+		return fmt.Sprintf("сбор не удался %d раз подряд, удачных сборов за %d дн нет", f.FailedInRow, SpendWindow)
+	}
+	return fmt.Sprintf("сбор не удался %d раз подряд, данные от %s", f.FailedInRow, f.LastSuccess.Format("02-01 15:04"))
+}
@@ -0,0 +1,97 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"partner_balance/internal/req"
+	"partner_balance/internal/storage"
+	"partner_balance/internal/storage/memory"
+	"partner_balance/internal/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBalanceInsert_RecordsAttempts(t *testing.T) {
+	freshHealth(t)
+	fail := true
+	req.Register("fetch-flaky", req.ProviderFunc(func(ctx context.Context, cfg utils.PartnerConfig) (float64, error) {
+		if fail {
+			return 0, fmt.Errorf("не удалось получить баланс: %w", &req.StatusError{StatusCode: 503, Status: "503 Service Unavailable"})
+		}
+		return 700, nil
+	}))
+	utils.AppConfig = utils.Config{
+		Networks: map[string]map[string]utils.PartnerConfig{
+			"FetchNet": {"Flaky": {Token: "t", IsActive: true, Provider: "fetch-flaky"}},
+		},
+		Alerts: utils.AlertsConfig{AlertRules: utils.AlertRules{SpendMultiplier: ptr(0)}},
+	}
+	t.Cleanup(func() { utils.AppConfig = utils.Config{} })
+
+	store := memory.New()
+	require.NoError(t, store.InsertPartner("Flaky", "FetchNet", true))
+	p := New(store)
+
+	_, err := p.BalanceInsert(context.Background(), PartnerList())
+	require.Error(t, err)
+	fail = false
+	_, err = p.BalanceInsert(context.Background(), PartnerList())
+	require.NoError(t, err)
+
+	attempts, err := store.GetFetchAttempts("FetchNet", "Flaky", time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+	assert.False(t, attempts[0].OK)
+	assert.Equal(t, req.ErrorClassHTTP, attempts[0].ErrorClass)
+	assert.Equal(t, 503, attempts[0].HTTPStatus)
+	assert.Contains(t, attempts[0].Error, "503 Service Unavailable")
+	assert.True(t, attempts[1].OK)
+	assert.Equal(t, 700.0, attempts[1].Balance)
+	assert.Empty(t, attempts[1].ErrorClass)
+}
+
+func TestClassifyFetchError(t *testing.T) {
+	tests := []struct {
+		err    error
+		class  string
+		status int
+	}{
+		{&CircuitOpenError{Partner: "P"}, ErrorClassCircuitOpen, 0},
+		{fmt.Errorf("router: %w", req.ErrUnknownProvider), ErrorClassConfig, 0},
+		{&storageError{errors.New("db down")}, ErrorClassStorage, 0},
+		{fmt.Errorf("ошибка получения баланса: %w", &req.StatusError{StatusCode: 429}), req.ErrorClassHTTP, 429},
+		{fmt.Errorf("ошибка получения баланса: %w", context.DeadlineExceeded), req.ErrorClassTimeout, 0},
+		{errors.New("что-то ещё"), req.ErrorClassOther, 0},
+	}
+	for _, tt := range tests {
+		class, status := classifyFetchError(tt.err)
+		assert.Equal(t, tt.class, class, tt.err.Error())
+		assert.Equal(t, tt.status, status, tt.err.Error())
+	}
+}
+
+func TestFreshness(t *testing.T) {
+	t0 := time.Date(2025, 4, 1, 10, 0, 0, 0, time.UTC)
+	attempt := func(h int, ok bool, class string) storage.FetchAttempt {
+		return storage.FetchAttempt{StartedAt: t0.Add(time.Duration(h) * time.Hour), OK: ok, ErrorClass: class}
+	}
+
+	assert.Equal(t, Freshness{}, freshness(nil))
+	assert.Empty(t, FormatFreshness(Freshness{}))
+
+	f := freshness([]storage.FetchAttempt{
+		attempt(0, false, "http"), attempt(1, true, ""), attempt(2, false, "timeout"), attempt(3, false, "network"),
+	})
+	assert.Equal(t, Freshness{
+		LastSuccess: t0.Add(time.Hour), LastAttempt: t0.Add(3 * time.Hour),
+		FailedInRow: 2, LastErrorClass: "network",
+	}, f)
+	assert.Equal(t, "сбор не удался 2 раз подряд, данные от 01-04 11:00", FormatFreshness(f))
+
+	f = freshness([]storage.FetchAttempt{attempt(0, false, "timeout")})
+	assert.Equal(t, "сбор не удался 1 раз подряд, удачных сборов за 3 дн нет", FormatFreshness(f))
+}
@@ -0,0 +1,51 @@
+package processor
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Runway — прогноз, когда закончится баланс партнёра при текущем расходе
+type Runway struct {
+	Partner   string
+	Balance   float64
+	PerDay    float64   // расход в сутки, по которому строится прогноз
+	HoursLeft float64   // сколько часов хватит баланса
+	ZeroAt    time.Time // когда баланс дойдёт до нуля
+	TopUpBy   time.Time // до какого времени нужно пополнить (ZeroAt минус запас)
+	Known     bool      // false, если расход не удалось оценить и прогноза нет
+}
+
+// Forecast строит прогноз по текущему балансу и расходу в сутки.
+// lead — запас времени до нуля, за который нужно успеть пополнить аккаунт.
+func Forecast(partner string, balance float64, perDay float64, now time.Time, lead time.Duration) Runway {
+	r := Runway{Partner: partner, Balance: balance, PerDay: perDay}
+	if perDay <= 0 {
+		return r
+	}
+
+	r.Known = true
+	r.HoursLeft = math.Max(balance, 0) / perDay * 24
+	r.ZeroAt = now.Add(time.Duration(r.HoursLeft * float64(time.Hour)))
+	r.TopUpBy = r.ZeroAt.Add(-lead)
+	return r
+}
+
+// FormatRunway возвращает короткое описание прогноза для отчёта
+func FormatRunway(r Runway, now time.Time) string {
+	if !r.Known {
+		return ""
+	}
+	var left string
+	if r.HoursLeft < 48 {
+		left = fmt.Sprintf("%.0f ч", r.HoursLeft)
+	} else {
+		left = fmt.Sprintf("%.1f дн", r.HoursLeft/24)
+	}
+	zero := r.ZeroAt.Format("02-01 15:04")
+	if !r.TopUpBy.After(now) {
+		return fmt.Sprintf("0 через %s (%s), пополнить сейчас", left, zero)
+	}
+	return fmt.Sprintf("0 через %s (%s), пополнить до %s", left, zero, r.TopUpBy.Format("02-01 15:04"))
+}
@@ -0,0 +1,31 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForecast(t *testing.T) {
+	now := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	r := Forecast("Partner1", 480, 240, now, 24*time.Hour)
+	assert.True(t, r.Known)
+	assert.InDelta(t, 48.0, r.HoursLeft, 1e-9)
+	assert.Equal(t, now.Add(48*time.Hour), r.ZeroAt)
+	assert.Equal(t, now.Add(24*time.Hour), r.TopUpBy)
+	assert.Equal(t, "0 через 2.0 дн (03-04 12:00), пополнить до 02-04 12:00", FormatRunway(r, now))
+
+	soon := Forecast("Partner1", 50, 240, now, 24*time.Hour)
+	assert.InDelta(t, 5.0, soon.HoursLeft, 1e-9)
+	assert.Equal(t, "0 через 5 ч (01-04 17:00), пополнить сейчас", FormatRunway(soon, now))
+
+	negative := Forecast("Partner1", -10, 240, now, 24*time.Hour)
+	assert.Equal(t, 0.0, negative.HoursLeft)
+	assert.Equal(t, now, negative.ZeroAt)
+
+	unknown := Forecast("Partner1", 100, 0, now, 24*time.Hour)
+	assert.False(t, unknown.Known)
+	assert.Empty(t, FormatRunway(unknown, now))
+}
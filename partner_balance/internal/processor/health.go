@@ -0,0 +1,159 @@
+package processor
+
+import (
+	"fmt"
+	"partner_balance/internal/logger"
+	"partner_balance/internal/utils"
+	"sort"
+	"sync"
+	"time"
+)
+
+// BreakerState — состояние размыкателя цепи партнёра
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"    // запросы идут как обычно
+	BreakerOpen     BreakerState = "open"      // партнёр недоступен, запросы не выполняются
+	BreakerHalfOpen BreakerState = "half_open" // пропущена одна пробная попытка
+)
+
+// PartnerHealth — здоровье API партнёра по последним запросам баланса
+type PartnerHealth struct {
+	Network             string
+	Partner             string
+	State               BreakerState
+	LastSuccess         time.Time // последний успешный запрос, нулевое — не было
+	LastFailure         time.Time // последняя ошибка, нулевое — не было
+	LastError           string
+	ConsecutiveFailures int
+	FailingSince        time.Time // первая ошибка текущей серии, нулевое — серии нет
+	OpenedAt            time.Time // когда цепь разомкнулась в последний раз
+}
+
+// CircuitOpenError возвращается вместо запроса к партнёру, цепь которого разомкнута
+type CircuitOpenError struct {
+	Partner string
+	Since   time.Time // с какого момента партнёр недоступен
+	RetryAt time.Time // когда будет пробная попытка
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("партнёр %s недоступен с %s, следующая попытка в %s",
+		e.Partner, e.Since.Format("02-01 15:04"), e.RetryAt.Format("15:04"))
+}
+
+// healthKey — партнёр в сети
+type healthKey struct {
+	network string
+	partner string
+}
+
+// healthRegistry хранит здоровье и размыкатели цепи всех партнёров
+type healthRegistry struct {
+	mu       sync.Mutex
+	partners map[healthKey]*PartnerHealth
+	probing  map[healthKey]bool // в полуоткрытом состоянии уже идёт пробная попытка
+}
+
+func newHealthRegistry() *healthRegistry {
+	return &healthRegistry{
+		partners: make(map[healthKey]*PartnerHealth),
+		probing:  make(map[healthKey]bool),
+	}
+}
+
+// health — общий реестр, в него пишет RouterSnapshot
+var health = newHealthRegistry()
+
+func (h *healthRegistry) get(key healthKey) *PartnerHealth {
+	ph, ok := h.partners[key]
+	if !ok {
+		ph = &PartnerHealth{Network: key.network, Partner: key.partner, State: BreakerClosed}
+		h.partners[key] = ph
+	}
+	return ph
+}
+
+// allow решает, можно ли сейчас обращаться к партнёру. По истечении OpenFor разомкнутая цепь
+// становится полуоткрытой и пропускает одну пробную попытку, остальные получают CircuitOpenError.
+func (h *healthRegistry) allow(key healthKey, now time.Time) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ph := h.get(key)
+	retryAt := ph.OpenedAt.Add(utils.AppConfig.Breaker.OpenFor())
+	switch {
+	case ph.State == BreakerClosed:
+		return nil
+	case ph.State == BreakerOpen && !now.Before(retryAt):
+		ph.State = BreakerHalfOpen
+		h.probing[key] = true
+		logger.Log.Infof("Цепь партнёра %s (%s) полуоткрыта, пробная попытка", key.partner, key.network)
+		return nil
+	case ph.State == BreakerHalfOpen && !h.probing[key]:
+		h.probing[key] = true
+		return nil
+	}
+	return &CircuitOpenError{Partner: key.partner, Since: ph.FailingSince, RetryAt: retryAt}
+}
+
+// success отмечает успешный запрос и замыкает цепь
+func (h *healthRegistry) success(key healthKey, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ph := h.get(key)
+	if ph.State != BreakerClosed {
+		logger.Log.Infof("Партнёр %s (%s) снова доступен, цепь замкнута", key.partner, key.network)
+	}
+	ph.State = BreakerClosed
+	ph.LastSuccess = now
+	ph.ConsecutiveFailures = 0
+	ph.FailingSince = time.Time{}
+	delete(h.probing, key)
+}
+
+// failure отмечает ошибку запроса; после порога ошибок подряд или неудачной пробы цепь размыкается
+func (h *healthRegistry) failure(key healthKey, err error, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ph := h.get(key)
+	ph.LastFailure = now
+	ph.LastError = err.Error()
+	ph.ConsecutiveFailures++
+	if ph.FailingSince.IsZero() {
+		ph.FailingSince = now
+	}
+	delete(h.probing, key)
+	if ph.State == BreakerHalfOpen || ph.ConsecutiveFailures >= utils.AppConfig.Breaker.Threshold() {
+		if ph.State != BreakerOpen {
+			logger.Log.Warnf("Цепь партнёра %s (%s) разомкнута после %d ошибок подряд: %v",
+				key.partner, key.network, ph.ConsecutiveFailures, err)
+		}
+		ph.State = BreakerOpen
+		ph.OpenedAt = now
+	}
+}
+
+// release снимает отметку пробной попытки, которая не дала результата (запрос отменён вызывающим)
+func (h *healthRegistry) release(key healthKey) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.probing, key)
+}
+
+// Health возвращает здоровье всех партнёров, к которым были запросы, по сети и имени
+func Health() []PartnerHealth {
+	health.mu.Lock()
+	defer health.mu.Unlock()
+	result := make([]PartnerHealth, 0, len(health.partners))
+	for _, ph := range health.partners {
+		result = append(result, *ph)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Network != result[j].Network {
+			return result[i].Network < result[j].Network
+		}
+		return result[i].Partner < result[j].Partner
+	})
+	return result
+}
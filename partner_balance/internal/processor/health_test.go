@@ -0,0 +1,112 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"partner_balance/internal/req"
+	"partner_balance/internal/storage/memory"
+	"partner_balance/internal/utils"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// freshHealth подменяет общий реестр здоровья пустым на время теста
+func freshHealth(t *testing.T) *healthRegistry {
+	orig := health
+	health = newHealthRegistry()
+	t.Cleanup(func() { health = orig })
+	return health
+}
+
+func TestHealthRegistry_Breaker(t *testing.T) {
+	h := freshHealth(t)
+	utils.AppConfig = utils.Config{Breaker: utils.BreakerConfig{FailureThreshold: 2, OpenMinutes: 5}}
+	t.Cleanup(func() { utils.AppConfig = utils.Config{} })
+
+	key := healthKey{network: "Net", partner: "P"}
+	t0 := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+	fail := errors.New("timeout")
+
+	require.NoError(t, h.allow(key, t0))
+	h.failure(key, fail, t0)
+	require.NoError(t, h.allow(key, t0.Add(time.Minute)), "одна ошибка цепь не размыкает")
+	h.failure(key, fail, t0.Add(time.Minute))
+
+	var open *CircuitOpenError
+	require.ErrorAs(t, h.allow(key, t0.Add(2*time.Minute)), &open)
+	assert.Equal(t, t0, open.Since, "недоступен с первой ошибки серии")
+	assert.Equal(t, t0.Add(6*time.Minute), open.RetryAt)
+
+	// после паузы пропускается ровно одна пробная попытка
+	require.NoError(t, h.allow(key, t0.Add(6*time.Minute)))
+	assert.ErrorAs(t, h.allow(key, t0.Add(6*time.Minute)), &open)
+	h.failure(key, fail, t0.Add(6*time.Minute))
+	require.ErrorAs(t, h.allow(key, t0.Add(7*time.Minute)), &open, "неудачная проба снова размыкает цепь")
+	assert.Equal(t, t0, open.Since)
+
+	require.NoError(t, h.allow(key, t0.Add(11*time.Minute)))
+	h.success(key, t0.Add(11*time.Minute))
+	require.NoError(t, h.allow(key, t0.Add(11*time.Minute)))
+
+	got := Health()
+	require.Len(t, got, 1)
+	assert.Equal(t, BreakerClosed, got[0].State)
+	assert.Equal(t, t0.Add(11*time.Minute), got[0].LastSuccess)
+	assert.Equal(t, t0.Add(6*time.Minute), got[0].LastFailure)
+	assert.Equal(t, "timeout", got[0].LastError)
+	assert.Zero(t, got[0].ConsecutiveFailures)
+	assert.True(t, got[0].FailingSince.IsZero())
+}
+
+func TestHealthRegistry_ReleaseProbe(t *testing.T) {
+	h := freshHealth(t)
+	utils.AppConfig = utils.Config{Breaker: utils.BreakerConfig{FailureThreshold: 1}}
+	t.Cleanup(func() { utils.AppConfig = utils.Config{} })
+
+	key := healthKey{network: "Net", partner: "P"}
+	t0 := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+	h.failure(key, errors.New("down"), t0)
+
+	probeAt := t0.Add(utils.DefaultBreakerOpen)
+	require.NoError(t, h.allow(key, probeAt))
+	h.release(key)
+	assert.NoError(t, h.allow(key, probeAt), "отменённая проба не блокирует следующую")
+}
+
+func TestBalances_UnavailablePartner(t *testing.T) {
+	freshHealth(t)
+	var calls atomic.Int32
+	req.Register("breaker-down", req.ProviderFunc(func(ctx context.Context, cfg utils.PartnerConfig) (float64, error) {
+		calls.Add(1)
+		return 0, errors.New("сервис недоступен")
+	}))
+	utils.AppConfig = utils.Config{
+		Networks: map[string]map[string]utils.PartnerConfig{
+			"BreakerNet": {"Down": {Token: "t", IsActive: true, Provider: "breaker-down"}},
+		},
+		Breaker: utils.BreakerConfig{FailureThreshold: 2},
+	}
+	t.Cleanup(func() { utils.AppConfig = utils.Config{} })
+	p := New(memory.New())
+
+	for range 2 {
+		st := p.Balances(context.Background(), "BreakerNet")
+		require.Len(t, st, 1)
+		assert.Error(t, st[0].Err)
+		assert.False(t, st[0].Unavailable())
+	}
+
+	st := p.Balances(context.Background(), "BreakerNet")
+	require.Len(t, st, 1)
+	assert.True(t, st[0].Unavailable())
+	assert.Equal(t, int32(2), calls.Load(), "при разомкнутой цепи провайдер не вызывается")
+
+	text := renderHTML(t, p.BalanceReport(context.Background(), "BreakerNet"))
+	assert.Contains(t, text, "<b>Down</b>: недоступен с "+st[0].UnavailableSince.Format("02-01 15:04"))
+	spend, _ := p.SpendReport(context.Background(), "BreakerNet")
+	assert.Contains(t, renderHTML(t, spend), "<b>Down</b>: недоступен с ")
+}
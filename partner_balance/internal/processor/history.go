@@ -0,0 +1,110 @@
+package processor
+
+import (
+	"fmt"
+	"partner_balance/internal/storage"
+	"time"
+)
+
+// MaxHistoryPoints — максимальное количество интервалов в одном запросе истории
+const MaxHistoryPoints = 2000
+
+// ErrHistoryRange — неверный диапазон или шаг запроса истории
+var ErrHistoryRange = fmt.Errorf("неверный запрос истории")
+
+// BalanceHistory возвращает историю баланса и расхода партнёра за [from, to) с шагом step.
+// Для шагов day и week дни, сырые замеры которых уже удалены политикой хранения,
+// берутся из дневных агрегатов; расход за такие дни оценивается по разнице последних балансов.
+func (p *Processor) BalanceHistory(network, partnerName string, from, to time.Time, step storage.Step) ([]storage.HistoryBucket, error) {
+	if !step.Valid() {
+		return nil, fmt.Errorf("%w: неизвестный шаг %q", ErrHistoryRange, step)
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: начало периода не раньше конца", ErrHistoryRange)
+	}
+	if points := to.Sub(from) / step.Duration(); points > MaxHistoryPoints {
+		return nil, fmt.Errorf("%w: слишком много интервалов (%d, максимум %d)", ErrHistoryRange, points, MaxHistoryPoints)
+	}
+
+	if step == storage.StepHour {
+		return p.store.BalanceHistory(partnerName, network, from, to, step)
+	}
+
+	days, err := p.store.BalanceHistory(partnerName, network, from, to, storage.StepDay)
+	if err != nil {
+		return nil, err
+	}
+	rollups, err := p.store.GetDailyRollups(partnerName, network, from)
+	if err != nil {
+		return nil, err
+	}
+	days = mergeRollups(days, rollups, to, from.Location())
+
+	if step == storage.StepDay {
+		return days, nil
+	}
+	return regroup(days, step, from.Location()), nil
+}
+
+// mergeRollups дополняет дневные интервалы агрегатами за дни без сырых замеров
+func mergeRollups(days []storage.HistoryBucket, rollups []storage.DailyRollup, to time.Time, loc *time.Location) []storage.HistoryBucket {
+	covered := make(map[time.Time]bool, len(days))
+	for _, d := range days {
+		covered[d.Start] = true
+	}
+
+	var merged []storage.HistoryBucket
+	i := 0
+	var prevLast *float64
+	for _, r := range rollups {
+		y, m, d := r.Day.Date()
+		start := time.Date(y, m, d, 0, 0, 0, 0, loc)
+		if !start.Before(to) {
+			break
+		}
+		for i < len(days) && days[i].Start.Before(start) {
+			merged = append(merged, days[i])
+			prevLast = &days[i].Last
+			i++
+		}
+		if covered[start] {
+			continue
+		}
+		b := storage.HistoryBucket{Start: start, Min: r.Min, Max: r.Max, Last: r.Last, Samples: r.Samples}
+		if prevLast != nil {
+			if delta := *prevLast - r.Last; delta > 0 {
+				b.Spend = delta
+			} else {
+				b.TopUp = -delta
+			}
+		}
+		merged = append(merged, b)
+		prevLast = &merged[len(merged)-1].Last
+	}
+	return append(merged, days[i:]...)
+}
+
+// regroup объединяет упорядоченные интервалы в более крупные интервалы шага step
+func regroup(buckets []storage.HistoryBucket, step storage.Step, loc *time.Location) []storage.HistoryBucket {
+	var result []storage.HistoryBucket
+	for _, b := range buckets {
+		start := step.Truncate(b.Start, loc)
+		if len(result) == 0 || !result[len(result)-1].Start.Equal(start) {
+			b.Start = start
+			result = append(result, b)
+			continue
+		}
+		g := &result[len(result)-1]
+		if b.Min < g.Min {
+			g.Min = b.Min
+		}
+		if b.Max > g.Max {
+			g.Max = b.Max
+		}
+		g.Last = b.Last
+		g.Samples += b.Samples
+		g.Spend += b.Spend
+		g.TopUp += b.TopUp
+	}
+	return result
+}
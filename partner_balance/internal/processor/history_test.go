@@ -0,0 +1,71 @@
+package processor
+
+import (
+	"errors"
+	"partner_balance/internal/storage"
+	"partner_balance/internal/storage/memory"
+	"partner_balance/internal/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBalanceHistory_MergesRollups(t *testing.T) {
+	store := memory.New()
+	require.NoError(t, store.InsertPartner("Partner1", "TestNet", true))
+
+	today := startOfDay(utils.LocalNow())
+	insert := func(days, hour int, balance float64) {
+		at := today.AddDate(0, 0, days).Add(time.Duration(hour) * time.Hour)
+		store.Now = func() time.Time { return at }
+		require.NoError(t, store.InsertBalance("Partner1", balance, "TestNet"))
+	}
+	insert(-10, 12, 500)
+	insert(-10, 18, 400)
+	insert(-9, 12, 300)
+	// старые замеры свёрнуты в дневные агрегаты и удалены
+	require.NoError(t, store.RollupDaily("TestNet", today.AddDate(0, 0, -8)))
+	_, err := store.DeleteBalancesBefore("TestNet", today.AddDate(0, 0, -8))
+	require.NoError(t, err)
+	insert(-1, 12, 200)
+	insert(-1, 18, 150)
+	store.Now = time.Now
+
+	p := New(store)
+	days, err := p.BalanceHistory("TestNet", "Partner1", today.AddDate(0, 0, -12), today, storage.StepDay)
+	require.NoError(t, err)
+	require.Len(t, days, 3)
+
+	assert.Equal(t, today.AddDate(0, 0, -10), days[0].Start)
+	assert.Equal(t, storage.HistoryBucket{Start: days[0].Start, Min: 400, Max: 500, Last: 400, Samples: 2}, days[0])
+	assert.Equal(t, 100.0, days[1].Spend) // оценка по последним балансам агрегатов
+	assert.Equal(t, today.AddDate(0, 0, -1), days[2].Start)
+	assert.Equal(t, 50.0, days[2].Spend)
+	assert.Equal(t, 150.0, days[2].Last)
+
+	weeks, err := p.BalanceHistory("TestNet", "Partner1", today.AddDate(0, 0, -12), today, storage.StepWeek)
+	require.NoError(t, err)
+	var samples int
+	for _, w := range weeks {
+		assert.Equal(t, time.Monday, w.Start.Weekday())
+		samples += w.Samples
+	}
+	assert.Equal(t, 5, samples)
+	assert.Equal(t, 150.0, weeks[len(weeks)-1].Last)
+}
+
+func TestBalanceHistory_InvalidRange(t *testing.T) {
+	p := New(memory.New())
+	now := time.Now()
+
+	_, err := p.BalanceHistory("TestNet", "Partner1", now.Add(-time.Hour), now, storage.Step("month"))
+	assert.True(t, errors.Is(err, ErrHistoryRange))
+
+	_, err = p.BalanceHistory("TestNet", "Partner1", now, now.Add(-time.Hour), storage.StepHour)
+	assert.True(t, errors.Is(err, ErrHistoryRange))
+
+	_, err = p.BalanceHistory("TestNet", "Partner1", now.AddDate(-1, 0, 0), now, storage.StepHour)
+	assert.True(t, errors.Is(err, ErrHistoryRange))
+}
@@ -1,24 +1,40 @@
 package processor
 
 import (
+	"context"
 	"fmt"
 	"math"
+	"partner_balance/internal/fx"
 	"partner_balance/internal/logger"
-	db "partner_balance/internal/postgres"
-	"partner_balance/internal/req/partner1"
-	"partner_balance/internal/req/partner2"
-	"partner_balance/internal/req/partner3"
-
+	"partner_balance/internal/req"
+	_ "partner_balance/internal/req/httpjson"
+	_ "partner_balance/internal/req/partner1"
+	_ "partner_balance/internal/req/partner2"
+	_ "partner_balance/internal/req/partner3"
+	"partner_balance/internal/storage"
 	"partner_balance/internal/utils"
-	"sort"
 	"strings"
-	"sync"
-	"time"
 )
 
+// Processor собирает балансы партнёров и строит по ним отчёты.
+// Хранилище передаётся снаружи, что позволяет тестировать логику на in-memory реализации.
+type Processor struct {
+	store storage.BalanceStore
+	hub   alertHub
+	fx    fx.Source // источник курсов, nil — суммы только в исходной валюте
+	cache *snapshotCache
+}
+
+func New(store storage.BalanceStore) *Processor {
+	return &Processor{store: store, cache: newSnapshotCache()}
+}
+
 type Partner struct {
-	Name  string // название партнёра
-	Token string // токен из конфига
+	Name     string              // название партнёра
+	Network  string              // сеть, к которой относится партнёр
+	Token    string              // токен из конфига
+	Provider string              // тип провайдера из реестра req
+	Config   utils.PartnerConfig // полная запись партнёра из конфига
 }
 
 type NetworkGroup struct {
@@ -31,50 +47,6 @@ func RoundTo(x float64, n int) float64 {
 	return math.Round(x*math.Pow(10, float64(n))) / math.Pow(10, float64(n))
 }
 
-func AverageArrow(balances []float64) []float64 {
-    if len(balances) < 2 {
-        return []float64{}
-    }
-    diffs := make([]float64, 0, len(balances)-1)
-    for i := 1; i < len(balances); i++ {
-        prev, curr := balances[i-1], balances[i]
-        if prev != 0 && curr != 0 && curr > prev {
-            diffs = append(diffs, curr-prev)
-        }
-    }
-    return diffs
-}
-
-func AverageValue(balances []float64) float64 {
-	if len(balances) == 0 {
-		return 0.0
-	}
-	var value float64
-	for _, v := range balances {
-		value += v*4
-	}
-	return  RoundTo(value/float64(len(balances)), 2)
-}
-
-func FormatMapWithTimestamp(m map[string]string) string {
-    now := time.Now().Format("02-01-2006 / 15:04")
-    var sb strings.Builder
-    sb.WriteString(now)
-    sb.WriteByte('\n')
-
-    keys := make([]string, 0, len(m))
-    for k := range m {
-        keys = append(keys, k)
-    }
-    sort.Strings(keys)
-
-    for _, k := range keys {
-        sb.WriteString(fmt.Sprintf("<b>%s</b>: %s\n\n", k, m[k]))
-    }
-
-    return sb.String()
-}
-
 // Загрузка списка сеток, их партнеров и токенов
 func PartnerList() []NetworkGroup {
 	var groups []NetworkGroup
@@ -86,8 +58,11 @@ func PartnerList() []NetworkGroup {
 				continue
 			}
 			ng.Partners = append(ng.Partners, Partner{
-				Name:  partnerName,
-				Token: cfg.Token,
+				Name:     partnerName,
+				Network:  groupName,
+				Token:    cfg.Token,
+				Provider: cfg.ProviderType(partnerName),
+				Config:   cfg,
 			})
 		}
 
@@ -99,210 +74,182 @@ func PartnerList() []NetworkGroup {
 }
 
 // Вставка или обновление всех партнёров из списка групп в базу данных
-func InsertPartners(groups []NetworkGroup) error {
+func (p *Processor) InsertPartners(groups []NetworkGroup) error {
 	for _, ng := range groups {
-		for _, p := range ng.Partners {
+		for _, partner := range ng.Partners {
 			// Вставляем или обновляем партнёра в базе данных
-			if err := db.InsertPartner(p.Name, ng.GroupName, true); err != nil {
+			if err := p.store.InsertPartner(partner.Name, ng.GroupName, true); err != nil {
 				return fmt.Errorf(
 					"processor.InsertPartners: не удалось добавить партнёра %s в сеть %s: %v",
-					p.Name, ng.GroupName, err,
+					partner.Name, ng.GroupName, err,
 				)
 			}
-			logger.Log.Debugf("Партнёр %s успешно добавлен/обновлён в сети %s", p.Name, ng.GroupName)
+			logger.Log.Debugf("Партнёр %s успешно добавлен/обновлён в сети %s", partner.Name, ng.GroupName)
 		}
 	}
 	logger.Log.Info("Операция InsertPartners завершена")
 	return nil
 }
 
-func Router(partners Partner) (float64, error) {
-	var result float64
-	var err error
-	switch partners.Name {
-	case "Partner1":
-		result, err = partner1.GetBalance(partners.Token)
-	case "Partner2":
-		result, err = partner2.GetBalance(partners.Token)
-	case "Partner3":
-		result, err = partner3.GetBalance(partners.Token)
-	default:
-		logger.Log.Errorf("Ошибка: неправильное название партнера (router): %s", partners.Name)
-		return 0, fmt.Errorf("неправильное название партнера (router)")
+// Router находит провайдер партнёра в реестре и запрашивает у него баланс
+func Router(ctx context.Context, partners Partner) (float64, error) {
+	snap, err := RouterSnapshot(ctx, partners)
+	return snap.Available, err
+}
+
+// RouterSnapshot получает полный снимок баланса партнёра через его провайдер
+func RouterSnapshot(ctx context.Context, partners Partner) (req.BalanceSnapshot, error) {
+	provider, err := req.Get(partners.Provider)
+	if err != nil {
+		logger.FromContext(ctx).Errorf("Ошибка: провайдер %q для партнера %s не найден (router): %v", partners.Provider, partners.Name, err)
+		return req.BalanceSnapshot{}, fmt.Errorf("провайдер партнера %s не найден (router): %w", partners.Name, err)
+	}
+	key := healthKey{network: partners.Network, partner: partners.Name}
+	if err := health.allow(key, utils.LocalNow()); err != nil {
+		logger.FromContext(ctx).Debugf("Пропуск запроса баланса: %v", err)
+		return req.BalanceSnapshot{}, err
 	}
+	cfg := partners.Config
+	cfg.Provider = partners.Provider
+	result, err := req.Snapshot(ctx, provider, cfg)
 	if err != nil {
-		logger.Log.Errorf("Ошибка получения баланса у партнера %s: %v", partners.Name, err)
-		return 0, fmt.Errorf("ошибка получения баланса: %v", err)
+		if ctx.Err() != nil {
+			// запрос отменён вызывающим, партнёр тут ни при чём
+			health.release(key)
+		} else {
+			health.failure(key, err, utils.LocalNow())
+		}
+		logger.FromContext(ctx).Errorf("Ошибка получения баланса у партнера %s: %v", partners.Name, err)
+		return req.BalanceSnapshot{}, fmt.Errorf("ошибка получения баланса: %w", err)
 	}
+	health.success(key, utils.LocalNow())
 	return result, nil
 }
 
-// Вставка баланса в бд
-func BalanceInsert() error {
-	groups := PartnerList()
-	var wg sync.WaitGroup
-
-	for _, v := range groups {
-		groupName := v.GroupName
-		for _, partner := range v.Partners {
-			wg.Add(1)
-			go func(partner Partner, groupName string) error {
-				defer wg.Done()
-				balance, err := Router(partner)
-				if err != nil {
-					logger.Log.Errorf("Ошибка получения баланса партнера %s (группа %s): %v", partner.Name, groupName, err)
-					return err
-				}
-				if err := db.InsertBalance(partner.Name, balance, groupName); err != nil {
-					logger.Log.Errorf("Ошибка вставки баланса партнера %s (группа %s): %v", partner.Name, groupName, err)
-					return err
+// ValidateProviders проверяет, что для каждого активного партнёра зарегистрирован провайдер
+// и провайдер принимает настройки партнёра (req.ConfigValidator)
+func ValidateProviders(groups []NetworkGroup) error {
+	for _, ng := range groups {
+		for _, p := range ng.Partners {
+			provider, err := req.Get(p.Provider)
+			if err != nil {
+				return fmt.Errorf(
+					"processor.ValidateProviders: партнёр %s в сети %s: %v (доступные: %s)",
+					p.Name, ng.GroupName, err, strings.Join(req.Providers(), ", "),
+				)
+			}
+			if v, ok := provider.(req.ConfigValidator); ok {
+				if err := v.ValidateConfig(p.Config); err != nil {
+					return fmt.Errorf("processor.ValidateProviders: партнёр %s в сети %s: %v", p.Name, ng.GroupName, err)
 				}
-				logger.Log.Debugf("Успешно вставлен баланс партнера %s (группа %s): %.2f", partner.Name, groupName, balance)
-				return nil
-			}(partner, groupName)
+			}
 		}
 	}
-	wg.Wait()
-	logger.Log.Info("Завершена операция вставки балансов")
 	return nil
 }
 
-// Функция получения среднего спенда из бд
-func GetStatistic() map[string]map[string]float64 {
-	stats := make(map[string]map[string]float64)
-	groups := PartnerList()
-	for _, v := range groups {
-		if stats[v.GroupName] == nil {
-			stats[v.GroupName] = make(map[string]float64)
-		}
-		for _, x := range v.Partners {
-			dbData, err := db.GetBalances(x.Name, v.GroupName)
+// SplitPartners заменяет в конфиге активных партнёров, которых провайдер разворачивает (req.Splitter),
+// на их части. Вызывается после ValidateProviders, до первого обращения к списку партнёров.
+func SplitPartners() error {
+	for _, ng := range PartnerList() {
+		partners := utils.AppConfig.Networks[ng.GroupName]
+		for _, p := range ng.Partners {
+			provider, err := req.Get(p.Provider)
 			if err != nil {
-				logger.Log.Errorf("Ошибка GetStatistic %s --- %s: %v", x.Name, v.GroupName, err)
-				return nil
+				continue
 			}
-			averData := AverageArrow(dbData)
-			averValue := AverageValue(averData)
-			stats[v.GroupName][x.Name] = averValue
-		}
-	}
-	return stats
-}
-
-// Функция формирования строки с текущим балансом
-func CallBalanceList(networkName string) string {
-	logger.Log.Infof("Вызов CallBalance для сети: %s", networkName)
-	balances := make(map[string]string)
-	for _, group := range PartnerList() {
-		if group.GroupName != networkName {
-			continue
-		}
-		for _, partner := range group.Partners {
-			balance, err := Router(partner)
+			splitter, ok := provider.(req.Splitter)
+			if !ok {
+				continue
+			}
+			parts, err := splitter.Split(p.Name, p.Config)
 			if err != nil {
-				logger.Log.Errorf("Ошибка получения баланса партнера %s: %v", partner.Name, err)
+				return fmt.Errorf("processor.SplitPartners: партнёр %s в сети %s: %v", p.Name, ng.GroupName, err)
+			}
+			if parts == nil {
 				continue
 			}
-			balances[partner.Name] = fmt.Sprintf("%.2f", RoundTo(balance, 2))
-			logger.Log.Debugf("Получен баланс партнера %s: %.2f", partner.Name, balance)
+			for name := range parts {
+				if _, dup := partners[name]; dup {
+					return fmt.Errorf("processor.SplitPartners: партнёр %s в сети %s уже есть в конфиге", name, ng.GroupName)
+				}
+			}
+			delete(partners, p.Name)
+			for name, cfg := range parts {
+				partners[name] = cfg
+			}
+			logger.Log.Infof("Партнёр %s в сети %s развёрнут в %d партнёров", p.Name, ng.GroupName, len(parts))
 		}
 	}
-	result := FormatMapWithTimestamp(balances)
-	logger.Log.Infof("Завершена операция CallBalance для сети %s", networkName)
-	return result
+	return nil
 }
 
-// формирование списка партнеров, которых надо будет пополнить
-func CompareBalances(networkName string) string {
-	logger.Log.Infof("Вызов CompareBalances для сети: %s", networkName)
-	stats := GetStatistic()
-	alerts := make(map[string]string)
-
-	for _, group := range PartnerList() {
-		if group.GroupName != networkName {
-			continue
-		}
-		for _, partner := range group.Partners {
-			avgVal, exists := stats[networkName][partner.Name]
-			if !exists {
-				logger.Log.Warnf("Не найдена статистика для партнёра %s", partner.Name)
-				continue
-			}
-
-			threshold := avgVal * 1.3
-
-			bal, err := Router(partner)
-			if err != nil {
-				logger.Log.Errorf("Ошибка получения баланса партнёра %s: %v", partner.Name, err)
-				continue
+// ScheduleGroups раскладывает активных партнёров по расписаниям сбора из конфига
+func ScheduleGroups() map[string][]NetworkGroup {
+	bySpec := make(map[string]map[string]*NetworkGroup)
+	for _, ng := range PartnerList() {
+		for _, partner := range ng.Partners {
+			spec := utils.AppConfig.ScheduleFor(ng.GroupName, partner.Name)
+			if bySpec[spec] == nil {
+				bySpec[spec] = make(map[string]*NetworkGroup)
 			}
-			if bal < threshold {
-				alerts[partner.Name] = fmt.Sprintf("%.2f (spend %.2f) ⚠️", RoundTo(bal, 2), RoundTo(avgVal, 2))
-				logger.Log.Warnf("Баланс партнёра %s ниже порога (%.2f < %.2f)", partner.Name, bal, threshold)
-			} else {
-				alerts[partner.Name] = fmt.Sprintf("%.2f (spend %.2f)", RoundTo(bal, 2), RoundTo(avgVal, 2))
-				logger.Log.Infof("Баланс партнёра %s в норме (%.2f < %.2f)", partner.Name, bal, threshold)
+			group, ok := bySpec[spec][ng.GroupName]
+			if !ok {
+				group = &NetworkGroup{GroupName: ng.GroupName}
+				bySpec[spec][ng.GroupName] = group
 			}
+			group.Partners = append(group.Partners, partner)
 		}
 	}
 
-	if len(alerts) == 0 {
-		logger.Log.Infof("Нет алертов для сети %s, сообщение не будет отправлено", networkName)
-		return ""
+	result := make(map[string][]NetworkGroup, len(bySpec))
+	for spec, groups := range bySpec {
+		for _, g := range groups {
+			result[spec] = append(result[spec], *g)
+		}
 	}
-	result := FormatMapWithTimestamp(alerts)
-	logger.Log.Infof("Завершена операция CompareBalances для сети %s", networkName)
 	return result
-	// ответ будет иметь такой формат
-	//–––––––––––––––––––––––––––––––––––––
-	//01-01-2025 / 17:16
-	//Partner1: 120.03 (spend 11.66)
-	//
-	//Partner2: 231.39 (spend 10.05)
-	// 			.	.	.
-	//PartnerN: 22.22 (spend 55.05) ⚠️
-	//–––––––––––––––––––––––––––––––––––––
-	//
 }
 
-func CallBalanceListWithStat(networkName string) string {
-	logger.Log.Infof("Вызов CompareBalances для сети: %s", networkName)
-	stats := GetStatistic()
-	alerts := make(map[string]string)
+// toStorageSnapshot переводит снимок провайдера в запись хранилища.
+// Валюта из ответа партнёра важнее валюты из конфига.
+func toStorageSnapshot(snap req.BalanceSnapshot, network, partnerName string) storage.Snapshot {
+	currency := strings.ToUpper(snap.Currency)
+	if currency == "" {
+		currency = utils.AppConfig.CurrencyFor(network, partnerName)
+	}
+	return storage.Snapshot{
+		Balance:     snap.Available,
+		Currency:    currency,
+		Reserved:    snap.Reserved,
+		CreditLimit: snap.CreditLimit,
+		Bonus:       snap.Bonus,
+		Extra:       snap.Extra,
+		Raw:         snap.Raw,
+	}
+}
 
-	for _, group := range PartnerList() {
-		if group.GroupName != networkName {
-			continue
-		}
-		for _, partner := range group.Partners {
-			avgVal, exists := stats[networkName][partner.Name]
-			if !exists {
-				logger.Log.Warnf("Не найдена статистика для партнёра %s", partner.Name)
-				continue
-			}
+// SpendWindow — за сколько дней назад берутся замеры для расчёта расхода
+const SpendWindow = 3
 
-			threshold := avgVal * 1.3
+// GetSpendStats считает расход партнёра по замерам за последние SpendWindow дней.
+// Интервалы с неудачными попытками сбора считаются пропусками.
+func (p *Processor) GetSpendStats(network string, partnerName string) (SpendStats, error) {
+	stats, _, err := p.spendAndFreshness(network, partnerName)
+	return stats, err
+}
 
-			bal, err := Router(partner)
-			if err != nil {
-				logger.Log.Errorf("Ошибка получения баланса партнёра %s: %v", partner.Name, err)
-				continue
-			}
-			if bal < threshold {
-				alerts[partner.Name] = fmt.Sprintf("%.2f (spend %.2f) ⚠️", RoundTo(bal, 2), RoundTo(avgVal, 2))
-				logger.Log.Warnf("Баланс партнёра %s ниже порога (%.2f < %.2f)", partner.Name, bal, threshold)
-			} else {
-				alerts[partner.Name] = fmt.Sprintf("%.2f (spend %.2f)", RoundTo(bal, 2), RoundTo(avgVal, 2))
-				logger.Log.Infof("Баланс партнёра %s в норме (%.2f < %.2f)", partner.Name, bal, threshold)
-			}
-		}
+// spendAndFreshness считает расход и свежесть данных партнёра за последние SpendWindow дней
+func (p *Processor) spendAndFreshness(network string, partnerName string) (SpendStats, Freshness, error) {
+	since := startOfDay(utils.LocalNow()).AddDate(0, 0, -SpendWindow)
+	samples, err := p.store.GetSamples(partnerName, network, since)
+	if err != nil {
+		return SpendStats{}, Freshness{}, err
 	}
-
-	if len(alerts) == 0 {
-		logger.Log.Infof("Нет алертов для сети %s, сообщение не будет отправлено", networkName)
-		return ""
+	attempts, err := p.store.GetFetchAttempts(network, partnerName, since)
+	if err != nil {
+		return SpendStats{}, Freshness{}, err
 	}
-	result := FormatMapWithTimestamp(alerts)
-	logger.Log.Infof("Завершена операция CompareBalances для сети %s", networkName)
-	return result
+	return SpendRateWithGaps(samples, failedAt(attempts)), freshness(attempts), nil
 }
+
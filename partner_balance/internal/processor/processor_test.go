@@ -1,57 +1,267 @@
 package processor
 
 import (
+    "context"
+    "errors"
+    "partner_balance/internal/logger"
+    "partner_balance/internal/req"
+    "partner_balance/internal/storage/memory"
+    "partner_balance/internal/utils"
     "testing"
+    "time"
 
+    "github.com/sirupsen/logrus"
+    logtest "github.com/sirupsen/logrus/hooks/test"
     "github.com/stretchr/testify/assert"
+    "gopkg.in/yaml.v3"
 )
 
-func TestAverageArrow(t *testing.T) {
-    data := []float64{1, 2, 3, 3, 4, 1, 2, 0, 4, 5, 6, 1}
-    got := AverageArrow(data)
-    want := []float64{1, 1, 1, 1, 1, 1}
-    assert.Equal(t, want, got)
+func TestRouter_UsesRegisteredProvider(t *testing.T) {
+    req.Register("router-test", req.ProviderFunc(func(ctx context.Context, cfg utils.PartnerConfig) (float64, error) {
+        if cfg.Token != "secret" {
+            return 0, errors.New("bad token")
+        }
+        return 42.5, nil
+    }))
+
+    got, err := Router(context.Background(), Partner{Name: "Any", Provider: "router-test", Config: utils.PartnerConfig{Token: "secret"}})
+    assert.NoError(t, err)
+    assert.Equal(t, 42.5, got)
+
+    _, err = Router(context.Background(), Partner{Name: "Any", Provider: "router-test", Config: utils.PartnerConfig{Token: "wrong"}})
+    assert.Error(t, err)
+}
+
+func TestRouter_UnknownProvider(t *testing.T) {
+    _, err := Router(context.Background(), Partner{Name: "Ghost", Provider: "no-such-provider"})
+    assert.Error(t, err)
+}
+
+func TestValidateProviders(t *testing.T) {
+    ok := []NetworkGroup{{GroupName: "net", Partners: []Partner{{Name: "Partner1", Provider: "partner1"}}}}
+    assert.NoError(t, ValidateProviders(ok))
+
+    bad := []NetworkGroup{{GroupName: "net", Partners: []Partner{{Name: "Partner9", Provider: "partner9"}}}}
+    assert.Error(t, ValidateProviders(bad))
+
+    noFeeds := []NetworkGroup{{GroupName: "net", Partners: []Partner{{Name: "Partner3", Provider: "partner3"}}}}
+    assert.ErrorContains(t, ValidateProviders(noFeeds), "feed_ids")
 }
-func TestAverageArrow2(t *testing.T) {
-    data := []float64{}
-    got := AverageArrow(data)
-    want := []float64{30, 30 ,30}
-    assert.Equal(t, want, got)
+
+func TestSplitPartners(t *testing.T) {
+    feeds := utils.PartnerConfig{IsActive: true, Provider: "partner3"}
+    assert.NoError(t, yaml.Unmarshal([]byte("feed_ids: ['1', '2']\nmode: individual"), &feeds.Params))
+    utils.AppConfig = utils.Config{Networks: map[string]map[string]utils.PartnerConfig{
+        "TestNet": {"Feeds": feeds, "Partner1": {IsActive: true, Provider: "partner1"}},
+    }}
+    t.Cleanup(func() { utils.AppConfig = utils.Config{} })
+
+    assert.NoError(t, SplitPartners())
+    var names []string
+    for _, p := range NetworkPartners("TestNet") {
+        names = append(names, p.Name)
+    }
+    assert.ElementsMatch(t, []string{"Feeds/1", "Feeds/2", "Partner1"}, names)
+}
+
+// setupProcessor настраивает конфиг с одной сетью и процессор поверх in-memory хранилища
+func setupProcessor(t *testing.T, provider string, history []float64) *Processor {
+    utils.AppConfig = utils.Config{Networks: map[string]map[string]utils.PartnerConfig{
+        "TestNet": {"Partner1": {Token: "t", IsActive: true, Provider: provider}},
+    }}
+    t.Cleanup(func() { utils.AppConfig = utils.Config{} })
+
+    store := memory.New()
+    // замеры раз в 6 часов — 4 замера в сутки
+    base := time.Now().Add(-13 * time.Hour)
+    assert.NoError(t, store.InsertPartner("Partner1", "TestNet", true))
+    for i, b := range history {
+        at := base.Add(time.Duration(i) * 6 * time.Hour)
+        store.Now = func() time.Time { return at }
+        assert.NoError(t, store.InsertBalance("Partner1", b, "TestNet"))
+    }
+    return New(store)
+}
+
+func TestSpendReport(t *testing.T) {
+    req.Register("compare-low", req.ProviderFunc(func(ctx context.Context, cfg utils.PartnerConfig) (float64, error) {
+        return 150, nil
+    }))
+    req.Register("compare-high", req.ProviderFunc(func(ctx context.Context, cfg utils.PartnerConfig) (float64, error) {
+        return 5000, nil
+    }))
+
+    lowReport, forecasts := setupProcessor(t, "compare-low", []float64{300, 200, 100}).SpendReport(context.Background(), "TestNet")
+    low := renderHTML(t, lowReport)
+    assert.Contains(t, low, "<b>Partner1</b>: 150.00 (spend 400.00) ⚠️")
+    assert.Contains(t, low, "0 через 9 ч")
+    assert.Len(t, forecasts, 1)
+    assert.True(t, forecasts[0].Known)
+    assert.InDelta(t, 9.0, forecasts[0].HoursLeft, 1e-9)
+
+    highReport, _ := setupProcessor(t, "compare-high", []float64{300, 200, 100}).SpendReport(context.Background(), "TestNet")
+    high := renderHTML(t, highReport)
+    assert.Contains(t, high, "<b>Partner1</b>: 5000.00 (spend 400.00)")
+    assert.NotContains(t, high, "⚠️")
+
+    empty, _ := setupProcessor(t, "compare-high", nil).SpendReport(context.Background(), "OtherNet")
+    assert.Empty(t, empty.Rows)
+    assert.Empty(t, renderHTML(t, empty))
 }
 
-func TestAverageValue(t *testing.T) {
-    data := []float64{25, 30, 35}
-    got := AverageValue(data)
-    want := 120.00
-    assert.Equal(t, want, got)
+func TestSpendStatus_LogsFiredRules(t *testing.T) {
+    req.Register("rules-logged", req.ProviderFunc(func(ctx context.Context, cfg utils.PartnerConfig) (float64, error) {
+        return 150, nil
+    }))
+    p := setupProcessor(t, "rules-logged", []float64{300, 200, 100})
+    hook := logtest.NewLocal(logger.Log)
+    t.Cleanup(func() { logger.Log.ReplaceHooks(make(logrus.LevelHooks)) })
+
+    // GetSpendStats берёт состояния из SpendStatus, минуя SpendReport
+    statuses := p.SpendStatus(context.Background(), "TestNet")
+    assert.Len(t, statuses, 1)
+    assert.True(t, statuses[0].Alert)
+
+    entry := hook.LastEntry()
+    if assert.NotNil(t, entry) {
+        assert.Contains(t, entry.Message, "Партнёр Partner1: сработало правило")
+    }
 }
-func TestAverageValue2(t *testing.T) {
-    data := []float64{-7.67, 8.96, 35.73, 62.84, 101.02, 133.2, 169.63, 190.69, 228.72, 286.39, 313.86}
-    got := AverageValue(AverageArrow(data))
-    want := 128.61
-    assert.Equal(t, want, got)
+
+func TestApplyRetention(t *testing.T) {
+    utils.AppConfig = utils.Config{
+        Networks: map[string]map[string]utils.PartnerConfig{
+            "TestNet": {"Partner1": {IsActive: true}},
+        },
+        Retention: utils.RetentionConfig{RetentionPolicy: utils.RetentionPolicy{KeepDays: 7, RollupKeepDays: 30}},
+    }
+    t.Cleanup(func() { utils.AppConfig = utils.Config{} })
+
+    store := memory.New()
+    assert.NoError(t, store.InsertPartner("Partner1", "TestNet", true))
+    now := utils.LocalNow()
+    for _, at := range []time.Time{now.AddDate(0, 0, -40), now.AddDate(0, 0, -10), now} {
+        store.Now = func() time.Time { return at }
+        assert.NoError(t, store.InsertBalance("Partner1", 100, "TestNet"))
+    }
+    store.Now = time.Now
+
+    assert.NoError(t, New(store).ApplyRetention())
+
+    // сырой замер старше keep_days удалён, но остался в дневном агрегате;
+    // агрегат старше rollup_keep_days удалён вместе с замером
+    rollups, err := store.GetDailyRollups("Partner1", "TestNet", now.AddDate(0, 0, -60))
+    assert.NoError(t, err)
+    assert.Len(t, rollups, 1)
+
+    deleted, err := store.DeleteBalancesBefore("TestNet", now.Add(time.Hour))
+    assert.NoError(t, err)
+    assert.Equal(t, int64(1), deleted)
 }
 
-func TestAverageArrow_Empty(t *testing.T) {
-    assert.Empty(t, AverageArrow([]float64{}))
+func TestRetentionFor_NetworkOverride(t *testing.T) {
+    keep, noRollup := 14, 0
+    cfg := utils.Config{Retention: utils.RetentionConfig{
+        RetentionPolicy: utils.RetentionPolicy{KeepDays: 7, RollupKeepDays: 365},
+        Networks: map[string]utils.RetentionOverride{
+            "Short":    {KeepDays: &keep},
+            "NoRollup": {RollupKeepDays: &noRollup},
+        },
+    }}
+
+    assert.Equal(t, utils.RetentionPolicy{KeepDays: 14, RollupKeepDays: 365}, cfg.RetentionFor("Short"))
+    assert.Equal(t, utils.RetentionPolicy{KeepDays: 7, RollupKeepDays: 0}, cfg.RetentionFor("NoRollup"), "явный 0 выключает свёртку")
+    assert.Equal(t, utils.RetentionPolicy{KeepDays: 7, RollupKeepDays: 365}, cfg.RetentionFor("Other"))
 }
 
-func TestAverageArrow_Single(t *testing.T) {
-    assert.Empty(t, AverageArrow([]float64{42}))
+func TestGetSpendStats_HourlyPolling(t *testing.T) {
+    utils.AppConfig = utils.Config{Networks: map[string]map[string]utils.PartnerConfig{
+        "TestNet": {"Partner1": {IsActive: true}},
+    }}
+    t.Cleanup(func() { utils.AppConfig = utils.Config{} })
+
+    // расход 10 в час при опросе раз в час — 240 в сутки
+    store := memory.New()
+    assert.NoError(t, store.InsertPartner("Partner1", "TestNet", true))
+    base := time.Now().Add(-12 * time.Hour)
+    for i := 0; i < 10; i++ {
+        at := base.Add(time.Duration(i) * time.Hour)
+        store.Now = func() time.Time { return at }
+        assert.NoError(t, store.InsertBalance("Partner1", 1000-float64(i)*10, "TestNet"))
+    }
+
+    spend, err := New(store).GetSpendStats("TestNet", "Partner1")
+    assert.NoError(t, err)
+    assert.Equal(t, 240.0, RoundTo(spend.PerDay, 2))
 }
 
-func TestAverageArrow_NoPositive(t *testing.T) {
+func TestScheduleGroups(t *testing.T) {
+    utils.AppConfig = utils.Config{
+        Networks: map[string]map[string]utils.PartnerConfig{
+            "NetA": {
+                "Partner1": {IsActive: true},
+                "Partner2": {IsActive: true, Schedule: "@every 1h"},
+                "Partner3": {IsActive: false, Schedule: "@every 5m"},
+            },
+            "NetB": {"Partner1": {IsActive: true}},
+        },
+        Schedule: utils.ScheduleConfig{Networks: map[string]string{"NetB": "@every 2h"}},
+    }
+    t.Cleanup(func() { utils.AppConfig = utils.Config{} })
 
-    assert.Empty(t, AverageArrow([]float64{5, 5, 5}))
-    assert.Empty(t, AverageArrow([]float64{10, 8, 6, 4}))
+    groups := ScheduleGroups()
+    assert.Len(t, groups, 3)
+    assert.Equal(t, "NetA", groups[utils.DefaultSchedule][0].GroupName)
+    assert.Equal(t, "Partner1", groups[utils.DefaultSchedule][0].Partners[0].Name)
+    assert.Equal(t, "Partner2", groups["@every 1h"][0].Partners[0].Name)
+    assert.Equal(t, "NetB", groups["@every 2h"][0].GroupName)
 }
 
+func TestSpendStatus_ReportsErrors(t *testing.T) {
+    req.Register("status-fail", req.ProviderFunc(func(ctx context.Context, cfg utils.PartnerConfig) (float64, error) {
+        return 0, errors.New("api down")
+    }))
+    req.Register("status-low", req.ProviderFunc(func(ctx context.Context, cfg utils.PartnerConfig) (float64, error) {
+        return 150, nil
+    }))
 
-func TestAverageArrow_PositiveDifferences(t *testing.T) {
-    data := []float64{1, 3, 2, 5}
-    got := AverageArrow(data)
+    statuses := setupProcessor(t, "status-fail", []float64{300, 200, 100}).SpendStatus(context.Background(), "TestNet")
+    assert.Len(t, statuses, 1)
+    assert.Error(t, statuses[0].Err)
+    assert.False(t, statuses[0].HasStats)
 
-    want := []float64{2, 3}
-    assert.Equal(t, want, got)
+    statuses = setupProcessor(t, "status-low", []float64{300, 200, 100}).SpendStatus(context.Background(), "TestNet")
+    assert.Len(t, statuses, 1)
+    assert.NoError(t, statuses[0].Err)
+    assert.True(t, statuses[0].HasStats)
+    assert.True(t, statuses[0].Alert)
+    assert.InDelta(t, 520.0, statuses[0].Threshold, 1e-9)
+    assert.Len(t, statuses[0].Alerts, 1)
+    assert.Equal(t, RuleSpendMultiplier, statuses[0].Alerts[0].Rule)
 }
 
+func TestSpendStatus_PartnerRules(t *testing.T) {
+    req.Register("rules-low", req.ProviderFunc(func(ctx context.Context, cfg utils.PartnerConfig) (float64, error) {
+        return 50, nil
+    }))
+
+    p := setupProcessor(t, "rules-low", []float64{300, 200, 100})
+    minBalance, drop, off := 80.0, 40.0, 0.0
+    partner := utils.AppConfig.Networks["TestNet"]["Partner1"]
+    partner.Alerts = utils.AlertRules{MinBalance: &minBalance, DropPercent: &drop, SpendMultiplier: &off}
+    utils.AppConfig.Networks["TestNet"]["Partner1"] = partner
+
+    statuses := p.SpendStatus(context.Background(), "TestNet")
+    assert.Len(t, statuses, 1)
+    var rules []AlertRule
+    for _, a := range statuses[0].Alerts {
+        rules = append(rules, a.Rule)
+    }
+    assert.Equal(t, []AlertRule{RuleMinBalance, RuleDropPercent}, rules)
+    assert.Zero(t, statuses[0].Threshold)
+
+    rep, _ := p.SpendReport(context.Background(), "TestNet")
+    text := renderHTML(t, rep)
+    assert.Contains(t, text, "⚠️ баланс 50.00 ниже минимума 80.00")
+    assert.Contains(t, text, "⚠️ баланс упал на 50.0% с 100.00, порог 40%")
+}
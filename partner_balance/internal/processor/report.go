@@ -0,0 +1,91 @@
+package processor
+
+import (
+	"context"
+	"partner_balance/internal/report"
+	"partner_balance/internal/utils"
+	"sort"
+	"time"
+)
+
+// BuildSpendReport строит отчёт /stat по состояниям партнёров из SpendStatus. Недоступные и
+// не ответившие вовремя партнёры отмечаются, партнёры с ошибкой, а также без расхода, тревог
+// и неудачных сборов пропускаются.
+func BuildSpendReport(network string, statuses []PartnerStatus, now time.Time) report.Report {
+	r := report.Report{Network: network, Kind: report.KindSpend, GeneratedAt: now}
+	for _, status := range statuses {
+		if status.Err != nil && !status.Unavailable() && !status.TimedOut {
+			continue
+		}
+		if status.Err == nil && !status.HasStats && !status.Alert && status.Freshness.FailedInRow == 0 {
+			continue
+		}
+		r.Rows = append(r.Rows, reportRow(status, now))
+	}
+	sortRows(r.Rows)
+	return r
+}
+
+// BuildBalanceReport строит отчёт /balance по текущим балансам из Balances
+func BuildBalanceReport(network string, statuses []PartnerStatus, now time.Time) report.Report {
+	r := report.Report{Network: network, Kind: report.KindBalances, GeneratedAt: now}
+	for _, status := range statuses {
+		r.Rows = append(r.Rows, reportRow(status, now))
+	}
+	sortRows(r.Rows)
+	return r
+}
+
+// reportRow переводит состояние партнёра в строку отчёта
+func reportRow(status PartnerStatus, now time.Time) report.Row {
+	row := report.Row{Partner: status.Partner}
+	switch {
+	case status.Unavailable():
+		row.State, row.UnavailableSince = report.StateUnavailable, status.UnavailableSince
+		return row
+	case status.TimedOut:
+		row.State = report.StateTimedOut
+		return row
+	case status.Err != nil:
+		row.State = report.StateNoData
+		return row
+	}
+	row.State = report.StateOK
+	row.Balance, row.Currency = status.Balance, status.Currency
+	row.ReportingBalance, row.ReportingCurrency = status.ReportingBalance, status.ReportingCurrency
+	row.AsOf = AsOf(status, now)
+	if status.HasStats {
+		row.HasSpend, row.SpendPerDay = true, status.SpendPerDay
+		row.Runway = FormatRunway(status.Runway, status.FetchedAt)
+	}
+	row.Alert = status.Alert
+	for _, a := range status.Alerts {
+		row.Reasons = append(row.Reasons, a.Reason)
+	}
+	row.Freshness = FormatFreshness(status.Freshness)
+	return row
+}
+
+func sortRows(rows []report.Row) {
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Partner < rows[j].Partner })
+}
+
+// SpendReport строит отчёт /stat по сети и возвращает прогнозы партнёров с посчитанным расходом
+func (p *Processor) SpendReport(ctx context.Context, networkName string) (report.Report, []Runway) {
+	statuses := p.SpendStatus(ctx, networkName)
+	var forecasts []Runway
+	for _, status := range statuses {
+		if status.Err != nil {
+			continue
+		}
+		if status.HasStats {
+			forecasts = append(forecasts, status.Runway)
+		}
+	}
+	return BuildSpendReport(networkName, statuses, utils.LocalNow()), forecasts
+}
+
+// BalanceReport строит отчёт /balance по сети
+func (p *Processor) BalanceReport(ctx context.Context, networkName string) report.Report {
+	return BuildBalanceReport(networkName, p.Balances(ctx, networkName), utils.LocalNow())
+}
@@ -0,0 +1,50 @@
+package processor
+
+import (
+	"errors"
+	"partner_balance/internal/report"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// renderHTML рендерит отчёт в формате по умолчанию, как его получает tg_router
+func renderHTML(t *testing.T, r report.Report) string {
+	t.Helper()
+	text, err := report.Render(report.DefaultFormat, r)
+	require.NoError(t, err)
+	return text
+}
+
+func TestBuildReports(t *testing.T) {
+	now := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+	statuses := []PartnerStatus{
+		{Partner: "Quiet", Balance: 900, FetchedAt: now},
+		{Partner: "Low", Balance: 50, FetchedAt: now.Add(-time.Hour), HasStats: true, SpendPerDay: 100,
+			Alert: true, Alerts: []FiredAlert{{Rule: RuleMinBalance, Reason: "баланс 50.00 ниже минимума 80.00"}}},
+		{Partner: "Broken", Err: errors.New("500"), FetchedAt: now},
+		{Partner: "Slow", Err: errors.New("deadline"), TimedOut: true, FetchedAt: now},
+		{Partner: "Down", Err: errors.New("open"), UnavailableSince: now.Add(-time.Hour), FetchedAt: now},
+	}
+
+	spend := BuildSpendReport("TestNet", statuses, now)
+	assert.Equal(t, report.KindSpend, spend.Kind)
+	require.Len(t, spend.Rows, 3, "без расхода, тревог и с ошибкой партнёры пропускаются")
+	assert.Equal(t, "Down", spend.Rows[0].Partner)
+	assert.Equal(t, report.StateUnavailable, spend.Rows[0].State)
+	low := spend.Rows[1]
+	assert.Equal(t, report.StateOK, low.State)
+	assert.Equal(t, now.Add(-time.Hour), low.AsOf)
+	assert.True(t, low.HasSpend)
+	assert.Equal(t, []string{"баланс 50.00 ниже минимума 80.00"}, low.Reasons)
+	assert.Equal(t, report.StateTimedOut, spend.Rows[2].State)
+
+	balances := BuildBalanceReport("TestNet", statuses, now)
+	require.Len(t, balances.Rows, 5)
+	assert.Equal(t, "Broken", balances.Rows[0].Partner)
+	assert.Equal(t, report.StateNoData, balances.Rows[0].State)
+	assert.Equal(t, "Quiet", balances.Rows[3].Partner)
+	assert.True(t, balances.Rows[3].AsOf.IsZero())
+}
@@ -0,0 +1,68 @@
+package processor
+
+import (
+	"fmt"
+	"partner_balance/internal/logger"
+	"partner_balance/internal/utils"
+	"time"
+)
+
+// startOfDay возвращает полночь дня t в его часовом поясе
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// ApplyRetention чистит историю всех сетей из конфига по их политике хранения.
+// Если для сети включены агрегаты, сырые замеры перед удалением сворачиваются в дневные min/max/last.
+// Ошибка одной сети не мешает обработать остальные.
+func (p *Processor) ApplyRetention() error {
+	today := startOfDay(utils.LocalNow())
+	var failed []string
+
+	for _, network := range utils.NetworkNames() {
+		policy := utils.AppConfig.RetentionFor(network)
+		if err := p.applyRetention(network, policy, today); err != nil {
+			logger.Log.Errorf("Ошибка очистки истории сети %s: %v", network, err)
+			failed = append(failed, network)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("не удалось очистить историю сетей: %v", failed)
+	}
+	logger.Log.Info("Операция ApplyRetention завершена")
+	return nil
+}
+
+func (p *Processor) applyRetention(network string, policy utils.RetentionPolicy, today time.Time) error {
+	rawBefore := today.AddDate(0, 0, -policy.KeepDays)
+
+	if policy.RollupKeepDays > 0 {
+		if err := p.store.RollupDaily(network, rawBefore); err != nil {
+			return err
+		}
+	}
+
+	deleted, err := p.store.DeleteBalancesBefore(network, rawBefore)
+	if err != nil {
+		return err
+	}
+	logger.Log.Infof("Сеть %s: удалено %d замеров старше %s", network, deleted, rawBefore.Format("02-01-2006"))
+
+	deleted, err = p.store.DeleteFetchAttemptsBefore(network, rawBefore)
+	if err != nil {
+		return err
+	}
+	logger.Log.Infof("Сеть %s: удалено %d попыток сбора старше %s", network, deleted, rawBefore.Format("02-01-2006"))
+
+	if policy.RollupKeepDays > 0 {
+		rollupBefore := today.AddDate(0, 0, -policy.RollupKeepDays)
+		deleted, err := p.store.DeleteRollupsBefore(network, rollupBefore)
+		if err != nil {
+			return err
+		}
+		logger.Log.Infof("Сеть %s: удалено %d дневных агрегатов старше %s", network, deleted, rollupBefore.Format("02-01-2006"))
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package processor
+
+import (
+	"partner_balance/internal/storage"
+	"sort"
+	"time"
+)
+
+// GapFactor — интервал между замерами длиннее медианного в GapFactor раз считается пропуском:
+// баланс за такой интервал мог быть пополнен и потрачен, поэтому он не участвует в расчёте расхода.
+const GapFactor = 3
+
+// SpendStats — расход партнёра, посчитанный по временным меткам замеров
+type SpendStats struct {
+	PerHour     float64       // расход в час
+	PerDay      float64       // расход в сутки
+	From        time.Time     // время первого замера
+	To          time.Time     // время последнего замера
+	Window      time.Duration // To - From
+	Covered     time.Duration // время, по которому посчитан расход (без пополнений и пропусков)
+	Consumed    float64       // сколько потрачено за Covered
+	Samples     int           // количество замеров
+	TopUps      int           // сколько раз баланс вырос между замерами
+	TopUpAmount float64       // суммарный прирост баланса
+	Gaps        int           // сколько интервалов отброшено как пропуски
+	FailedFetch int           // сколько из пропусков подтверждены неудачными попытками сбора
+}
+
+// SpendRate считает расход по замерам баланса в любом порядке.
+// Рост баланса между соседними замерами считается пополнением и исключается из расхода,
+// слишком длинные интервалы (см. GapFactor) считаются пропусками и тоже исключаются.
+func SpendRate(samples []storage.Sample) SpendStats {
+	return SpendRateWithGaps(samples, nil)
+}
+
+// SpendRateWithGaps считает расход как SpendRate, но интервал между замерами, внутри которого
+// была неудачная попытка сбора (failed), всегда считается пропуском, даже если он не длинный.
+func SpendRateWithGaps(samples []storage.Sample, failed []time.Time) SpendStats {
+	failures := make([]time.Time, len(failed))
+	copy(failures, failed)
+	sort.Slice(failures, func(i, j int) bool { return failures[i].Before(failures[j]) })
+
+	sorted := make([]storage.Sample, len(samples))
+	copy(sorted, samples)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].CreatedAt.Before(sorted[j].CreatedAt) })
+
+	stats := SpendStats{Samples: len(sorted)}
+	if len(sorted) == 0 {
+		return stats
+	}
+	stats.From = sorted[0].CreatedAt
+	stats.To = sorted[len(sorted)-1].CreatedAt
+	stats.Window = stats.To.Sub(stats.From)
+
+	maxInterval := time.Duration(0)
+	if median := medianInterval(sorted); median > 0 {
+		maxInterval = median * GapFactor
+	}
+
+	for i := 1; i < len(sorted); i++ {
+		prev, curr := sorted[i-1], sorted[i]
+		dt := curr.CreatedAt.Sub(prev.CreatedAt)
+		if dt <= 0 {
+			continue
+		}
+		delta := prev.Balance - curr.Balance
+		if delta < 0 {
+			stats.TopUps++
+			stats.TopUpAmount += -delta
+			continue
+		}
+		if failedBetween(failures, prev.CreatedAt, curr.CreatedAt) {
+			stats.Gaps++
+			stats.FailedFetch++
+			continue
+		}
+		if maxInterval > 0 && dt > maxInterval {
+			stats.Gaps++
+			continue
+		}
+		stats.Consumed += delta
+		stats.Covered += dt
+	}
+
+	if stats.Covered > 0 {
+		stats.PerHour = stats.Consumed / stats.Covered.Hours()
+		stats.PerDay = stats.PerHour * 24
+	}
+	return stats
+}
+
+// failedBetween сообщает, была ли неудачная попытка строго между from и to; failures отсортированы
+func failedBetween(failures []time.Time, from, to time.Time) bool {
+	i := sort.Search(len(failures), func(i int) bool { return failures[i].After(from) })
+	return i < len(failures) && failures[i].Before(to)
+}
+
+// medianInterval — медианный интервал между соседними замерами (отсортированными по времени)
+func medianInterval(sorted []storage.Sample) time.Duration {
+	var intervals []time.Duration
+	for i := 1; i < len(sorted); i++ {
+		if dt := sorted[i].CreatedAt.Sub(sorted[i-1].CreatedAt); dt > 0 {
+			intervals = append(intervals, dt)
+		}
+	}
+	if len(intervals) == 0 {
+		return 0
+	}
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i] < intervals[j] })
+	return intervals[len(intervals)/2]
+}
@@ -0,0 +1,131 @@
+package processor
+
+import (
+	"partner_balance/internal/storage"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// series строит замеры с заданными смещениями в часах от базового времени
+func series(points ...[2]float64) []storage.Sample {
+	base := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	samples := make([]storage.Sample, len(points))
+	for i, p := range points {
+		samples[i] = storage.Sample{
+			CreatedAt: base.Add(time.Duration(p[0] * float64(time.Hour))),
+			Balance:   p[1],
+		}
+	}
+	return samples
+}
+
+func TestSpendRate(t *testing.T) {
+	tests := []struct {
+		name        string
+		samples     []storage.Sample
+		perHour     float64
+		perDay      float64
+		topUps      int
+		topUpAmount float64
+		gaps        int
+		window      time.Duration
+	}{
+		{
+			name:    "no samples",
+			samples: nil,
+		},
+		{
+			name:    "single sample",
+			samples: series([2]float64{0, 100}),
+		},
+		{
+			name:    "steady spend every 6 hours",
+			samples: series([2]float64{0, 400}, [2]float64{6, 300}, [2]float64{12, 200}, [2]float64{18, 100}),
+			perHour: 100.0 / 6,
+			perDay:  400,
+			window:  18 * time.Hour,
+		},
+		{
+			name:    "hourly polling",
+			samples: series([2]float64{0, 1000}, [2]float64{1, 990}, [2]float64{2, 980}, [2]float64{3, 970}),
+			perHour: 10,
+			perDay:  240,
+			window:  3 * time.Hour,
+		},
+		{
+			name:    "newest first input is sorted",
+			samples: series([2]float64{3, 970}, [2]float64{2, 980}, [2]float64{1, 990}, [2]float64{0, 1000}),
+			perHour: 10,
+			perDay:  240,
+			window:  3 * time.Hour,
+		},
+		{
+			name: "top-up is excluded",
+			samples: series(
+				[2]float64{0, 100}, [2]float64{1, 90}, [2]float64{2, 80},
+				[2]float64{3, 580}, [2]float64{4, 570},
+			),
+			perHour:     10,
+			perDay:      240,
+			topUps:      1,
+			topUpAmount: 500,
+			window:      4 * time.Hour,
+		},
+		{
+			name: "missing samples are a gap",
+			samples: series(
+				[2]float64{0, 100}, [2]float64{1, 90}, [2]float64{2, 80},
+				[2]float64{12, 75}, [2]float64{13, 65},
+			),
+			perHour: 10,
+			perDay:  240,
+			gaps:    1,
+			window:  13 * time.Hour,
+		},
+		{
+			name:    "only top-ups",
+			samples: series([2]float64{0, 100}, [2]float64{1, 200}),
+			topUps:  1, topUpAmount: 100,
+			window: time.Hour,
+		},
+		{
+			name:    "flat balance",
+			samples: series([2]float64{0, 50}, [2]float64{1, 50}, [2]float64{2, 50}),
+			window:  2 * time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SpendRate(tt.samples)
+			assert.InDelta(t, tt.perHour, got.PerHour, 1e-9)
+			assert.InDelta(t, tt.perDay, got.PerDay, 1e-9)
+			assert.Equal(t, len(tt.samples), got.Samples)
+			assert.Equal(t, tt.topUps, got.TopUps)
+			assert.InDelta(t, tt.topUpAmount, got.TopUpAmount, 1e-9)
+			assert.Equal(t, tt.gaps, got.Gaps)
+			assert.Equal(t, tt.window, got.Window)
+		})
+	}
+}
+
+func TestSpendRateWithGaps(t *testing.T) {
+	samples := series([2]float64{0, 1000}, [2]float64{1, 990}, [2]float64{2, 980}, [2]float64{3, 900})
+	base := samples[0].CreatedAt
+
+	// попытка в 2:30 не удалась — интервал 2→3 с подозрительно большим расходом отбрасывается
+	got := SpendRateWithGaps(samples, []time.Time{base.Add(150 * time.Minute)})
+	assert.Equal(t, 1, got.Gaps)
+	assert.Equal(t, 1, got.FailedFetch)
+	assert.InDelta(t, 10, got.PerHour, 1e-9)
+	assert.Equal(t, 2*time.Hour, got.Covered)
+
+	// неудачи вне интервалов между замерами и ровно в момент замера пропуском не считаются
+	got = SpendRateWithGaps(samples, []time.Time{base.Add(-time.Hour), base.Add(time.Hour), base.Add(4 * time.Hour)})
+	assert.Zero(t, got.Gaps)
+	assert.InDelta(t, 100.0/3, got.PerHour, 1e-9)
+
+	assert.Equal(t, SpendRate(samples), SpendRateWithGaps(samples, nil))
+}
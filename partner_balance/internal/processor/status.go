@@ -0,0 +1,144 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"partner_balance/internal/logger"
+	"partner_balance/internal/utils"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PartnerStatus — типизированное состояние партнёра, из которого строятся отчёты и ответы gRPC
+type PartnerStatus struct {
+	Partner   string
+	Balance   float64
+	Currency  string    // код валюты, пусто — неизвестна
+	FetchedAt time.Time // когда получен баланс; для снимка из кэша — время его сбора
+	Err       error     // ошибка получения баланса, остальные поля тогда не заполнены
+	// UnavailableSince — цепь партнёра разомкнута, с какого момента он недоступен; нулевое — доступен
+	UnavailableSince time.Time
+	TimedOut         bool // партнёр не ответил за время, отведённое отчёту
+
+	HasStats    bool    // расход посчитан и поля ниже заполнены
+	SpendPerDay float64 // средний расход в сутки
+	Threshold   float64 // порог правила spend_multiplier, 0 если правило выключено
+	Runway      Runway  // прогноз окончания баланса
+
+	Alert  bool         // сработало хотя бы одно правило тревоги
+	Alerts []FiredAlert // сработавшие правила и причины
+
+	Freshness Freshness // свежесть собранных данных (только в SpendStatus)
+
+	// Суммы в валюте отчётов; ReportingCurrency пуст, если пересчёт не настроен или невозможен
+	ReportingCurrency    string
+	ReportingBalance     float64
+	ReportingSpendPerDay float64 // 0, если расход пересчитать не удалось
+}
+
+// NetworkPartners возвращает активных партнёров сети, отсортированных по имени
+func NetworkPartners(networkName string) []Partner {
+	var partners []Partner
+	for _, group := range PartnerList() {
+		if group.GroupName == networkName {
+			partners = append(partners, group.Partners...)
+		}
+	}
+	sort.Slice(partners, func(i, j int) bool { return partners[i].Name < partners[j].Name })
+	return partners
+}
+
+// Balances возвращает балансы всех активных партнёров сети: свежие снимки из кэша,
+// устаревшие запрашиваются у партнёров одновременно. Отчёт ждёт не дольше report.fetch_timeout_seconds
+// и дедлайна ctx: не ответившие к этому времени партнёры отмечаются TimedOut, остальные возвращаются как есть.
+func (p *Processor) Balances(ctx context.Context, networkName string) []PartnerStatus {
+	ctx, cancel := context.WithTimeout(ctx, utils.AppConfig.Report.FetchTimeout())
+	defer cancel()
+
+	partners := NetworkPartners(networkName)
+	result := make([]PartnerStatus, len(partners))
+	var wg sync.WaitGroup
+	for i, partner := range partners {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result[i] = p.balance(ctx, networkName, partner)
+		}()
+	}
+	wg.Wait()
+	return result
+}
+
+// balance получает баланс одного партнёра для отчёта
+func (p *Processor) balance(ctx context.Context, networkName string, partner Partner) PartnerStatus {
+	status := PartnerStatus{Partner: partner.Name}
+	snap, fetchedAt, err := p.snapshot(ctx, partner)
+	status.FetchedAt = fetchedAt
+	if err != nil {
+		status.FetchedAt = utils.LocalNow()
+	}
+	var open *CircuitOpenError
+	switch {
+	case errors.As(err, &open):
+		status.Err = err
+		status.UnavailableSince = open.Since
+	case err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded):
+		status.Err = err
+		status.TimedOut = true
+		logger.FromContext(ctx).Warnf("Партнёр %s (%s) не ответил за время отчёта", partner.Name, networkName)
+	case err != nil:
+		status.Err = err
+		logger.FromContext(ctx).Errorf("Ошибка получения баланса партнера %s: %v", partner.Name, status.Err)
+	default:
+		stored := toStorageSnapshot(snap, networkName, partner.Name)
+		status.Balance, status.Currency = stored.Balance, stored.Currency
+		p.applyReporting(&status)
+	}
+	return status
+}
+
+// SpendStatus получает текущие балансы партнёров сети, дополняет их расходом и прогнозом
+// и проверяет правила тревоги партнёра
+func (p *Processor) SpendStatus(ctx context.Context, networkName string) []PartnerStatus {
+	result := p.Balances(ctx, networkName)
+	lead := utils.AppConfig.TopUpLead()
+
+	for i := range result {
+		status := &result[i]
+		if status.Err != nil {
+			continue
+		}
+		rules := utils.AppConfig.AlertRulesFor(networkName, status.Partner)
+		in := AlertInput{Balance: status.Balance}
+
+		if spend, fresh, err := p.spendAndFreshness(networkName, status.Partner); err != nil {
+			logger.Log.Warnf("Не найдена статистика для партнёра %s: %v", status.Partner, err)
+		} else {
+			status.Freshness = fresh
+			avgVal := RoundTo(spend.PerDay, 2)
+			status.HasStats = true
+			status.SpendPerDay = avgVal
+			status.Threshold = SpendThreshold(rules, avgVal)
+			status.Runway = Forecast(status.Partner, status.Balance, avgVal, status.FetchedAt, lead)
+			in.HasStats, in.SpendPerDay, in.Runway = true, avgVal, status.Runway
+			p.applyReporting(status)
+		}
+
+		if history, err := p.store.GetBalances(status.Partner, networkName); err == nil && len(history) > 0 {
+			in.HasPrev, in.PrevBalance = true, history[0]
+		}
+
+		status.Alerts = EvaluateAlerts(rules, in)
+		status.Alert = len(status.Alerts) > 0
+		for _, a := range status.Alerts {
+			logger.Log.Warnf("Партнёр %s: сработало правило %s: %s", status.Partner, a.Rule, a.Reason)
+		}
+	}
+	return result
+}
+
+// Unavailable сообщает, что цепь партнёра разомкнута и баланс не запрашивался
+func (s PartnerStatus) Unavailable() bool {
+	return !s.UnavailableSince.IsZero()
+}
@@ -0,0 +1,124 @@
+package processor
+
+import (
+	"context"
+	"partner_balance/internal/req"
+	"partner_balance/internal/storage/memory"
+	"partner_balance/internal/utils"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// refreshDeadline — сколько осталось до дедлайна обновления, которое видел провайдер report-deadline
+var refreshDeadline atomic.Int64
+
+// reportRelease отпускает зависший провайдер report-slow; setupReport создаёт его на каждый тест
+var reportRelease chan struct{}
+
+func init() {
+	req.Register("report-fast", req.ProviderFunc(func(ctx context.Context, cfg utils.PartnerConfig) (float64, error) {
+		time.Sleep(30 * time.Millisecond)
+		return 250, nil
+	}))
+	req.Register("report-deadline", req.ProviderFunc(func(ctx context.Context, cfg utils.PartnerConfig) (float64, error) {
+		if deadline, ok := ctx.Deadline(); ok {
+			refreshDeadline.Store(int64(time.Until(deadline)))
+		}
+		return 10, nil
+	}))
+	req.Register("report-slow", req.ProviderFunc(func(ctx context.Context, cfg utils.PartnerConfig) (float64, error) {
+		select {
+		case <-reportRelease:
+			return 10, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}))
+}
+
+// setupReport настраивает сеть ReportNet из партнёров с указанными провайдерами
+func setupReport(t *testing.T, providers map[string]string) *Processor {
+	freshHealth(t)
+	reportRelease = make(chan struct{})
+	partners := make(map[string]utils.PartnerConfig)
+	for name, provider := range providers {
+		partners[name] = utils.PartnerConfig{Token: name, IsActive: true, Provider: provider}
+	}
+	utils.AppConfig = utils.Config{Networks: map[string]map[string]utils.PartnerConfig{"ReportNet": partners}}
+	t.Cleanup(func() { utils.AppConfig = utils.Config{} })
+	return New(memory.New())
+}
+
+// releaseSlow отпускает report-slow и дожидается общего обновления снимка,
+// чтобы оно не пережило тест и не читало конфиг следующего
+func releaseSlow(p *Processor) {
+	close(reportRelease)
+	p.Balances(context.Background(), "ReportNet")
+}
+
+func TestBalances_FetchesConcurrently(t *testing.T) {
+	p := setupReport(t, map[string]string{"A": "report-fast", "B": "report-fast", "C": "report-fast", "D": "report-fast"})
+
+	started := time.Now()
+	st := p.Balances(context.Background(), "ReportNet")
+	assert.Less(t, time.Since(started), 100*time.Millisecond, "партнёры опрашиваются одновременно")
+	require.Len(t, st, 4)
+	for _, s := range st {
+		assert.NoError(t, s.Err)
+		assert.Equal(t, 250.0, s.Balance)
+	}
+}
+
+func TestBalances_PartialOnTimeout(t *testing.T) {
+	p := setupReport(t, map[string]string{"Fast": "report-fast", "Slow": "report-slow"})
+	t.Cleanup(func() { releaseSlow(p) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	st := p.Balances(ctx, "ReportNet")
+	assert.Less(t, time.Since(started), 500*time.Millisecond, "отчёт не ждёт зависшего партнёра")
+
+	require.Len(t, st, 2)
+	assert.Equal(t, "Fast", st[0].Partner)
+	assert.NoError(t, st[0].Err)
+	assert.False(t, st[0].TimedOut)
+	assert.Equal(t, 250.0, st[0].Balance)
+	assert.Equal(t, "Slow", st[1].Partner)
+	assert.Error(t, st[1].Err)
+	assert.True(t, st[1].TimedOut)
+
+	assert.Contains(t, renderHTML(t, BuildBalanceReport("ReportNet", st, time.Now())), "<b>Slow</b>: не ответил вовремя")
+}
+
+func TestBalances_CanceledIsNotTimeout(t *testing.T) {
+	p := setupReport(t, map[string]string{"Slow": "report-slow"})
+	t.Cleanup(func() { releaseSlow(p) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	st := p.Balances(ctx, "ReportNet")
+	require.Len(t, st, 1)
+	assert.ErrorIs(t, st[0].Err, context.Canceled)
+	assert.False(t, st[0].TimedOut)
+}
+
+func TestBalances_RefreshOutlivesCallerWithinFetchTimeout(t *testing.T) {
+	p := setupReport(t, map[string]string{"A": "report-deadline"})
+	utils.AppConfig.Report = utils.ReportConfig{FetchTimeoutSeconds: 5}
+	refreshDeadline.Store(0)
+
+	// дедлайн вызывающего короче: общее обновление его не наследует, но ограничено fetch_timeout_seconds
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	st := p.Balances(ctx, "ReportNet")
+	require.Len(t, st, 1)
+	require.NoError(t, st[0].Err)
+	left := time.Duration(refreshDeadline.Load())
+	assert.Greater(t, left, 4*time.Second)
+	assert.LessOrEqual(t, left, 5*time.Second)
+}
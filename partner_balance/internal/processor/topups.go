@@ -0,0 +1,92 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"partner_balance/internal/logger"
+	"partner_balance/internal/storage"
+	"partner_balance/internal/utils"
+	"sort"
+	"time"
+)
+
+// TopUpSummary — пополнения партнёра за период
+type TopUpSummary struct {
+	Partner string
+	Count   int
+	Total   float64 // сумма оценок пополнений
+}
+
+// DetectTopUp сравнивает два последних замера партнёра и, если баланс вырос, записывает пополнение.
+// Время пополнения неизвестно, поэтому берётся середина интервала, а к приросту добавляется
+// расход за интервал, посчитанный по замерам до пополнения без пропусков, как в отчётах о расходе
+// (см. SpendRateWithGaps). Возвращает nil, если пополнения нет.
+// Повторная проверка того же замера пополнение не дублирует. Запись в лог помечается run_id из ctx.
+func (p *Processor) DetectTopUp(ctx context.Context, network, partnerName string) (*storage.TopUp, error) {
+	since := startOfDay(utils.LocalNow()).AddDate(0, 0, -SpendWindow)
+	samples, err := p.store.GetSamples(partnerName, network, since)
+	if err != nil {
+		return nil, err
+	}
+	if len(samples) < 2 {
+		return nil, nil
+	}
+
+	prev, curr := samples[len(samples)-2], samples[len(samples)-1]
+	delta := curr.Balance - prev.Balance
+	if delta <= 0 {
+		return nil, nil
+	}
+	attempts, err := p.store.GetFetchAttempts(network, partnerName, since)
+	if err != nil {
+		return nil, err
+	}
+	dt := curr.CreatedAt.Sub(prev.CreatedAt)
+	spend := SpendRateWithGaps(samples[:len(samples)-1], failedAt(attempts))
+
+	topUp := &storage.TopUp{
+		Network:     network,
+		Partner:     partnerName,
+		At:          prev.CreatedAt.Add(dt / 2),
+		Amount:      RoundTo(delta+spend.PerHour*dt.Hours(), 2),
+		Delta:       RoundTo(delta, 2),
+		PrevBalance: prev.Balance,
+		Balance:     curr.Balance,
+		DetectedAt:  curr.CreatedAt,
+	}
+	err = p.store.InsertTopUp(topUp)
+	if errors.Is(err, storage.ErrTopUpExists) {
+		// этот замер уже проверялся
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	logger.FromContext(ctx).Infof("Пополнение партнёра %s (%s): %.2f → %.2f, оценка суммы %.2f", partnerName, network, prev.Balance, curr.Balance, topUp.Amount)
+	return topUp, nil
+}
+
+// TopUps возвращает пополнения сети за период и итоги по партнёрам, отсортированные по имени
+func (p *Processor) TopUps(network, partnerName string, from, to time.Time) ([]storage.TopUp, []TopUpSummary, error) {
+	topUps, err := p.store.GetTopUps(network, partnerName, from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byPartner := make(map[string]*TopUpSummary)
+	for _, t := range topUps {
+		s, ok := byPartner[t.Partner]
+		if !ok {
+			s = &TopUpSummary{Partner: t.Partner}
+			byPartner[t.Partner] = s
+		}
+		s.Count++
+		s.Total = RoundTo(s.Total+t.Amount, 2)
+	}
+	summaries := make([]TopUpSummary, 0, len(byPartner))
+	for _, s := range byPartner {
+		summaries = append(summaries, *s)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Partner < summaries[j].Partner })
+	return topUps, summaries, nil
+}
@@ -0,0 +1,83 @@
+package processor
+
+import (
+	"context"
+	"partner_balance/internal/storage"
+	"partner_balance/internal/storage/memory"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectTopUp(t *testing.T) {
+	store := memory.New()
+	require.NoError(t, store.InsertPartner("Partner1", "TestNet", true))
+	p := New(store)
+
+	// расход 10 в час, затем пополнение между замерами с интервалом 2 часа
+	base := time.Now().Add(-6 * time.Hour)
+	insert := func(hours int, balance float64) {
+		at := base.Add(time.Duration(hours) * time.Hour)
+		store.Now = func() time.Time { return at }
+		require.NoError(t, store.InsertBalance("Partner1", balance, "TestNet"))
+	}
+
+	insert(0, 300)
+	insert(2, 280)
+	topUp, err := p.DetectTopUp(context.Background(), "TestNet", "Partner1")
+	require.NoError(t, err)
+	assert.Nil(t, topUp)
+
+	insert(4, 1260)
+	topUp, err = p.DetectTopUp(context.Background(), "TestNet", "Partner1")
+	require.NoError(t, err)
+	require.NotNil(t, topUp)
+	assert.Equal(t, 980.0, topUp.Delta)
+	assert.InDelta(t, 1000.0, topUp.Amount, 1e-9)
+	assert.Equal(t, base.Add(3*time.Hour), topUp.At)
+	assert.Equal(t, base.Add(4*time.Hour), topUp.DetectedAt)
+
+	topUp, err = p.DetectTopUp(context.Background(), "TestNet", "Partner1")
+	require.NoError(t, err)
+	assert.Nil(t, topUp, "повторная проверка того же замера не дублирует пополнение")
+
+	insert(6, 1240)
+	topUp, err = p.DetectTopUp(context.Background(), "TestNet", "Partner1")
+	require.NoError(t, err)
+	assert.Nil(t, topUp)
+
+	topUps, summaries, err := p.TopUps("TestNet", "", base, base.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.Len(t, topUps, 1)
+	assert.Equal(t, []TopUpSummary{{Partner: "Partner1", Count: 1, Total: 1000}}, summaries)
+}
+
+func TestDetectTopUp_SkipsFailedFetches(t *testing.T) {
+	store := memory.New()
+	require.NoError(t, store.InsertPartner("Partner1", "TestNet", true))
+	p := New(store)
+
+	// расход 10 в час; интервал с неудачным сбором (списание 40) в оценку расхода не входит
+	base := time.Now().Add(-8 * time.Hour)
+	insert := func(hours int, balance float64) {
+		at := base.Add(time.Duration(hours) * time.Hour)
+		store.Now = func() time.Time { return at }
+		require.NoError(t, store.InsertBalance("Partner1", balance, "TestNet"))
+	}
+	insert(0, 400)
+	insert(1, 390)
+	insert(2, 380)
+	require.NoError(t, store.InsertFetchAttempt(&storage.FetchAttempt{
+		Network: "TestNet", Partner: "Partner1", StartedAt: base.Add(150 * time.Minute), ErrorClass: "timeout",
+	}))
+	insert(3, 340)
+	insert(5, 1320)
+
+	topUp, err := p.DetectTopUp(context.Background(), "TestNet", "Partner1")
+	require.NoError(t, err)
+	require.NotNil(t, topUp)
+	assert.Equal(t, 980.0, topUp.Delta)
+	assert.InDelta(t, 1000.0, topUp.Amount, 1e-9)
+}
@@ -0,0 +1,245 @@
+package report
+
+import (
+	"embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"html"
+	"io/fs"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"text/template"
+	"time"
+)
+
+// Встроенные форматы отчёта — имена шаблонов из templates/
+const (
+	FormatTelegramHTML = "telegram_html"
+	FormatMarkdown     = "markdown" // разметка Markdown, которую понимает Telegram
+	FormatPlain        = "plain"
+	FormatCSV          = "csv"
+
+	DefaultFormat = FormatTelegramHTML
+)
+
+// Разметки форматов: от разметки зависят экранирование строк в шаблоне и parse_mode Telegram
+const (
+	MarkupHTML     = "html"
+	MarkupMarkdown = "markdown"
+	MarkupPlain    = "plain"
+)
+
+// ErrUnknownFormat — шаблона с таким именем нет
+var ErrUnknownFormat = errors.New("неизвестный формат отчёта")
+
+//go:embed templates/*.tmpl
+var templatesFS embed.FS
+
+// commonTemplate — общие блоки всех форматов
+const commonTemplate = "common.tmpl"
+
+// markup описывает разметку формата
+type markup struct {
+	escape    func(string) string // экранирует строки партнёров и причин тревог; поля CSV экранирует функция csv
+	parseMode string              // parse_mode Telegram, пусто — текст без разметки
+}
+
+var markups = map[string]markup{
+	MarkupHTML:     {escape: html.EscapeString, parseMode: "HTML"},
+	MarkupMarkdown: {escape: escapeMarkdown, parseMode: "Markdown"},
+	MarkupPlain:    {escape: func(s string) string { return s }},
+}
+
+// builtinFormats — разметка встроенных форматов
+var builtinFormats = map[string]string{
+	FormatTelegramHTML: MarkupHTML,
+	FormatMarkdown:     MarkupMarkdown,
+	FormatPlain:        MarkupPlain,
+	FormatCSV:          MarkupPlain,
+}
+
+// format — шаблон формата и его разметка
+type format struct {
+	tmpl   *template.Template
+	markup markup
+}
+
+// formats — форматы по имени; Load заменяет их целиком
+var formats atomic.Pointer[map[string]format]
+
+func init() {
+	loaded, err := load("", nil)
+	if err != nil {
+		panic(err)
+	}
+	formats.Store(&loaded)
+}
+
+// Load собирает форматы заново: встроенные шаблоны и файлы <формат>.tmpl из dir (пусто — только встроенные).
+// Файл с именем встроенного формата заменяет его шаблон, файл с новым именем добавляет формат,
+// common.tmpl из dir заменяет общие блоки. formatMarkups задаёт разметку форматов по имени
+// (html, markdown, plain); новый формат без разметки рендерится как plain.
+func Load(dir string, formatMarkups map[string]string) error {
+	loaded, err := load(dir, formatMarkups)
+	if err != nil {
+		return err
+	}
+	formats.Store(&loaded)
+	return nil
+}
+
+func load(dir string, formatMarkups map[string]string) (map[string]format, error) {
+	names := make(map[string]bool, len(builtinFormats))
+	for name := range builtinFormats {
+		names[name] = true
+	}
+	custom := make(map[string]bool)
+	if dir != "" {
+		files, err := fs.Glob(os.DirFS(dir), "*.tmpl")
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения шаблонов отчётов из %s: %w", dir, err)
+		}
+		for _, file := range files {
+			custom[file] = true
+			if file != commonTemplate {
+				names[strings.TrimSuffix(file, ".tmpl")] = true
+			}
+		}
+	}
+
+	result := make(map[string]format, len(names))
+	for name := range names {
+		markupName := formatMarkups[name]
+		if markupName == "" {
+			markupName = builtinFormats[name]
+		}
+		if markupName == "" {
+			markupName = MarkupPlain
+		}
+		m, ok := markups[markupName]
+		if !ok {
+			return nil, fmt.Errorf("формат %s: неизвестная разметка %q, доступны: %s, %s, %s",
+				name, markupName, MarkupHTML, MarkupMarkdown, MarkupPlain)
+		}
+
+		tmpl := template.New(name).Funcs(template.FuncMap{
+			"esc":      m.escape,
+			"mdbold":   boldMarkdown,
+			"money":    money,
+			"short":    func(t time.Time) string { return t.Format("02-01 15:04") },
+			"datetime": func(t time.Time) string { return t.Format("02-01-2006 / 15:04") },
+			"csv":      csvRecord,
+		})
+		for _, file := range []string{commonTemplate, name + ".tmpl"} {
+			var err error
+			if custom[file] {
+				_, err = tmpl.ParseFS(os.DirFS(dir), file)
+			} else {
+				_, err = tmpl.ParseFS(templatesFS, "templates/"+file)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("ошибка разбора шаблона %s формата %s: %w", file, name, err)
+			}
+		}
+		result[name] = format{tmpl: tmpl, markup: m}
+	}
+	return result, nil
+}
+
+// Formats возвращает имена доступных форматов
+func Formats() []string {
+	loaded := *formats.Load()
+	names := make([]string, 0, len(loaded))
+	for name := range loaded {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckFormat проверяет, что формат известен; пустой формат — DefaultFormat
+func CheckFormat(format string) error {
+	if format == "" {
+		return nil
+	}
+	if _, ok := (*formats.Load())[format]; !ok {
+		return fmt.Errorf("%w %q, доступны: %s", ErrUnknownFormat, format, strings.Join(Formats(), ", "))
+	}
+	return nil
+}
+
+// ParseMode возвращает parse_mode Telegram для формата; пусто — текст без разметки или формат неизвестен
+func ParseMode(format string) string {
+	if format == "" {
+		format = DefaultFormat
+	}
+	return (*formats.Load())[format].markup.parseMode
+}
+
+// Render рендерит отчёт шаблоном format, пустой format — DefaultFormat.
+// Отчёт без строк рендерится в пустую строку: сообщать не о чем.
+func Render(format string, r Report) (string, error) {
+	if err := CheckFormat(format); err != nil {
+		return "", err
+	}
+	if format == "" {
+		format = DefaultFormat
+	}
+	if len(r.Rows) == 0 {
+		return "", nil
+	}
+	var sb strings.Builder
+	if err := (*formats.Load())[format].tmpl.ExecuteTemplate(&sb, format+".tmpl", r); err != nil {
+		return "", fmt.Errorf("ошибка рендеринга отчёта в формате %s: %w", format, err)
+	}
+	return sb.String(), nil
+}
+
+// escapeMarkdown экранирует символы разметки Markdown Telegram вне выделения
+func escapeMarkdown(s string) string {
+	return strings.NewReplacer("_", `\_`, "*", `\*`, "`", "\\`", "[", `\[`).Replace(s)
+}
+
+// boldMarkdown выделяет строку жирным в Markdown Telegram. Внутри выделения обратная черта
+// выводится как есть, а другие символы разметки не действуют, кроме закрывающей "*":
+// на ней выделение закрывается, звёздочка экранируется и выделение открывается заново.
+func boldMarkdown(s string) string {
+	parts := strings.Split(s, "*")
+	for i, part := range parts {
+		if part != "" {
+			parts[i] = "*" + part + "*"
+		}
+	}
+	return strings.Join(parts, `\*`)
+}
+
+// csvRecord собирает строку CSV из значений шаблона: суммы — с двумя знаками,
+// время — "2006-01-02 15:04" (нулевое — пусто), списки — через "; "
+func csvRecord(values ...any) (string, error) {
+	record := make([]string, len(values))
+	for i, v := range values {
+		switch v := v.(type) {
+		case float64:
+			record[i] = strconv.FormatFloat(v, 'f', 2, 64)
+		case time.Time:
+			if !v.IsZero() {
+				record[i] = v.Format("2006-01-02 15:04")
+			}
+		case []string:
+			record[i] = strings.Join(v, "; ")
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+	var sb strings.Builder
+	w := csv.NewWriter(&sb)
+	if err := w.Write(record); err != nil {
+		return "", err
+	}
+	w.Flush()
+	return sb.String(), w.Error()
+}
@@ -0,0 +1,108 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleReport() Report {
+	at := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+	return Report{
+		Network:     "TestNet",
+		Kind:        KindSpend,
+		GeneratedAt: at,
+		Rows: []Row{
+			{
+				Partner: "A&B_1", State: StateOK, Balance: 150, Currency: "RUB",
+				ReportingBalance: 1.5, ReportingCurrency: "USD", AsOf: at.Add(-30 * time.Minute),
+				HasSpend: true, SpendPerDay: 400, Alert: true,
+				Runway:  "0 через 9 ч (01-04 21:00), пополнить сейчас",
+				Reasons: []string{"баланс 150.00 ниже минимума 200.00"},
+			},
+			{Partner: "Down", State: StateUnavailable, UnavailableSince: at.Add(-2 * time.Hour)},
+			{Partner: "Slow", State: StateTimedOut},
+		},
+	}
+}
+
+func TestRender_TelegramHTML(t *testing.T) {
+	text, err := Render("", sampleReport())
+	require.NoError(t, err)
+	assert.Equal(t, "01-04-2025 / 12:00\n"+
+		"<b>A&amp;B_1</b>: 150.00 RUB ≈ 1.50 USD (на 01-04 11:30) (spend 400.00) ⚠️\n"+
+		"0 через 9 ч (01-04 21:00), пополнить сейчас\n"+
+		"⚠️ баланс 150.00 ниже минимума 200.00\n\n"+
+		"<b>Down</b>: недоступен с 01-04 10:00\n\n"+
+		"<b>Slow</b>: не ответил вовремя\n\n", text)
+}
+
+func TestRender_Markdown(t *testing.T) {
+	text, err := Render(FormatMarkdown, sampleReport())
+	require.NoError(t, err)
+	assert.Contains(t, text, "*TestNet* — 01-04-2025 / 12:00\n")
+	assert.Contains(t, text, "*A&B_1*: 150.00 RUB", "внутри выделения символы не экранируются")
+	assert.Contains(t, text, "\n*Slow*: не ответил вовремя\n")
+}
+
+func TestBoldMarkdown(t *testing.T) {
+	assert.Equal(t, "*snake_case*", boldMarkdown("snake_case"))
+	assert.Equal(t, `*2*\**2=4*`, boldMarkdown("2*2=4"))
+	assert.Equal(t, `\**x*\*`, boldMarkdown("*x*"))
+}
+
+func TestRender_Plain(t *testing.T) {
+	text, err := Render(FormatPlain, sampleReport())
+	require.NoError(t, err)
+	assert.Contains(t, text, "\nA&B_1: 150.00 RUB ≈ 1.50 USD (на 01-04 11:30) (spend 400.00) ⚠️\n")
+	assert.NotContains(t, text, "<b>")
+}
+
+func TestRender_CSV(t *testing.T) {
+	text, err := Render(FormatCSV, sampleReport())
+	require.NoError(t, err)
+	assert.Equal(t, "network,generated_at,partner,state,balance,currency,reporting_balance,reporting_currency,as_of,spend_per_day,alert,runway,alerts,freshness,unavailable_since\n"+
+		`TestNet,2025-04-01 12:00,A&B_1,ok,150.00,RUB,1.50,USD,2025-04-01 11:30,400.00,true,"0 через 9 ч (01-04 21:00), пополнить сейчас",баланс 150.00 ниже минимума 200.00,,`+"\n"+
+		"TestNet,2025-04-01 12:00,Down,unavailable,,,,,,,false,,,,2025-04-01 10:00\n"+
+		"TestNet,2025-04-01 12:00,Slow,timed_out,,,,,,,false,,,,\n", text)
+}
+
+func TestRender_EmptyAndUnknown(t *testing.T) {
+	text, err := Render(FormatPlain, Report{Network: "TestNet"})
+	require.NoError(t, err)
+	assert.Empty(t, text, "пустой отчёт не отправляется")
+
+	_, err = Render("pdf", sampleReport())
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+	assert.NoError(t, CheckFormat(""))
+	assert.Equal(t, []string{FormatCSV, FormatMarkdown, FormatPlain, FormatTelegramHTML}, Formats())
+}
+
+func TestLoad_TemplatesDir(t *testing.T) {
+	t.Cleanup(func() { require.NoError(t, Load("", nil)) })
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "plain.tmpl"),
+		[]byte(`{{range .Rows}}{{.Partner}};{{end}}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "short_html.tmpl"),
+		[]byte(`{{range .Rows}}<i>{{esc .Partner}}</i>{{end}}`), 0o644))
+	require.NoError(t, Load(dir, map[string]string{"short_html": MarkupHTML}))
+
+	assert.Equal(t, []string{FormatCSV, FormatMarkdown, FormatPlain, "short_html", FormatTelegramHTML}, Formats())
+	text, err := Render(FormatPlain, sampleReport())
+	require.NoError(t, err)
+	assert.Equal(t, "A&B_1;Down;Slow;", text, "файл из каталога заменяет встроенный шаблон")
+	text, err = Render("short_html", sampleReport())
+	require.NoError(t, err)
+	assert.Equal(t, "<i>A&amp;B_1</i><i>Down</i><i>Slow</i>", text)
+	assert.Equal(t, "HTML", ParseMode("short_html"))
+	assert.Equal(t, "HTML", ParseMode(""))
+	assert.Empty(t, ParseMode(FormatCSV))
+
+	assert.Error(t, Load(dir, map[string]string{"short_html": "rtf"}), "неизвестная разметка")
+	assert.NoError(t, CheckFormat("short_html"), "при ошибке остаются прежние форматы")
+}
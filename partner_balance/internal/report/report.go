@@ -0,0 +1,72 @@
+// Package report — модель отчёта о балансах партнёров сети. Модель строится один раз
+// и рендерится именованными шаблонами text/template: Telegram HTML, Markdown, текст, CSV.
+package report
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Kind — вид отчёта
+type Kind string
+
+const (
+	KindBalances Kind = "balances" // текущие балансы (/balance)
+	KindSpend    Kind = "spend"    // балансы с расходом, прогнозом и тревогами (/stat)
+)
+
+// State — состояние строки партнёра
+type State string
+
+const (
+	StateOK          State = "ok"          // баланс получен
+	StateUnavailable State = "unavailable" // цепь партнёра разомкнута, баланс не запрашивался
+	StateTimedOut    State = "timed_out"   // партнёр не ответил за время отчёта
+	StateNoData      State = "no_data"     // баланс не получен из-за ошибки
+)
+
+// Report — отчёт по сети, строки отсортированы по имени партнёра
+type Report struct {
+	Network     string
+	Kind        Kind
+	GeneratedAt time.Time
+	Rows        []Row
+}
+
+// Row — строка отчёта о партнёре; поля баланса заполнены только в состоянии StateOK
+type Row struct {
+	Partner          string
+	State            State
+	UnavailableSince time.Time // с какого момента партнёр недоступен (StateUnavailable)
+
+	Balance           float64
+	Currency          string // код валюты, пусто — неизвестна
+	ReportingBalance  float64
+	ReportingCurrency string    // валюта отчётов, пусто — пересчёт не настроен или невозможен
+	AsOf              time.Time // баланс из кэша: на какое время он снят; нулевое — получен только что
+
+	HasSpend    bool // расход посчитан
+	SpendPerDay float64
+	Alert       bool     // сработало хотя бы одно правило тревоги
+	Runway      string   // прогноз окончания баланса, пусто — неизвестен
+	Reasons     []string // причины сработавших правил тревоги
+	Freshness   string   // неудачные сборы подряд, пусто — последний сбор удался
+}
+
+// Amount — баланс с валютой и пересчётом в валюту отчётов, например "5000.00 RUB ≈ 54.64 USD"
+func (r Row) Amount() string {
+	text := money(r.Balance)
+	if r.Currency != "" {
+		text += " " + r.Currency
+	}
+	if r.ReportingCurrency != "" && r.ReportingCurrency != r.Currency {
+		text += " ≈ " + money(r.ReportingBalance) + " " + r.ReportingCurrency
+	}
+	return text
+}
+
+// money — сумма с двумя знаками после запятой
+func money(x float64) string {
+	return fmt.Sprintf("%.2f", math.Round(x*100)/100)
+}
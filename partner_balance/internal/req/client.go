@@ -0,0 +1,217 @@
+package req
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"partner_balance/internal/logger"
+	"partner_balance/internal/utils"
+)
+
+// Response — ответ партнёра с уже прочитанным телом.
+type Response struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+	Body       []byte
+}
+
+// httpClient общий для всех адаптеров, таймаут задаётся контекстом каждой попытки
+var httpClient = &http.Client{}
+
+// sleep ждёт d или отмены ctx; подменяется в тестах
+var sleep = func(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// Do выполняет запрос к API партнёра с учётом cfg.HTTP: ограничивает частоту и число
+// одновременных запросов аккаунта и повторяет попытку при сетевой ошибке, 429 и 5xx
+// с экспоненциальной паузой со случайным разбросом, соблюдая Retry-After. Если Retry-After
+// больше предела паузы (backoff_max_seconds), повтора нет и возвращается ответ партнёра.
+// newRequest вызывается на каждую попытку, чтобы тело запроса можно было отправить заново.
+// Ответ с любым статусом возвращается без ошибки, проверка статуса остаётся за адаптером.
+func Do(ctx context.Context, cfg utils.PartnerConfig, newRequest func() (*http.Request, error)) (*Response, error) {
+	lim := limiterFor(cfg)
+	retries := cfg.HTTP.Retries()
+	for attempt := 0; ; attempt++ {
+		request, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+		resp, err := doOnce(ctx, lim, cfg.HTTP.Timeout(), request)
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("не удалось выполнить запрос: %w", ctx.Err())
+		}
+		if attempt >= retries || !retryable(resp, err) {
+			return resp, err
+		}
+
+		wait, ok := backoff(cfg.HTTP, attempt, resp)
+		if !ok {
+			// партнёр просит подождать дольше предела паузы: ответ 429 возвращается как есть
+			logger.FromContext(ctx).Warnf("Запрос к %s вернул %s с Retry-After %q больше предела паузы, без повтора",
+				request.URL.Host, resp.Status, resp.Header.Get("Retry-After"))
+			return resp, nil
+		}
+		if err != nil {
+			logger.FromContext(ctx).Warnf("Попытка %d запроса к %s не удалась: %v, повтор через %s", attempt+1, request.URL.Host, err, wait)
+		} else {
+			logger.FromContext(ctx).Warnf("Попытка %d запроса к %s вернула %s, повтор через %s", attempt+1, request.URL.Host, resp.Status, wait)
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return nil, fmt.Errorf("не удалось выполнить запрос: %w", err)
+		}
+	}
+}
+
+// doOnce выполняет одну попытку запроса в пределах лимитов аккаунта
+func doOnce(ctx context.Context, lim *limiter, timeout time.Duration, request *http.Request) (*Response, error) {
+	release, err := lim.acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось выполнить запрос: %w", err)
+	}
+	defer release()
+
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	resp, err := httpClient.Do(request.WithContext(attemptCtx))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось выполнить запрос: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения тела ответа: %w", err)
+	}
+	return &Response{StatusCode: resp.StatusCode, Status: resp.Status, Header: resp.Header, Body: body}, nil
+}
+
+// retryable сообщает, стоит ли повторять попытку
+func retryable(resp *Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// backoff возвращает паузу перед следующей попыткой: base·2^attempt со случайным разбросом
+// в пределах [d/2, d], но не больше предела из конфига и не меньше Retry-After.
+// false — Retry-After больше предела, повторять попытку не нужно.
+func backoff(c utils.HTTPConfig, attempt int, resp *Response) (time.Duration, bool) {
+	base, limit := c.Backoff()
+	d := base << attempt
+	if d <= 0 || d > limit {
+		d = limit
+	}
+	d = d/2 + rand.N(d/2+1)
+	if resp != nil {
+		if ra, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now()); ok && ra > d {
+			if ra > limit {
+				return 0, false
+			}
+			d = ra
+		}
+	}
+	return d, true
+}
+
+// retryAfter разбирает заголовок Retry-After: число секунд или HTTP-дату
+func retryAfter(v string, now time.Time) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// limiter ограничивает частоту и число одновременных запросов одного аккаунта
+type limiter struct {
+	interval time.Duration // минимальный промежуток между запросами, 0 — без ограничения
+	slots    chan struct{} // семафор одновременных запросов, nil — без ограничения
+
+	mu   sync.Mutex
+	next time.Time // раньше этого момента следующий запрос не начнётся
+}
+
+var (
+	limitersMu sync.Mutex
+	limiters   = make(map[limiterKey]*limiter)
+)
+
+// limiterKey — аккаунт партнёра: тип провайдера и токен
+type limiterKey struct {
+	provider string
+	token    string
+}
+
+// limiterFor возвращает лимитер аккаунта; лимиты берутся из конфига при первом запросе
+func limiterFor(cfg utils.PartnerConfig) *limiter {
+	key := limiterKey{provider: cfg.Provider, token: cfg.Token}
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+	if l, ok := limiters[key]; ok {
+		return l
+	}
+	l := &limiter{}
+	if cfg.HTTP.RatePerMinute > 0 {
+		l.interval = time.Duration(float64(time.Minute) / cfg.HTTP.RatePerMinute)
+	}
+	if cfg.HTTP.MaxConcurrent > 0 {
+		l.slots = make(chan struct{}, cfg.HTTP.MaxConcurrent)
+	}
+	limiters[key] = l
+	return l
+}
+
+// acquire дожидается свободного слота и своей очереди по частоте; release освобождает слот
+func (l *limiter) acquire(ctx context.Context) (release func(), err error) {
+	release = func() {}
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+			release = func() { <-l.slots }
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if l.interval > 0 {
+		l.mu.Lock()
+		now := time.Now()
+		start := now
+		if l.next.After(now) {
+			start = l.next
+		}
+		l.next = start.Add(l.interval)
+		l.mu.Unlock()
+		if wait := start.Sub(now); wait > 0 {
+			if err := sleep(ctx, wait); err != nil {
+				release()
+				return nil, err
+			}
+		}
+	}
+	return release, nil
+}
@@ -0,0 +1,214 @@
+package req
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"partner_balance/internal/utils"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordSleeps подменяет паузы на запись их длительности
+func recordSleeps(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var (
+		mu    sync.Mutex
+		waits []time.Duration
+	)
+	orig := sleep
+	sleep = func(ctx context.Context, d time.Duration) error {
+		mu.Lock()
+		defer mu.Unlock()
+		waits = append(waits, d)
+		return ctx.Err()
+	}
+	t.Cleanup(func() { sleep = orig })
+	return &waits
+}
+
+func get(url string) func() (*http.Request, error) {
+	return func() (*http.Request, error) {
+		return http.NewRequest(http.MethodGet, url, nil)
+	}
+}
+
+func TestDo_RetriesServerErrors(t *testing.T) {
+	waits := recordSleeps(t)
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	resp, err := Do(context.Background(), utils.PartnerConfig{Token: "retry"}, get(srv.URL))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "ok", string(resp.Body))
+	assert.Equal(t, int32(3), calls.Load())
+	require.Len(t, *waits, 2)
+	assert.GreaterOrEqual(t, (*waits)[1], utils.DefaultHTTPBackoff, "вторая пауза не меньше половины base·2")
+}
+
+func TestDo_GivesUpAfterRetries(t *testing.T) {
+	recordSleeps(t)
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	retries := 1
+	resp, err := Do(context.Background(), utils.PartnerConfig{Token: "give-up", HTTP: utils.HTTPConfig{MaxRetries: &retries}}, get(srv.URL))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestDo_NoRetryOnClientError(t *testing.T) {
+	waits := recordSleeps(t)
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	resp, err := Do(context.Background(), utils.PartnerConfig{Token: "client-error"}, get(srv.URL))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Empty(t, *waits)
+}
+
+func TestDo_RetryAfter(t *testing.T) {
+	waits := recordSleeps(t)
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	_, err := Do(context.Background(), utils.PartnerConfig{Token: "retry-after"}, get(srv.URL))
+	require.NoError(t, err)
+	assert.Equal(t, []time.Duration{7 * time.Second}, *waits)
+}
+
+func TestDo_RetryAfterOverLimit(t *testing.T) {
+	waits := recordSleeps(t)
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	resp, err := Do(context.Background(), utils.PartnerConfig{Token: "retry-after-limit"}, get(srv.URL))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, int32(1), calls.Load(), "повтор раньше Retry-After не выполняется")
+	assert.Empty(t, *waits)
+}
+
+func TestDo_ContextCanceled(t *testing.T) {
+	recordSleeps(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := Do(ctx, utils.PartnerConfig{Token: "canceled"}, get(srv.URL))
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestDo_MaxConcurrent(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	cfg := utils.PartnerConfig{Token: "concurrent", HTTP: utils.HTTPConfig{MaxConcurrent: 2}}
+	var wg sync.WaitGroup
+	for range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := Do(context.Background(), cfg, get(srv.URL))
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), peak.Load())
+}
+
+func TestDo_RatePerMinute(t *testing.T) {
+	waits := recordSleeps(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	cfg := utils.PartnerConfig{Token: "rate", HTTP: utils.HTTPConfig{RatePerMinute: 60}}
+	for range 3 {
+		_, err := Do(context.Background(), cfg, get(srv.URL))
+		require.NoError(t, err)
+	}
+	require.Len(t, *waits, 2, "первый запрос без ожидания")
+	assert.InDelta(t, time.Second, (*waits)[0], float64(100*time.Millisecond))
+	assert.InDelta(t, 2*time.Second, (*waits)[1], float64(100*time.Millisecond))
+}
+
+func TestBackoff(t *testing.T) {
+	cfg := utils.HTTPConfig{BackoffBaseMillis: 100, BackoffMaxSeconds: 1}
+	for attempt, want := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		d, ok := backoff(cfg, attempt, nil)
+		assert.True(t, ok)
+		assert.GreaterOrEqual(t, d, want/2, "attempt %d", attempt)
+		assert.LessOrEqual(t, d, want, "attempt %d", attempt)
+	}
+
+	_, ok := backoff(cfg, 0, &Response{Header: http.Header{"Retry-After": []string{"120"}}})
+	assert.False(t, ok, "Retry-After больше backoff_max_seconds не сокращается")
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	d, ok := retryAfter("5", now)
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Second, d)
+
+	d, ok = retryAfter(now.Add(90*time.Second).Format(http.TimeFormat), now)
+	assert.True(t, ok)
+	assert.Equal(t, 90*time.Second, d)
+
+	_, ok = retryAfter("", now)
+	assert.False(t, ok)
+	_, ok = retryAfter("soon", now)
+	assert.False(t, ok)
+}
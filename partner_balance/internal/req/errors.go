@@ -0,0 +1,92 @@
+package req
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// Классы ошибок получения баланса, см. Classify
+const (
+	ErrorClassTimeout  = "timeout"  // партнёр не ответил вовремя
+	ErrorClassHTTP     = "http"     // партнёр ответил статусом, отличным от 200
+	ErrorClassNetwork  = "network"  // не удалось соединиться
+	ErrorClassParse    = "parse"    // ответ не удалось разобрать
+	ErrorClassCanceled = "canceled" // запрос отменён вызывающим
+	ErrorClassOther    = "other"
+)
+
+// StatusError — партнёр ответил статусом, отличным от 200
+type StatusError struct {
+	StatusCode int
+	Status     string
+	Body       []byte
+}
+
+// maxErrorBody — сколько байт тела ответа попадает в текст StatusError
+const maxErrorBody = 200
+
+func (e *StatusError) Error() string {
+	body := e.Body
+	if len(body) > maxErrorBody {
+		body = body[:maxErrorBody]
+	}
+	if len(body) == 0 {
+		return fmt.Sprintf("неожиданный статус ответа: %s", e.Status)
+	}
+	return fmt.Sprintf("неожиданный статус ответа: %s, тело: %s", e.Status, body)
+}
+
+// CheckStatus возвращает StatusError, если партнёр ответил не 200
+func (r *Response) CheckStatus() error {
+	if r.StatusCode == http.StatusOK {
+		return nil
+	}
+	return &StatusError{StatusCode: r.StatusCode, Status: r.Status, Body: r.Body}
+}
+
+// ErrBadResponse — ответ партнёра не удалось разобрать, см. BadResponse
+var ErrBadResponse = errors.New("некорректный ответ партнёра")
+
+// badResponse помечает ошибку как ErrBadResponse, сохраняя её текст
+type badResponse struct{ error }
+
+func (e badResponse) Unwrap() []error { return []error{e.error, ErrBadResponse} }
+
+// BadResponse помечает ошибку разбора ответа партнёра, не меняя её текст; nil остаётся nil
+func BadResponse(err error) error {
+	if err == nil {
+		return nil
+	}
+	return badResponse{err}
+}
+
+// Classify определяет класс ошибки получения баланса и HTTP-статус ответа, 0 — ответа не было
+func Classify(err error) (class string, httpStatus int) {
+	var (
+		statusErr *StatusError
+		netErr    net.Error
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+		numErr    *strconv.NumError
+	)
+	switch {
+	case err == nil:
+		return "", 0
+	case errors.As(err, &statusErr):
+		return ErrorClassHTTP, statusErr.StatusCode
+	case errors.Is(err, context.Canceled):
+		return ErrorClassCanceled, 0
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorClassTimeout, 0
+	case errors.As(err, &netErr):
+		return ErrorClassNetwork, 0
+	case errors.Is(err, ErrBadResponse), errors.As(err, &syntaxErr), errors.As(err, &typeErr), errors.As(err, &numErr):
+		return ErrorClassParse, 0
+	}
+	return ErrorClassOther, 0
+}
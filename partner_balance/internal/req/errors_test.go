@@ -0,0 +1,78 @@
+package req
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"partner_balance/internal/utils"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	var syntaxErr *json.SyntaxError
+	badJSON := json.Unmarshal([]byte("{"), &struct{}{})
+	assert.ErrorAs(t, badJSON, &syntaxErr)
+	_, numErr := strconv.ParseFloat("n/a", 64)
+
+	tests := []struct {
+		name   string
+		err    error
+		class  string
+		status int
+	}{
+		{"nil", nil, "", 0},
+		{"status", fmt.Errorf("фид 1: %w", &StatusError{StatusCode: 502, Status: "502 Bad Gateway"}), ErrorClassHTTP, 502},
+		{"deadline", fmt.Errorf("не удалось выполнить запрос: %w", context.DeadlineExceeded), ErrorClassTimeout, 0},
+		{"canceled", fmt.Errorf("не удалось выполнить запрос: %w", context.Canceled), ErrorClassCanceled, 0},
+		{"network", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, ErrorClassNetwork, 0},
+		{"json", fmt.Errorf("ошибка декодирования ответа: %w", badJSON), ErrorClassParse, 0},
+		{"number", fmt.Errorf("ошибка преобразования баланса: %w", numErr), ErrorClassParse, 0},
+		{"bad response", BadResponse(errors.New("в ответе нет поля")), ErrorClassParse, 0},
+		{"other", errors.New("что-то ещё"), ErrorClassOther, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			class, status := Classify(tt.err)
+			assert.Equal(t, tt.class, class)
+			assert.Equal(t, tt.status, status)
+		})
+	}
+}
+
+func TestCheckStatus(t *testing.T) {
+	assert.NoError(t, (&Response{StatusCode: http.StatusOK}).CheckStatus())
+
+	err := (&Response{StatusCode: 500, Status: "500 Internal Server Error", Body: []byte("oops")}).CheckStatus()
+	var statusErr *StatusError
+	assert.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, "неожиданный статус ответа: 500 Internal Server Error, тело: oops", err.Error())
+}
+
+func TestBadResponse_KeepsText(t *testing.T) {
+	assert.Nil(t, BadResponse(nil))
+	err := BadResponse(errors.New("поле \"balance\" не является числом"))
+	assert.Equal(t, "поле \"balance\" не является числом", err.Error())
+	assert.ErrorIs(t, err, ErrBadResponse)
+}
+
+func TestDo_TimeoutIsClassified(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	noRetry := 0
+	_, err := Do(ctx, utils.PartnerConfig{Token: "timeout", HTTP: utils.HTTPConfig{MaxRetries: &noRetry}}, get(srv.URL))
+	class, _ := Classify(err)
+	assert.Equal(t, ErrorClassTimeout, class)
+}
@@ -0,0 +1,214 @@
+package httpjson
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"partner_balance/internal/req"
+	"partner_balance/internal/utils"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/tidwall/gjson"
+)
+
+// ProviderType — тип универсального провайдера, который полностью настраивается из config.yaml.
+const ProviderType = "http"
+
+func init() {
+	req.Register(ProviderType, req.SnapshotFunc(GetSnapshot))
+}
+
+// templateData — значения, доступные в шаблонах URL, заголовков и тела запроса
+type templateData struct {
+	Token     string
+	Today     string
+	Yesterday string
+}
+
+func render(name, text string, data templateData) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("ошибка разбора шаблона %s: %w", name, err)
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("ошибка заполнения шаблона %s: %w", name, err)
+	}
+	return sb.String(), nil
+}
+
+// buildRequest собирает HTTP-запрос по описанию из конфига
+func buildRequest(ctx context.Context, cfg utils.PartnerConfig) (*http.Request, error) {
+	rc := cfg.Request
+	if rc == nil || rc.URL == "" {
+		return nil, fmt.Errorf("в конфиге партнёра не задан request.url")
+	}
+
+	now := utils.LocalNow()
+	data := templateData{
+		Token:     cfg.Token,
+		Today:     now.Format("2006-01-02"),
+		Yesterday: now.AddDate(0, 0, -1).Format("2006-01-02"),
+	}
+
+	rawURL, err := render("url", rc.URL, data)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("некорректный url %q: %w", rawURL, err)
+	}
+
+	tokenIn := strings.ToLower(rc.TokenIn)
+	if tokenIn == "query" {
+		if rc.TokenName == "" {
+			return nil, fmt.Errorf("для token_in: query нужно задать token_name")
+		}
+		q := u.Query()
+		q.Set(rc.TokenName, cfg.Token)
+		u.RawQuery = q.Encode()
+	}
+
+	var body io.Reader
+	if rc.Body != "" {
+		b, err := render("body", rc.Body, data)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewBufferString(b)
+	}
+
+	method := strings.ToUpper(rc.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	request, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать запрос: %w", err)
+	}
+
+	request.Header.Set("Accept", "application/json")
+	for name, value := range rc.Headers {
+		v, err := render("header "+name, value, data)
+		if err != nil {
+			return nil, err
+		}
+		request.Header.Set(name, v)
+	}
+
+	switch tokenIn {
+	case "header":
+		if rc.TokenName == "" {
+			return nil, fmt.Errorf("для token_in: header нужно задать token_name")
+		}
+		request.Header.Set(rc.TokenName, cfg.Token)
+	case "query", "":
+	default:
+		return nil, fmt.Errorf("неизвестное значение token_in: %q", rc.TokenIn)
+	}
+
+	return request, nil
+}
+
+// parseBalance достаёт баланс из тела ответа по gjson-пути
+func parseBalance(body []byte, rc *utils.RequestConfig) (float64, error) {
+	if rc.Path == "" {
+		return 0, fmt.Errorf("в конфиге партнёра не задан request.path")
+	}
+	value := gjson.GetBytes(body, rc.Path)
+	if !value.Exists() {
+		return 0, fmt.Errorf("в ответе нет поля %q", rc.Path)
+	}
+
+	switch strings.ToLower(rc.ValueType) {
+	case "", "number":
+		if value.Type != gjson.Number {
+			return 0, fmt.Errorf("поле %q не является числом: %s", rc.Path, value.Raw)
+		}
+		return value.Float(), nil
+	case "string":
+		balance, err := strconv.ParseFloat(strings.TrimSpace(value.String()), 64)
+		if err != nil {
+			return 0, fmt.Errorf("ошибка преобразования баланса: %w", err)
+		}
+		return balance, nil
+	default:
+		return 0, fmt.Errorf("неизвестное значение value_type: %q", rc.ValueType)
+	}
+}
+
+// optionalNumber достаёт необязательную составляющую баланса; пустой путь или отсутствующее поле — nil
+func optionalNumber(body []byte, path string) (*float64, error) {
+	if path == "" {
+		return nil, nil
+	}
+	value := gjson.GetBytes(body, path)
+	switch value.Type {
+	case gjson.Null:
+		return nil, nil
+	case gjson.Number:
+		return req.Float(value.Float()), nil
+	case gjson.String:
+		v, err := strconv.ParseFloat(strings.TrimSpace(value.String()), 64)
+		if err != nil {
+			return nil, fmt.Errorf("поле %q не является числом: %s", path, value.Raw)
+		}
+		return req.Float(v), nil
+	default:
+		return nil, fmt.Errorf("поле %q не является числом: %s", path, value.Raw)
+	}
+}
+
+// parseSnapshot собирает снимок баланса из тела ответа по путям из конфига
+func parseSnapshot(body []byte, rc *utils.RequestConfig) (req.BalanceSnapshot, error) {
+	balance, err := parseBalance(body, rc)
+	if err != nil {
+		return req.BalanceSnapshot{}, err
+	}
+	snap := req.BalanceSnapshot{Available: balance, Raw: body}
+	for _, c := range []struct {
+		path string
+		dst  **float64
+	}{
+		{rc.ReservedPath, &snap.Reserved},
+		{rc.CreditLimitPath, &snap.CreditLimit},
+		{rc.BonusPath, &snap.Bonus},
+	} {
+		if *c.dst, err = optionalNumber(body, c.path); err != nil {
+			return req.BalanceSnapshot{}, err
+		}
+	}
+	if rc.CurrencyPath != "" {
+		snap.Currency = gjson.GetBytes(body, rc.CurrencyPath).String()
+	}
+	return snap, nil
+}
+
+// GetBalance выполняет запрос, описанный в cfg.Request, и возвращает баланс
+func GetBalance(ctx context.Context, cfg utils.PartnerConfig) (float64, error) {
+	snap, err := GetSnapshot(ctx, cfg)
+	return snap.Available, err
+}
+
+// GetSnapshot выполняет запрос, описанный в cfg.Request, и возвращает снимок баланса
+func GetSnapshot(ctx context.Context, cfg utils.PartnerConfig) (req.BalanceSnapshot, error) {
+	resp, err := req.Do(ctx, cfg, func() (*http.Request, error) {
+		return buildRequest(ctx, cfg)
+	})
+	if err != nil {
+		return req.BalanceSnapshot{}, err
+	}
+
+	if err := resp.CheckStatus(); err != nil {
+		return req.BalanceSnapshot{}, err
+	}
+
+	snap, err := parseSnapshot(resp.Body, cfg.Request)
+	return snap, req.BadResponse(err)
+}
@@ -0,0 +1,116 @@
+package httpjson
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"partner_balance/internal/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBalance_HeaderTokenNumber(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("x-api-key"))
+		assert.Equal(t, "v1", r.Header.Get("X-Version"))
+		w.Write([]byte(`{"data":{"item":123.45}}`))
+	}))
+	defer srv.Close()
+
+	got, err := GetBalance(context.Background(), utils.PartnerConfig{
+		Token: "secret",
+		Request: &utils.RequestConfig{
+			URL:       srv.URL + "/balance.json",
+			Headers:   map[string]string{"X-Version": "v1"},
+			TokenIn:   "header",
+			TokenName: "x-api-key",
+			Path:      "data.item",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 123.45, got)
+}
+
+func TestGetBalance_QueryTokenString(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.URL.Query().Get("api_token"))
+		assert.Equal(t, "feed", r.URL.Query().Get("group_by"))
+		w.Write([]byte(`{"data":{"balance":"77.10"}}`))
+	}))
+	defer srv.Close()
+
+	got, err := GetBalance(context.Background(), utils.PartnerConfig{
+		Token: "secret",
+		Request: &utils.RequestConfig{
+			URL:       srv.URL + "/api/v1/?group_by=feed&start_date={{.Today}}",
+			TokenIn:   "query",
+			TokenName: "api_token",
+			Path:      "data.balance",
+			ValueType: "string",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 77.10, got)
+}
+
+func TestGetBalance_Errors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"balance":"n/a"}`))
+	}))
+	defer srv.Close()
+
+	cases := map[string]*utils.RequestConfig{
+		"no request":     nil,
+		"bad status":     {URL: srv.URL + "/fail", Path: "balance"},
+		"missing path":   {URL: srv.URL, Path: "data.balance"},
+		"not a number":   {URL: srv.URL, Path: "balance"},
+		"bad string":     {URL: srv.URL, Path: "balance", ValueType: "string"},
+		"bad token_in":   {URL: srv.URL, Path: "balance", TokenIn: "cookie"},
+		"bad value_type": {URL: srv.URL, Path: "balance", ValueType: "bool"},
+	}
+	noRetry := 0
+	for name, rc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := GetBalance(context.Background(), utils.PartnerConfig{Token: "secret", Request: rc, HTTP: utils.HTTPConfig{MaxRetries: &noRetry}})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestGetSnapshot_Components(t *testing.T) {
+	body := `{"result":{"balance":"150.5","reserved":20,"credit":"1000","currency":"EUR"}}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	snap, err := GetSnapshot(context.Background(), utils.PartnerConfig{Request: &utils.RequestConfig{
+		URL:             srv.URL,
+		Path:            "result.balance",
+		ValueType:       "string",
+		ReservedPath:    "result.reserved",
+		CreditLimitPath: "result.credit",
+		BonusPath:       "result.bonus",
+		CurrencyPath:    "result.currency",
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, 150.5, snap.Available)
+	require.NotNil(t, snap.Reserved)
+	assert.Equal(t, 20.0, *snap.Reserved)
+	require.NotNil(t, snap.CreditLimit)
+	assert.Equal(t, 1000.0, *snap.CreditLimit)
+	assert.Nil(t, snap.Bonus)
+	assert.Equal(t, "EUR", snap.Currency)
+	assert.Equal(t, body, string(snap.Raw))
+
+	_, err = GetSnapshot(context.Background(), utils.PartnerConfig{Request: &utils.RequestConfig{
+		URL: srv.URL, Path: "result.balance", ValueType: "string", ReservedPath: "result.currency",
+	}})
+	assert.Error(t, err)
+}
@@ -1,43 +1,47 @@
 package partner1
 
 import (
+	"context"
 	"fmt"
-	"io"
 	"net/http"
-	"time"
+	"partner_balance/internal/req"
+	"partner_balance/internal/utils"
 
 	"github.com/tidwall/gjson"
 )
 
-func GetBalance(token string) (float64, error) {
-	url := "https://example.com/advertiser/balance.json"
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return 0.0, fmt.Errorf("не удалось создать запрос: %w", err)
-	}
+// ProviderType — тип провайдера, под которым адаптер регистрируется в реестре req.
+const ProviderType = "partner1"
 
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("x-api-key", token)
-
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return 0.0, fmt.Errorf("не удалось выполнить запрос: %w", err)
-	}
-	defer resp.Body.Close()
+func init() {
+	req.Register(ProviderType, req.SnapshotFunc(GetSnapshot))
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return 0.0, fmt.Errorf("не удалось получить баланс, статус: %s, тело: %s", resp.Status, body)
-	}
+func GetBalance(ctx context.Context, cfg utils.PartnerConfig) (float64, error) {
+	snap, err := GetSnapshot(ctx, cfg)
+	return snap.Available, err
+}
 
-	resBody, err := io.ReadAll(resp.Body)
+// GetSnapshot возвращает баланс вместе с телом ответа
+func GetSnapshot(ctx context.Context, cfg utils.PartnerConfig) (req.BalanceSnapshot, error) {
+	url := "https://example.com/advertiser/balance.json"
+	resp, err := req.Do(ctx, cfg, func() (*http.Request, error) {
+		request, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось создать запрос: %w", err)
+		}
+		request.Header.Set("Accept", "application/json")
+		request.Header.Set("x-api-key", cfg.Token)
+		return request, nil
+	})
 	if err != nil {
-		return 0.0, fmt.Errorf("ошибка чтения тела ответа: %w", err)
+		return req.BalanceSnapshot{}, err
 	}
 
-	bodyBalance := gjson.GetBytes(resBody, "item")
-	balance := bodyBalance.Float()
+	if err := resp.CheckStatus(); err != nil {
+		return req.BalanceSnapshot{}, err
+	}
 
-	return balance, nil
+	bodyBalance := gjson.GetBytes(resp.Body, "item")
+	return req.BalanceSnapshot{Available: bodyBalance.Float(), Raw: resp.Body}, nil
 }
@@ -1,44 +1,62 @@
 package partner2
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"time"
+	"partner_balance/internal/req"
+	"partner_balance/internal/utils"
 )
 
 type ExampleResponse struct {
 	BalanceCommon float64 `json:"balanceCommon"`
 	BalanceReal   float64 `json:"balanceReal"`
+	Currency      string  `json:"currency"`
 }
 
-func GetBalance(apiKey string) (float64, error) {
-	url := "https://api.example.com/v1/public/finance/balance"
+// ProviderType — тип провайдера, под которым адаптер регистрируется в реестре req.
+const ProviderType = "partner2"
 
-	client := http.Client{
-		Timeout: 10 * time.Second,
-	}
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return 0, fmt.Errorf("не удалось создать запрос: %w", err)
-	}
-	req.Header.Set("accept", "*/*")
-	req.Header.Set("X-Example-API-Key", apiKey)
+func init() {
+	req.Register(ProviderType, req.SnapshotFunc(GetSnapshot))
+}
+
+func GetBalance(ctx context.Context, cfg utils.PartnerConfig) (float64, error) {
+	snap, err := GetSnapshot(ctx, cfg)
+	return snap.Available, err
+}
+
+// GetSnapshot возвращает общий баланс, реальный баланс (без бонусов) в Extra, валюту и тело ответа
+func GetSnapshot(ctx context.Context, cfg utils.PartnerConfig) (req.BalanceSnapshot, error) {
+	url := "https://api.example.com/v1/public/finance/balance"
 
-	resp, err := client.Do(req)
+	resp, err := req.Do(ctx, cfg, func() (*http.Request, error) {
+		request, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось создать запрос: %w", err)
+		}
+		request.Header.Set("accept", "*/*")
+		request.Header.Set("X-Example-API-Key", cfg.Token)
+		return request, nil
+	})
 	if err != nil {
-		return 0, fmt.Errorf("не удалось выполнить запрос: %w", err)
+		return req.BalanceSnapshot{}, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("неожиданный статус ответа: %d", resp.StatusCode)
+	if err := resp.CheckStatus(); err != nil {
+		return req.BalanceSnapshot{}, err
 	}
 
 	var data ExampleResponse
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return 0, fmt.Errorf("ошибка декодирования ответа: %w", err)
+	if err := json.Unmarshal(resp.Body, &data); err != nil {
+		return req.BalanceSnapshot{}, fmt.Errorf("ошибка декодирования ответа: %w", err)
 	}
 
-	return data.BalanceCommon, nil
+	return req.BalanceSnapshot{
+		Available: data.BalanceCommon,
+		Extra:     map[string]float64{"balanceReal": data.BalanceReal},
+		Currency:  data.Currency,
+		Raw:       resp.Body,
+	}, nil
 }
@@ -1,11 +1,17 @@
 package partner3
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"partner_balance/internal/req"
+	"partner_balance/internal/utils"
 	"strconv"
 	"time"
+
+	"github.com/tidwall/gjson"
 )
 
 type FeedDetailStatisticsResponse struct {
@@ -14,32 +20,211 @@ type FeedDetailStatisticsResponse struct {
 	} `json:"data"`
 }
 
-func GetBalance(token string) (float64, error) {
-	feedID := "11111"
-	url := fmt.Sprintf("https://example.com/api/v1/?api_token=%s&start_date=2025-03-31&end_date=2025-03-31&group_by=feed&feed_ids=%s", token, feedID)
+// ProviderType — тип провайдера, под которым адаптер регистрируется в реестре req.
+const ProviderType = "partner3"
+
+// DefaultBaseURL — адрес API, если в params не задан base_url.
+const DefaultBaseURL = "https://example.com/api/v1/"
+
+// Режимы учёта фидов аккаунта.
+const (
+	ModeSum        = "sum"        // баланс — сумма балансов фидов
+	ModeMin        = "min"        // баланс — минимальный из балансов фидов
+	ModeIndividual = "individual" // каждый фид — отдельный партнёр "<имя>/<фид>" в отчётах
+)
+
+// Params — секция params партнёра с провайдером partner3.
+type Params struct {
+	BaseURL string   `yaml:"base_url"`
+	FeedIDs []string `yaml:"feed_ids"`
+	// Mode — как учитывать несколько фидов: sum (по умолчанию), min или individual.
+	Mode string `yaml:"mode"`
+	// Days — ширина окна статистики в днях, включая сегодня (по умолчанию 1).
+	Days int `yaml:"days"`
+}
+
+// provider — адаптер partner3: снимок баланса, проверка params и развёртывание фидов
+type provider struct {
+	req.SnapshotFunc
+}
+
+func init() {
+	req.Register(ProviderType, provider{req.SnapshotFunc(GetSnapshot)})
+}
+
+// ValidateConfig проверяет params партнёра при старте сервиса
+func (provider) ValidateConfig(cfg utils.PartnerConfig) error {
+	_, err := parseParams(cfg)
+	return err
+}
+
+// Split разворачивает партнёра в режиме individual в партнёров "<имя>/<фид>" с одним фидом каждый.
+// Части наследуют остальные настройки партнёра: валюту, расписание, правила тревоги.
+func (provider) Split(partnerName string, cfg utils.PartnerConfig) (map[string]utils.PartnerConfig, error) {
+	p, err := parseParams(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if p.Mode != ModeIndividual {
+		return nil, nil
+	}
+	parts := make(map[string]utils.PartnerConfig, len(p.FeedIDs))
+	for _, feedID := range p.FeedIDs {
+		name := partnerName + "/" + feedID
+		if _, dup := parts[name]; dup {
+			return nil, fmt.Errorf("фид %s указан в params.feed_ids дважды", feedID)
+		}
+		feed := p
+		feed.FeedIDs = []string{feedID}
+		feed.Mode = ModeSum
+		part, err := cfg.WithParams(feed)
+		if err != nil {
+			return nil, fmt.Errorf("фид %s: ошибка сборки params: %w", feedID, err)
+		}
+		// тип провайдера по имени части уже не определить
+		part.Provider = ProviderType
+		parts[name] = part
+	}
+	return parts, nil
+}
+
+// parseParams читает params партнёра и подставляет значения по умолчанию
+func parseParams(cfg utils.PartnerConfig) (Params, error) {
+	var p Params
+	if err := cfg.DecodeParams(&p); err != nil {
+		return p, fmt.Errorf("ошибка разбора params: %w", err)
+	}
+	if len(p.FeedIDs) == 0 {
+		return p, fmt.Errorf("не заданы params.feed_ids")
+	}
+	if p.BaseURL == "" {
+		p.BaseURL = DefaultBaseURL
+	}
+	switch p.Mode {
+	case "":
+		p.Mode = ModeSum
+	case ModeSum, ModeMin, ModeIndividual:
+	default:
+		return p, fmt.Errorf("неизвестный params.mode %q", p.Mode)
+	}
+	if p.Days <= 0 {
+		p.Days = 1
+	}
+	return p, nil
+}
+
+// dateWindow возвращает границы окна статистики в формате API по местному времени
+func dateWindow(now time.Time, days int) (string, string) {
+	const layout = "2006-01-02"
+	return now.AddDate(0, 0, -(days - 1)).Format(layout), now.Format(layout)
+}
 
-	client := http.Client{
-		Timeout: 10 * time.Second,
+func GetBalance(ctx context.Context, cfg utils.PartnerConfig) (float64, error) {
+	snap, err := GetSnapshot(ctx, cfg)
+	return snap.Available, err
+}
+
+// GetSnapshot опрашивает каждый фид из params и объединяет балансы согласно params.mode.
+// Баланс каждого фида сохраняется в Extra под ключом feed_<id>, числовые поля data — только для единственного фида.
+// Партнёр в режиме individual опрашивается только после развёртывания по фидам (Split).
+func GetSnapshot(ctx context.Context, cfg utils.PartnerConfig) (req.BalanceSnapshot, error) {
+	p, err := parseParams(cfg)
+	if err != nil {
+		return req.BalanceSnapshot{}, err
 	}
-	resp, err := client.Get(url)
+	if p.Mode == ModeIndividual && len(p.FeedIDs) > 1 {
+		return req.BalanceSnapshot{}, fmt.Errorf("params.mode individual: партнёр не развёрнут по фидам")
+	}
+	start, end := dateWindow(utils.LocalNow(), p.Days)
+
+	var (
+		total float64
+		extra = make(map[string]float64)
+		raw   = make(map[string]json.RawMessage)
+	)
+	for i, feedID := range p.FeedIDs {
+		balance, body, err := fetchFeed(ctx, cfg, p.BaseURL, feedID, start, end)
+		if err != nil {
+			return req.BalanceSnapshot{}, fmt.Errorf("фид %s: %w", feedID, err)
+		}
+		switch {
+		case i == 0:
+			total = balance
+		case p.Mode == ModeMin:
+			total = min(total, balance)
+		default:
+			total += balance
+		}
+		extra["feed_"+feedID] = balance
+		raw[feedID] = body
+	}
+
+	if len(p.FeedIDs) == 1 {
+		body := raw[p.FeedIDs[0]]
+		for k, v := range numericFields(body, "data") {
+			extra[k] = v
+		}
+		return req.BalanceSnapshot{Available: total, Extra: extra, Raw: body}, nil
+	}
+	body, err := json.Marshal(raw)
+	if err != nil {
+		return req.BalanceSnapshot{}, fmt.Errorf("ошибка сериализации ответов: %w", err)
+	}
+	return req.BalanceSnapshot{Available: total, Extra: extra, Raw: body}, nil
+}
+
+// fetchFeed запрашивает статистику одного фида за окно [start, end] и возвращает баланс и тело ответа
+func fetchFeed(ctx context.Context, cfg utils.PartnerConfig, baseURL, feedID, start, end string) (float64, []byte, error) {
+	q := url.Values{}
+	q.Set("api_token", cfg.Token)
+	q.Set("start_date", start)
+	q.Set("end_date", end)
+	q.Set("group_by", "feed")
+	q.Set("feed_ids", feedID)
+
+	resp, err := req.Do(ctx, cfg, func() (*http.Request, error) {
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"?"+q.Encode(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось создать запрос: %w", err)
+		}
+		return request, nil
+	})
 	if err != nil {
-		return 0, fmt.Errorf("не удалось выполнить запрос: %w", err)
+		return 0, nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("неожиданный статус ответа: %d", resp.StatusCode)
+	if err := resp.CheckStatus(); err != nil {
+		return 0, nil, err
 	}
 
 	var apiResp FeedDetailStatisticsResponse
-	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		return 0, fmt.Errorf("ошибка декодирования ответа: %w", err)
+	if err := json.Unmarshal(resp.Body, &apiResp); err != nil {
+		return 0, nil, fmt.Errorf("ошибка декодирования ответа: %w", err)
 	}
 
 	balance, err := strconv.ParseFloat(apiResp.Data.Balance, 64)
 	if err != nil {
-		return 0, fmt.Errorf("ошибка преобразования баланса: %w", err)
+		return 0, nil, fmt.Errorf("ошибка преобразования баланса: %w", err)
 	}
+	return balance, resp.Body, nil
+}
 
-	return balance, nil
+// numericFields собирает числовые поля объекта по пути path, включая числа в строках
+func numericFields(body []byte, path string) map[string]float64 {
+	fields := make(map[string]float64)
+	gjson.GetBytes(body, path).ForEach(func(key, value gjson.Result) bool {
+		switch value.Type {
+		case gjson.Number:
+			fields[key.String()] = value.Float()
+		case gjson.String:
+			if v, err := strconv.ParseFloat(value.String(), 64); err == nil {
+				fields[key.String()] = v
+			}
+		}
+		return true
+	})
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
 }
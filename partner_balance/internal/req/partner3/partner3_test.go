@@ -0,0 +1,123 @@
+package partner3
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"partner_balance/internal/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func partnerWithParams(t *testing.T, token, params string) utils.PartnerConfig {
+	t.Helper()
+	cfg := utils.PartnerConfig{Provider: ProviderType, Token: token}
+	require.NoError(t, yaml.Unmarshal([]byte(params), &cfg.Params))
+	return cfg
+}
+
+func feedServer(t *testing.T, balances map[string]string) *httptest.Server {
+	t.Helper()
+	today := utils.LocalNow().Format("2006-01-02")
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		assert.Equal(t, "secret", q.Get("api_token"))
+		assert.Equal(t, "feed", q.Get("group_by"))
+		assert.Equal(t, today, q.Get("end_date"))
+		balance, ok := balances[q.Get("feed_ids")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"data":{"balance":"` + balance + `","clicks":"12"}}`))
+	}))
+}
+
+func TestGetSnapshot_SingleFeed(t *testing.T) {
+	srv := feedServer(t, map[string]string{"42": "150.50"})
+	defer srv.Close()
+
+	snap, err := GetSnapshot(context.Background(), partnerWithParams(t, "secret", "base_url: "+srv.URL+"\nfeed_ids: ['42']"))
+	require.NoError(t, err)
+	assert.Equal(t, 150.50, snap.Available)
+	assert.Equal(t, 150.50, snap.Extra["feed_42"])
+	assert.Equal(t, 12.0, snap.Extra["clicks"])
+}
+
+func TestGetSnapshot_MultipleFeeds(t *testing.T) {
+	srv := feedServer(t, map[string]string{"1": "100", "2": "40"})
+	defer srv.Close()
+
+	snap, err := GetSnapshot(context.Background(), partnerWithParams(t, "secret", "base_url: "+srv.URL+"\nfeed_ids: ['1', '2']"))
+	require.NoError(t, err)
+	assert.Equal(t, 140.0, snap.Available)
+	assert.Equal(t, map[string]float64{"feed_1": 100, "feed_2": 40}, snap.Extra)
+	assert.JSONEq(t, `{"1":{"data":{"balance":"100","clicks":"12"}},"2":{"data":{"balance":"40","clicks":"12"}}}`, string(snap.Raw))
+
+	snap, err = GetSnapshot(context.Background(), partnerWithParams(t, "secret", "base_url: "+srv.URL+"\nfeed_ids: ['1', '2']\nmode: min"))
+	require.NoError(t, err)
+	assert.Equal(t, 40.0, snap.Available)
+}
+
+func TestGetSnapshot_FeedError(t *testing.T) {
+	srv := feedServer(t, map[string]string{"1": "100"})
+	defer srv.Close()
+
+	_, err := GetSnapshot(context.Background(), partnerWithParams(t, "secret", "base_url: "+srv.URL+"\nfeed_ids: ['1', '404']"))
+	assert.ErrorContains(t, err, "фид 404")
+}
+
+func TestParseParams(t *testing.T) {
+	_, err := parseParams(utils.PartnerConfig{Provider: ProviderType})
+	assert.ErrorContains(t, err, "feed_ids")
+
+	_, err = parseParams(partnerWithParams(t, "", "feed_ids: ['1']\nmode: avg"))
+	assert.ErrorContains(t, err, "mode")
+
+	p, err := parseParams(partnerWithParams(t, "", "feed_ids: ['1']"))
+	require.NoError(t, err)
+	assert.Equal(t, Params{BaseURL: DefaultBaseURL, FeedIDs: []string{"1"}, Mode: ModeSum, Days: 1}, p)
+}
+
+func TestSplit_Individual(t *testing.T) {
+	cfg := partnerWithParams(t, "secret", "feed_ids: ['1', '2']\nmode: individual\ndays: 3")
+	cfg.Provider = ""
+	cfg.Currency = "RUB"
+
+	parts, err := provider{}.Split("Feeds", cfg)
+	require.NoError(t, err)
+	require.Len(t, parts, 2)
+	part := parts["Feeds/2"]
+	assert.Equal(t, ProviderType, part.Provider)
+	assert.Equal(t, "RUB", part.Currency)
+	p, err := parseParams(part)
+	require.NoError(t, err)
+	assert.Equal(t, Params{BaseURL: DefaultBaseURL, FeedIDs: []string{"2"}, Mode: ModeSum, Days: 3}, p)
+
+	parts, err = provider{}.Split("Feeds", partnerWithParams(t, "", "feed_ids: ['1', '2']"))
+	require.NoError(t, err)
+	assert.Nil(t, parts, "режим sum не разворачивается")
+
+	_, err = provider{}.Split("Feeds", partnerWithParams(t, "", "feed_ids: ['1', '1']\nmode: individual"))
+	assert.ErrorContains(t, err, "дважды")
+}
+
+func TestGetSnapshot_IndividualNotSplit(t *testing.T) {
+	_, err := GetSnapshot(context.Background(), partnerWithParams(t, "", "feed_ids: ['1', '2']\nmode: individual"))
+	assert.ErrorContains(t, err, "individual")
+}
+
+func TestDateWindow(t *testing.T) {
+	now := time.Date(2025, 3, 1, 0, 30, 0, 0, time.UTC)
+	start, end := dateWindow(now, 1)
+	assert.Equal(t, "2025-03-01", start)
+	assert.Equal(t, "2025-03-01", end)
+
+	start, end = dateWindow(now, 7)
+	assert.Equal(t, "2025-02-23", start)
+	assert.Equal(t, "2025-03-01", end)
+}
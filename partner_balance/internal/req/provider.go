@@ -0,0 +1,81 @@
+package req
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"partner_balance/internal/utils"
+)
+
+// BalanceProvider — адаптер к API партнёрской сети, умеющий получить текущий баланс аккаунта.
+// Запросы адаптера должны отменяться вместе с ctx.
+type BalanceProvider interface {
+	GetBalance(ctx context.Context, cfg utils.PartnerConfig) (float64, error)
+}
+
+// ProviderFunc позволяет использовать обычную функцию как BalanceProvider.
+type ProviderFunc func(ctx context.Context, cfg utils.PartnerConfig) (float64, error)
+
+func (f ProviderFunc) GetBalance(ctx context.Context, cfg utils.PartnerConfig) (float64, error) {
+	return f(ctx, cfg)
+}
+
+// ConfigValidator — провайдер, который проверяет настройки партнёра при старте сервиса.
+type ConfigValidator interface {
+	ValidateConfig(cfg utils.PartnerConfig) error
+}
+
+// Splitter — провайдер, который разворачивает одну запись партнёра в несколько.
+// Каждая часть опрашивается, хранится и показывается в отчётах как отдельный партнёр.
+type Splitter interface {
+	// Split возвращает части по именам партнёров; nil — запись не разворачивается.
+	Split(partnerName string, cfg utils.PartnerConfig) (map[string]utils.PartnerConfig, error)
+}
+
+// ErrUnknownProvider — тип провайдера не зарегистрирован
+var ErrUnknownProvider = errors.New("неизвестный тип провайдера")
+
+var (
+	mu        sync.RWMutex
+	providers = make(map[string]BalanceProvider)
+)
+
+// Register регистрирует провайдер под указанным типом.
+// Вызывается из init() пакетов internal/req/*, повторная регистрация типа — ошибка программиста.
+func Register(providerType string, p BalanceProvider) {
+	mu.Lock()
+	defer mu.Unlock()
+	if p == nil {
+		panic("req.Register: провайдер не может быть nil")
+	}
+	if _, dup := providers[providerType]; dup {
+		panic(fmt.Sprintf("req.Register: провайдер %q уже зарегистрирован", providerType))
+	}
+	providers[providerType] = p
+}
+
+// Get возвращает провайдер по его типу.
+func Get(providerType string) (BalanceProvider, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	p, ok := providers[providerType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, providerType)
+	}
+	return p, nil
+}
+
+// Providers возвращает отсортированный список зарегистрированных типов провайдеров.
+func Providers() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
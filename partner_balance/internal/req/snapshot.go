@@ -0,0 +1,55 @@
+package req
+
+import (
+	"context"
+
+	"partner_balance/internal/utils"
+)
+
+// BalanceSnapshot — полный ответ партнёра о балансе: основной баланс, его составляющие и тело ответа.
+// Необязательные составляющие равны nil, если партнёр их не возвращает.
+type BalanceSnapshot struct {
+	Available   float64            // доступный баланс, по нему считаются расход и тревоги
+	Reserved    *float64           // зарезервированная сумма
+	CreditLimit *float64           // кредитный лимит
+	Bonus       *float64           // бонусный баланс
+	Extra       map[string]float64 // прочие числовые показатели ответа
+	Currency    string             // валюта из ответа; пусто — берётся из конфига
+	Raw         []byte             // тело ответа как есть
+}
+
+// SnapshotProvider — провайдер, который умеет вернуть полный снимок баланса.
+type SnapshotProvider interface {
+	BalanceProvider
+	GetSnapshot(ctx context.Context, cfg utils.PartnerConfig) (BalanceSnapshot, error)
+}
+
+// SnapshotFunc позволяет использовать обычную функцию как SnapshotProvider.
+type SnapshotFunc func(ctx context.Context, cfg utils.PartnerConfig) (BalanceSnapshot, error)
+
+func (f SnapshotFunc) GetSnapshot(ctx context.Context, cfg utils.PartnerConfig) (BalanceSnapshot, error) {
+	return f(ctx, cfg)
+}
+
+func (f SnapshotFunc) GetBalance(ctx context.Context, cfg utils.PartnerConfig) (float64, error) {
+	snap, err := f(ctx, cfg)
+	return snap.Available, err
+}
+
+// Snapshot возвращает снимок баланса от провайдера. Провайдеры, возвращающие только число,
+// дают снимок с одним Available.
+func Snapshot(ctx context.Context, p BalanceProvider, cfg utils.PartnerConfig) (BalanceSnapshot, error) {
+	if sp, ok := p.(SnapshotProvider); ok {
+		return sp.GetSnapshot(ctx, cfg)
+	}
+	balance, err := p.GetBalance(ctx, cfg)
+	if err != nil {
+		return BalanceSnapshot{}, err
+	}
+	return BalanceSnapshot{Available: balance}, nil
+}
+
+// Float возвращает указатель на значение, для необязательных составляющих снимка.
+func Float(v float64) *float64 {
+	return &v
+}
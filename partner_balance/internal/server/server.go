@@ -2,14 +2,21 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/robfig/cron/v3"
 	grpcpkg "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"net"
 	grpc "partner_balance/gateway"
+	"partner_balance/internal/fx"
 	"partner_balance/internal/logger"
 	db "partner_balance/internal/postgres"
 	"partner_balance/internal/processor"
+	"partner_balance/internal/report"
+	"partner_balance/internal/storage"
 	"partner_balance/internal/utils"
 	"time"
 )
@@ -30,27 +37,61 @@ func Run() error {
 		return err
 	}
 
+	store := db.NewStore(db.DB)
+	proc := processor.New(store)
+
 	// Загружаем конфиг с партнерскими сетками
 	if err := utils.LoadConfig("config.yaml"); err != nil {
 		logger.Log.Errorf("Ошибка загрузки конфигурации: %v", err)
 		return err
 	}
 
+	// Шаблоны отчётов: встроенные и переопределённые из report.templates_dir
+	if err := report.Load(utils.AppConfig.Report.TemplatesDir, utils.AppConfig.Report.Formats); err != nil {
+		logger.Log.Errorf("Ошибка загрузки шаблонов отчётов: %v", err)
+		return err
+	}
+
+	// Применяем миграции схем для всех сетей из конфига
+	if err := store.Migrate(utils.NetworkNames()); err != nil {
+		logger.Log.Errorf("Ошибка применения миграций: %v", err)
+		return err
+	}
+
+	// Проверяем, что у всех активных партнёров есть зарегистрированный провайдер
+	if err := processor.ValidateProviders(processor.PartnerList()); err != nil {
+		logger.Log.Errorf("Ошибка конфигурации провайдеров: %v", err)
+		return err
+	}
+	// Разворачиваем партнёров, которые опрашиваются по частям (например, фиды partner3 в режиме individual)
+	if err := processor.SplitPartners(); err != nil {
+		logger.Log.Errorf("Ошибка конфигурации провайдеров: %v", err)
+		return err
+	}
+
+	// Источник курсов для пересчёта сумм в валюту отчётов
+	rates, err := fx.New(utils.AppConfig.Currency)
+	if err != nil {
+		logger.Log.Errorf("Ошибка настройки курсов валют: %v", err)
+		return err
+	}
+	proc.SetFX(rates)
+
 	// Вставляем партнёров из конфигурации
-	if err := processor.InsertPartners(processor.PartnerList()); err != nil {
+	if err := proc.InsertPartners(processor.PartnerList()); err != nil {
 		logger.Log.Errorf("Ошибка вставки партнёров: %v", err)
 		return err
 	}
 
 	// Запускаем gRPC-сервер в фоне
 	go func() {
-		if err := startGRPCServer(); err != nil {
+		if err := startGRPCServer(proc); err != nil {
 			logger.Log.Errorf("Ошибка запуска gRPC-сервера: %v", err)
 		}
 	}()
 
 	// Запуск планировщика
-	if err := scheduler(context.Background()); err != nil {
+	if err := scheduler(context.Background(), proc); err != nil {
 		logger.Log.Errorf("Ошибка планировщика: %v", err)
 		return err
 	}
@@ -59,20 +100,34 @@ func Run() error {
 
 // Пополнение таблиц балансов по расписанию, удаление старых записей из бд
 
-func scheduler(ctx context.Context) error {
+func scheduler(ctx context.Context, proc *processor.Processor) error {
 	c := cron.New(cron.WithLocation(time.Local))
 
-	c.AddFunc("0 4,10,16,22 * * *", func() {
-		if err := processor.BalanceInsert(); err != nil {
-			logger.Log.Warnf("BalanceInsert error: %v", err)
+	// сбор балансов: одна задача на каждое расписание из конфига
+	for spec, groups := range processor.ScheduleGroups() {
+		if _, err := c.AddFunc(spec, func() {
+			run, err := proc.BalanceInsert(ctx, groups)
+			if err != nil {
+				logger.FromContext(logger.WithRunID(ctx, run.ID)).Warnf("BalanceInsert error: %v", err)
+			}
+			run.Schedule = spec
+			if err := proc.SaveRun(run); err != nil {
+				logger.FromContext(logger.WithRunID(ctx, run.ID)).Errorf("Ошибка сохранения итога сбора: %v", err)
+			}
+		}); err != nil {
+			return fmt.Errorf("некорректное расписание сбора %q: %w", spec, err)
 		}
-	})
+		logger.Log.Infof("Расписание сбора %q: групп %d", spec, len(groups))
+	}
 
-	c.AddFunc("0 0 0 * * *", func() {
-		if err := delete(); err != nil {
-			logger.Log.Warnf("delete error: %v", err)
+	// ежедневная очистка истории по политике хранения из конфига
+	if _, err := c.AddFunc("0 0 * * *", func() {
+		if err := proc.ApplyRetention(); err != nil {
+			logger.Log.Warnf("ApplyRetention error: %v", err)
 		}
-	})
+	}); err != nil {
+		return fmt.Errorf("ошибка регистрации задачи очистки: %w", err)
+	}
 
 	c.Start()
 
@@ -82,44 +137,392 @@ func scheduler(ctx context.Context) error {
 	return nil
 }
 
-func delete() error {
-	err := db.DeleteOldData("admeking")
+// gRPC!!!
+
+// statServer реализует gateway.StatServiceServer
+type statServer struct {
+	grpc.UnimplementedStatServiceServer
+	proc *processor.Processor
+}
+
+// Stat обрабатывает запрос StatRequest и возвращает StatReply
+func (s *statServer) Stat(ctx context.Context, req *grpc.StatRequest) (*grpc.StatReply, error) {
+	network := req.GetNetwork()
+	if err := report.CheckFormat(req.GetFormat()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	rep, forecasts := s.proc.SpendReport(ctx, network)
+	if err := ctx.Err(); err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+	text, err := report.Render(req.GetFormat(), rep)
 	if err != nil {
-		return err
+		logger.Log.Errorf("Ошибка формирования отчёта сети %s: %v", network, err)
+		return nil, status.Error(codes.Internal, "не удалось сформировать отчёт")
 	}
-	err = db.DeleteOldData("realpush")
+	return &grpc.StatReply{Text: text, Network: network, Forecasts: forecastsToProto(forecasts),
+		ParseMode: report.ParseMode(req.GetFormat())}, nil
+}
+
+// GetBalances возвращает текущие балансы партнёров сети
+func (s *statServer) GetBalances(ctx context.Context, req *grpc.NetworkRequest) (*grpc.BalancesReply, error) {
+	return s.balances(ctx, req, s.proc.Balances, processor.BuildBalanceReport)
+}
+
+// GetSpendStats возвращает балансы партнёров сети с расходом, порогом и прогнозом
+func (s *statServer) GetSpendStats(ctx context.Context, req *grpc.NetworkRequest) (*grpc.BalancesReply, error) {
+	return s.balances(ctx, req, s.proc.SpendStatus, processor.BuildSpendReport)
+}
+
+// balances получает состояния партнёров сети и рендерит по ним отчёт в формате из запроса
+func (s *statServer) balances(ctx context.Context, req *grpc.NetworkRequest,
+	fetch func(context.Context, string) []processor.PartnerStatus,
+	build func(string, []processor.PartnerStatus, time.Time) report.Report,
+) (*grpc.BalancesReply, error) {
+	network := req.GetNetwork()
+	if err := report.CheckFormat(req.GetFormat()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	statuses := fetch(ctx, network)
+	if err := ctx.Err(); err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+	reply := balancesReply(network, statuses)
+	text, err := report.Render(req.GetFormat(), build(network, statuses, utils.LocalNow()))
 	if err != nil {
-		return err
+		logger.Log.Errorf("Ошибка формирования отчёта сети %s: %v", network, err)
+		return nil, status.Error(codes.Internal, "не удалось сформировать отчёт")
+	}
+	reply.Text = text
+	reply.ParseMode = report.ParseMode(req.GetFormat())
+	return reply, nil
+}
+
+// ListReportFormats возвращает форматы отчётов и parse_mode Telegram для каждого
+func (s *statServer) ListReportFormats(ctx context.Context, req *grpc.ListReportFormatsRequest) (*grpc.ListReportFormatsReply, error) {
+	reply := &grpc.ListReportFormatsReply{}
+	for _, format := range report.Formats() {
+		reply.Formats = append(reply.Formats, &grpc.ReportFormat{Name: format, ParseMode: report.ParseMode(format)})
+	}
+	return reply, nil
+}
+
+// ListNetworks возвращает сети из конфига и их активных партнёров
+func (s *statServer) ListNetworks(ctx context.Context, req *grpc.ListNetworksRequest) (*grpc.ListNetworksReply, error) {
+	reply := &grpc.ListNetworksReply{}
+	for _, network := range utils.NetworkNames() {
+		n := &grpc.Network{Name: network}
+		for _, partner := range processor.NetworkPartners(network) {
+			n.Partners = append(n.Partners, partner.Name)
+		}
+		reply.Networks = append(reply.Networks, n)
+	}
+	return reply, nil
+}
+
+// GetBalanceHistory возвращает историю баланса и расхода партнёра с группировкой по шагу
+func (s *statServer) GetBalanceHistory(ctx context.Context, req *grpc.HistoryRequest) (*grpc.HistoryReply, error) {
+	to := utils.LocalNow()
+	if req.GetTo() != nil {
+		to = req.GetTo().AsTime().In(to.Location())
+	}
+	from := to.AddDate(0, 0, -7)
+	if req.GetFrom() != nil {
+		from = req.GetFrom().AsTime().In(to.Location())
+	}
+	step := storage.StepDay
+	if req.GetStep() != "" {
+		step = storage.Step(req.GetStep())
+	}
+
+	buckets, err := s.proc.BalanceHistory(req.GetNetwork(), req.GetPartner(), from, to, step)
+	switch {
+	case errors.Is(err, processor.ErrHistoryRange):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, storage.ErrPartnerNotFound):
+		return nil, status.Error(codes.NotFound, err.Error())
+	case err != nil:
+		logger.Log.Errorf("Ошибка получения истории %s (%s): %v", req.GetPartner(), req.GetNetwork(), err)
+		return nil, status.Error(codes.Internal, "не удалось получить историю балансов")
+	}
+
+	reply := &grpc.HistoryReply{Network: req.GetNetwork(), Partner: req.GetPartner(), Step: string(step)}
+	for _, b := range buckets {
+		reply.Points = append(reply.Points, &grpc.HistoryPoint{
+			Start:   timestamppb.New(b.Start),
+			Min:     b.Min,
+			Max:     b.Max,
+			Last:    b.Last,
+			Samples: int32(b.Samples),
+			Spend:   b.Spend,
+			TopUp:   b.TopUp,
+		})
+	}
+	return reply, nil
+}
+
+// GetTopUps возвращает пополнения партнёров сети за период и итоги по партнёрам
+func (s *statServer) GetTopUps(ctx context.Context, req *grpc.TopUpsRequest) (*grpc.TopUpsReply, error) {
+	to := utils.LocalNow()
+	if req.GetTo() != nil {
+		to = req.GetTo().AsTime().In(to.Location())
+	}
+	from := to.AddDate(0, 0, -7)
+	if req.GetFrom() != nil {
+		from = req.GetFrom().AsTime().In(to.Location())
 	}
-	err = db.DeleteOldData("advertrek")
+	if !from.Before(to) {
+		return nil, status.Error(codes.InvalidArgument, "начало периода не раньше конца")
+	}
+
+	topUps, summaries, err := s.proc.TopUps(req.GetNetwork(), req.GetPartner(), from, to)
 	if err != nil {
-		return err
+		logger.Log.Errorf("Ошибка получения пополнений сети %s: %v", req.GetNetwork(), err)
+		return nil, status.Error(codes.Internal, "не удалось получить пополнения")
 	}
-	return nil
+
+	reply := &grpc.TopUpsReply{
+		Network: req.GetNetwork(),
+		From:    timestamppb.New(from),
+		To:      timestamppb.New(to),
+	}
+	for _, t := range topUps {
+		reply.TopUps = append(reply.TopUps, &grpc.TopUp{
+			Id:          t.ID,
+			Partner:     t.Partner,
+			At:          timestamppb.New(t.At),
+			Amount:      t.Amount,
+			Delta:       t.Delta,
+			PrevBalance: t.PrevBalance,
+			Balance:     t.Balance,
+			DetectedAt:  timestamppb.New(t.DetectedAt),
+		})
+	}
+	for _, sum := range summaries {
+		reply.Summaries = append(reply.Summaries, &grpc.TopUpSummary{
+			Partner: sum.Partner,
+			Count:   int32(sum.Count),
+			Total:   sum.Total,
+		})
+	}
+	return reply, nil
 }
 
-// gRPC!!!
+// GetLastCollectionRun возвращает итог последнего запуска сбора балансов
+func (s *statServer) GetLastCollectionRun(ctx context.Context, req *grpc.LastCollectionRunRequest) (*grpc.CollectionRun, error) {
+	run, err := s.proc.LastRun(req.GetNetwork())
+	if errors.Is(err, storage.ErrNoCollectionRuns) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		logger.Log.Errorf("Ошибка получения последнего запуска сбора: %v", err)
+		return nil, status.Error(codes.Internal, "не удалось получить последний запуск сбора")
+	}
 
-// statServer реализует gateway.StatServiceServer
-type statServer struct {
-	grpc.UnimplementedStatServiceServer
+	reply := &grpc.CollectionRun{
+		Id:         run.ID,
+		Schedule:   run.Schedule,
+		StartedAt:  timestamppb.New(run.StartedAt),
+		FinishedAt: timestamppb.New(run.FinishedAt),
+	}
+	for _, res := range run.Results {
+		if req.GetNetwork() != "" && res.Network != req.GetNetwork() {
+			continue
+		}
+		if res.OK {
+			reply.Succeeded++
+		} else {
+			reply.Failed++
+		}
+		reply.Results = append(reply.Results, &grpc.CollectionResult{
+			Network:    res.Network,
+			Partner:    res.Partner,
+			Ok:         res.OK,
+			Balance:    res.Balance,
+			DurationMs: res.Duration.Milliseconds(),
+			ErrorClass: res.ErrorClass,
+			Error:      res.Error,
+		})
+	}
+	return reply, nil
 }
 
-// Stat обрабатывает запрос StatRequest и возвращает StatReply
-func (s *statServer) Stat(ctx context.Context, req *grpc.StatRequest) (*grpc.StatReply, error) {
-	network := req.GetNetwork()
-	text := processor.CompareBalances(network)
-	return &grpc.StatReply{Text: text, Network: network}, nil
+// GetPartnerHealth возвращает здоровье API партнёров и состояние их размыкателей цепи
+func (s *statServer) GetPartnerHealth(ctx context.Context, req *grpc.PartnerHealthRequest) (*grpc.PartnerHealthReply, error) {
+	reply := &grpc.PartnerHealthReply{}
+	for _, h := range processor.Health() {
+		if req.GetNetwork() != "" && h.Network != req.GetNetwork() {
+			continue
+		}
+		reply.Partners = append(reply.Partners, &grpc.PartnerHealth{
+			Network:             h.Network,
+			Partner:             h.Partner,
+			State:               string(h.State),
+			LastSuccess:         optionalTimestamp(h.LastSuccess),
+			LastFailure:         optionalTimestamp(h.LastFailure),
+			LastError:           h.LastError,
+			ConsecutiveFailures: int32(h.ConsecutiveFailures),
+			FailingSince:        optionalTimestamp(h.FailingSince),
+			OpenedAt:            optionalTimestamp(h.OpenedAt),
+		})
+	}
+	return reply, nil
+}
+
+// optionalTimestamp переводит время в protobuf; нулевое время — nil
+func optionalTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+const (
+	// alertPollInterval — как часто поток тревог перечитывает хранилище без сигнала,
+	// чтобы подхватить события, записанные другими экземплярами сервиса
+	alertPollInterval = 30 * time.Second
+	// alertBatch — сколько событий читается из хранилища за раз
+	alertBatch = 100
+)
+
+// SubscribeAlerts отправляет события тревог после after_id, а затем новые события по мере появления
+func (s *statServer) SubscribeAlerts(req *grpc.SubscribeAlertsRequest, stream grpc.StatService_SubscribeAlertsServer) error {
+	notify, cancel := s.proc.SubscribeAlerts()
+	defer cancel()
+
+	lastID := req.GetAfterId()
+	if lastID <= 0 {
+		id, err := s.proc.LastAlertEventID()
+		if err != nil {
+			logger.Log.Errorf("Ошибка получения последнего события тревоги: %v", err)
+			return status.Error(codes.Internal, "не удалось получить события тревог")
+		}
+		lastID = id
+	}
+	logger.Log.Infof("Подписка на тревоги сетей %v с события %d", req.GetNetworks(), lastID)
+
+	ticker := time.NewTicker(alertPollInterval)
+	defer ticker.Stop()
+	for {
+		events, err := s.proc.AlertEvents(req.GetNetworks(), lastID, alertBatch)
+		if err != nil {
+			logger.Log.Errorf("Ошибка чтения событий тревог: %v", err)
+			return status.Error(codes.Internal, "не удалось получить события тревог")
+		}
+		for _, e := range events {
+			if err := stream.Send(alertEventToProto(e)); err != nil {
+				return err
+			}
+			lastID = e.ID
+		}
+		if len(events) == alertBatch {
+			continue
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-notify:
+		case <-ticker.C:
+		}
+	}
+}
+
+func alertEventToProto(e storage.AlertEvent) *grpc.AlertEvent {
+	return &grpc.AlertEvent{
+		Id:        e.ID,
+		Network:   e.Network,
+		Partner:   e.Partner,
+		Rule:      e.Rule,
+		Kind:      string(e.Kind),
+		Reason:    e.Reason,
+		Value:     e.Value,
+		Limit:     e.Limit,
+		Since:     timestamppb.New(e.Since),
+		CreatedAt: timestamppb.New(e.CreatedAt),
+	}
+}
+
+func balancesReply(network string, statuses []processor.PartnerStatus) *grpc.BalancesReply {
+	reply := &grpc.BalancesReply{
+		Network:     network,
+		GeneratedAt: timestamppb.New(utils.LocalNow()),
+	}
+	for _, st := range statuses {
+		pb := &grpc.PartnerBalance{Partner: st.Partner}
+		if st.Err != nil {
+			pb.Error = st.Err.Error()
+			pb.TimedOut = st.TimedOut
+			if st.Unavailable() {
+				pb.UnavailableSince = timestamppb.New(st.UnavailableSince)
+			}
+			reply.Partners = append(reply.Partners, pb)
+			continue
+		}
+		pb.Balance = st.Balance
+		pb.Currency = st.Currency
+		if st.ReportingCurrency != "" {
+			pb.ReportingCurrency = st.ReportingCurrency
+			pb.ReportingBalance = st.ReportingBalance
+			pb.ReportingSpendPerDay = st.ReportingSpendPerDay
+		}
+		pb.FetchedAt = timestamppb.New(st.FetchedAt)
+		if st.HasStats {
+			pb.HasStats = true
+			pb.SpendPerDay = st.SpendPerDay
+			pb.Threshold = st.Threshold
+			pb.Forecast = runwayToProto(st.Runway)
+		}
+		if !st.Freshness.LastSuccess.IsZero() {
+			pb.LastCollectedAt = timestamppb.New(st.Freshness.LastSuccess)
+		}
+		pb.FailedCollections = int32(st.Freshness.FailedInRow)
+		pb.Alert = st.Alert
+		for _, a := range st.Alerts {
+			pb.Alerts = append(pb.Alerts, &grpc.FiredAlert{
+				Rule:   string(a.Rule),
+				Value:  a.Value,
+				Limit:  a.Limit,
+				Reason: a.Reason,
+			})
+		}
+		reply.Partners = append(reply.Partners, pb)
+	}
+	return reply
+}
+
+// forecastsToProto переводит прогнозы процессора в сообщения gRPC
+func forecastsToProto(runways []processor.Runway) []*grpc.PartnerForecast {
+	result := make([]*grpc.PartnerForecast, 0, len(runways))
+	for _, r := range runways {
+		result = append(result, runwayToProto(r))
+	}
+	return result
+}
+
+func runwayToProto(r processor.Runway) *grpc.PartnerForecast {
+	pf := &grpc.PartnerForecast{
+		Partner:     r.Partner,
+		Balance:     r.Balance,
+		SpendPerDay: r.PerDay,
+		Known:       r.Known,
+	}
+	if r.Known {
+		pf.HoursLeft = r.HoursLeft
+		pf.ZeroAt = timestamppb.New(r.ZeroAt)
+		pf.TopUpBy = timestamppb.New(r.TopUpBy)
+	}
+	return pf
 }
 
 //  поднимаем gRPC-сервер и слушаем порт 50051
-func startGRPCServer() error {
+func startGRPCServer(proc *processor.Processor) error {
 	lis, err := net.Listen("tcp", ":50051")
 	if err != nil {
 		return fmt.Errorf("failed to listen: %w", err)
 	}
 	grpcServer := grpcpkg.NewServer()
-	grpc.RegisterStatServiceServer(grpcServer, &statServer{})
+	grpc.RegisterStatServiceServer(grpcServer, &statServer{proc: proc})
 	logger.Log.Infof("gRPC-сервер запущен на :50051")
 	return grpcServer.Serve(lis)
 }
@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"fmt"
+	"partner_balance/internal/storage"
+	"sort"
+)
+
+type alertKey struct {
+	partnerKey
+	rule string
+}
+
+func (s *Store) GetAlertStates(network string) ([]storage.AlertState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var states []storage.AlertState
+	for key, st := range s.alerts {
+		if key.network == network {
+			states = append(states, st)
+		}
+	}
+	sort.Slice(states, func(i, j int) bool {
+		if states[i].Partner != states[j].Partner {
+			return states[i].Partner < states[j].Partner
+		}
+		return states[i].Rule < states[j].Rule
+	})
+	return states, nil
+}
+
+func (s *Store) SaveAlert(state storage.AlertState, event *storage.AlertEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := partnerKey{state.Network, state.Partner}
+	if _, ok := s.active[key]; !ok {
+		return fmt.Errorf("%w: %s в сети %s", storage.ErrPartnerNotFound, state.Partner, state.Network)
+	}
+
+	s.alerts[alertKey{key, state.Rule}] = state
+	if event != nil {
+		event.ID = int64(len(s.events) + 1)
+		event.CreatedAt = s.Now()
+		s.events = append(s.events, *event)
+	}
+	return nil
+}
+
+func (s *Store) GetAlertEvents(networks []string, afterID int64, limit int) ([]storage.AlertEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	wanted := make(map[string]bool, len(networks))
+	for _, n := range networks {
+		wanted[n] = true
+	}
+
+	var events []storage.AlertEvent
+	// события хранятся по возрастанию ID, ID = индекс + 1
+	for i := int(max(afterID, 0)); i < len(s.events) && len(events) < limit; i++ {
+		if len(wanted) == 0 || wanted[s.events[i].Network] {
+			events = append(events, s.events[i])
+		}
+	}
+	return events, nil
+}
+
+func (s *Store) LastAlertEventID() (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return int64(len(s.events)), nil
+}
@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"fmt"
+	"partner_balance/internal/storage"
+	"sort"
+	"time"
+)
+
+func (s *Store) InsertFetchAttempt(attempt *storage.FetchAttempt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.active[partnerKey{attempt.Network, attempt.Partner}]; !ok {
+		return fmt.Errorf("%w: %s в сети %s", storage.ErrPartnerNotFound, attempt.Partner, attempt.Network)
+	}
+	s.lastAttemptID++
+	attempt.ID = s.lastAttemptID
+	s.attempts = append(s.attempts, *attempt)
+	return nil
+}
+
+func (s *Store) GetFetchAttempts(network string, partnerName string, since time.Time) ([]storage.FetchAttempt, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var attempts []storage.FetchAttempt
+	for _, a := range s.attempts {
+		if a.Network != network || (partnerName != "" && a.Partner != partnerName) {
+			continue
+		}
+		if !a.StartedAt.Before(since) {
+			attempts = append(attempts, a)
+		}
+	}
+	sort.SliceStable(attempts, func(i, j int) bool { return attempts[i].StartedAt.Before(attempts[j].StartedAt) })
+	return attempts, nil
+}
+
+func (s *Store) DeleteFetchAttemptsBefore(network string, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.attempts[:0]
+	var deleted int64
+	for _, a := range s.attempts {
+		if a.Network == network && a.StartedAt.Before(before) {
+			deleted++
+			continue
+		}
+		kept = append(kept, a)
+	}
+	s.attempts = kept
+	return deleted, nil
+}
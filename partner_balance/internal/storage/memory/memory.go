@@ -0,0 +1,290 @@
+package memory
+
+import (
+	"fmt"
+	"partner_balance/internal/storage"
+	"sort"
+	"sync"
+	"time"
+)
+
+type partnerKey struct {
+	network string
+	partner string
+}
+
+type sample struct {
+	createdAt time.Time
+	balance   float64
+	currency  string
+	snap      storage.Snapshot
+}
+
+// Store — потокобезопасная in-memory реализация storage.BalanceStore для тестов.
+type Store struct {
+	mu       sync.RWMutex
+	active   map[partnerKey]bool
+	balances map[partnerKey][]sample
+	rollups  map[partnerKey]map[time.Time]storage.DailyRollup
+	alerts   map[alertKey]storage.AlertState
+	events   []storage.AlertEvent
+	topUps   []storage.TopUp
+	attempts []storage.FetchAttempt
+	runs     []storage.CollectionRun
+
+	lastAttemptID int64 // ID попыток не переиспользуются после удаления
+
+	// Now — источник текущего времени, в тестах можно подменить.
+	Now func() time.Time
+}
+
+var _ storage.BalanceStore = (*Store)(nil)
+
+func New() *Store {
+	return &Store{
+		active:   make(map[partnerKey]bool),
+		balances: make(map[partnerKey][]sample),
+		rollups:  make(map[partnerKey]map[time.Time]storage.DailyRollup),
+		alerts:   make(map[alertKey]storage.AlertState),
+		Now:      time.Now,
+	}
+}
+
+func (s *Store) InsertPartner(partnerName string, network string, isActive bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active[partnerKey{network, partnerName}] = isActive
+	return nil
+}
+
+func (s *Store) InsertBalance(partnerName string, balance float64, network string) error {
+	return s.InsertBalanceWithCurrency(partnerName, balance, "", network)
+}
+
+func (s *Store) InsertBalanceWithCurrency(partnerName string, balance float64, currency string, network string) error {
+	return s.InsertSnapshot(partnerName, network, storage.Snapshot{Balance: balance, Currency: currency})
+}
+
+func (s *Store) InsertSnapshot(partnerName string, network string, snap storage.Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := partnerKey{network, partnerName}
+	if _, ok := s.active[key]; !ok {
+		return fmt.Errorf("%w: %s в сети %s", storage.ErrPartnerNotFound, partnerName, network)
+	}
+	snap.CreatedAt = s.Now()
+	s.balances[key] = append(s.balances[key], sample{createdAt: snap.CreatedAt, balance: snap.Balance, currency: snap.Currency, snap: snap})
+	return nil
+}
+
+func (s *Store) GetSnapshots(partnerName string, network string, since time.Time) ([]storage.Snapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key := partnerKey{network, partnerName}
+	if _, ok := s.active[key]; !ok {
+		return nil, fmt.Errorf("%w: %s в сети %s", storage.ErrPartnerNotFound, partnerName, network)
+	}
+
+	var snaps []storage.Snapshot
+	for _, smp := range s.balances[key] {
+		if !smp.createdAt.Before(since) {
+			snaps = append(snaps, smp.snap)
+		}
+	}
+	sort.SliceStable(snaps, func(i, j int) bool { return snaps[i].CreatedAt.Before(snaps[j].CreatedAt) })
+	return snaps, nil
+}
+
+func (s *Store) GetBalances(partnerName string, network string) ([]float64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key := partnerKey{network, partnerName}
+	if _, ok := s.active[key]; !ok {
+		return nil, fmt.Errorf("%w: %s в сети %s", storage.ErrPartnerNotFound, partnerName, network)
+	}
+
+	from := startOfDay(s.Now()).AddDate(0, 0, -3)
+	samples := s.balances[key]
+	var balances []float64
+	// образцы хранятся в порядке вставки, отдаём от новых к старым
+	for i := len(samples) - 1; i >= 0; i-- {
+		if !samples[i].createdAt.Before(from) {
+			balances = append(balances, samples[i].balance)
+		}
+	}
+	return balances, nil
+}
+
+func (s *Store) GetSamples(partnerName string, network string, since time.Time) ([]storage.Sample, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key := partnerKey{network, partnerName}
+	if _, ok := s.active[key]; !ok {
+		return nil, fmt.Errorf("%w: %s в сети %s", storage.ErrPartnerNotFound, partnerName, network)
+	}
+
+	var samples []storage.Sample
+	for _, smp := range s.balances[key] {
+		if !smp.createdAt.Before(since) {
+			samples = append(samples, storage.Sample{CreatedAt: smp.createdAt, Balance: smp.balance, Currency: smp.currency})
+		}
+	}
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].CreatedAt.Before(samples[j].CreatedAt) })
+	return samples, nil
+}
+
+func (s *Store) BalanceHistory(partnerName string, network string, from, to time.Time, step storage.Step) ([]storage.HistoryBucket, error) {
+	samples, err := s.GetSamples(partnerName, network, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	var buckets []storage.HistoryBucket
+	var prev *storage.Sample
+	for i := range samples {
+		smp := samples[i]
+		if !smp.CreatedAt.Before(to) {
+			break
+		}
+		if smp.CreatedAt.Before(from) {
+			continue
+		}
+		start := step.Truncate(smp.CreatedAt, from.Location())
+		if len(buckets) == 0 || !buckets[len(buckets)-1].Start.Equal(start) {
+			buckets = append(buckets, storage.HistoryBucket{Start: start, Min: smp.Balance, Max: smp.Balance})
+		}
+		b := &buckets[len(buckets)-1]
+		if smp.Balance < b.Min {
+			b.Min = smp.Balance
+		}
+		if smp.Balance > b.Max {
+			b.Max = smp.Balance
+		}
+		b.Last = smp.Balance
+		b.Samples++
+		if prev != nil {
+			if delta := prev.Balance - smp.Balance; delta > 0 {
+				b.Spend += delta
+			} else {
+				b.TopUp += -delta
+			}
+		}
+		prev = &samples[i]
+	}
+	return buckets, nil
+}
+
+func (s *Store) RollupDaily(network string, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, samples := range s.balances {
+		if key.network != network {
+			continue
+		}
+		days := make(map[time.Time]storage.DailyRollup)
+		lastAt := make(map[time.Time]time.Time)
+		for _, smp := range samples {
+			if !smp.createdAt.Before(before) {
+				continue
+			}
+			day := dateOf(smp.createdAt, before.Location())
+			r, ok := days[day]
+			if !ok {
+				r = storage.DailyRollup{Day: day, Min: smp.balance, Max: smp.balance}
+			}
+			if smp.balance < r.Min {
+				r.Min = smp.balance
+			}
+			if smp.balance > r.Max {
+				r.Max = smp.balance
+			}
+			if !smp.createdAt.Before(lastAt[day]) {
+				r.Last = smp.balance
+				lastAt[day] = smp.createdAt
+			}
+			r.Samples++
+			days[day] = r
+		}
+		if len(days) == 0 {
+			continue
+		}
+		if s.rollups[key] == nil {
+			s.rollups[key] = make(map[time.Time]storage.DailyRollup)
+		}
+		for day, r := range days {
+			s.rollups[key][day] = r
+		}
+	}
+	return nil
+}
+
+func (s *Store) GetDailyRollups(partnerName string, network string, from time.Time) ([]storage.DailyRollup, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key := partnerKey{network, partnerName}
+	if _, ok := s.active[key]; !ok {
+		return nil, fmt.Errorf("%w: %s в сети %s", storage.ErrPartnerNotFound, partnerName, network)
+	}
+
+	fromDay := dateOf(from, from.Location())
+	var rollups []storage.DailyRollup
+	for day, r := range s.rollups[key] {
+		if !day.Before(fromDay) {
+			rollups = append(rollups, r)
+		}
+	}
+	sort.Slice(rollups, func(i, j int) bool { return rollups[i].Day.Before(rollups[j].Day) })
+	return rollups, nil
+}
+
+func (s *Store) DeleteBalancesBefore(network string, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	for key, samples := range s.balances {
+		if key.network != network {
+			continue
+		}
+		kept := samples[:0]
+		for _, smp := range samples {
+			if smp.createdAt.Before(before) {
+				deleted++
+				continue
+			}
+			kept = append(kept, smp)
+		}
+		s.balances[key] = kept
+	}
+	return deleted, nil
+}
+
+func (s *Store) DeleteRollupsBefore(network string, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	beforeDay := dateOf(before, before.Location())
+	var deleted int64
+	for key, days := range s.rollups {
+		if key.network != network {
+			continue
+		}
+		for day := range days {
+			if day.Before(beforeDay) {
+				delete(days, day)
+				deleted++
+			}
+		}
+	}
+	return deleted, nil
+}
+
+// dateOf возвращает дату момента t в часовом поясе loc как полночь UTC (аналог ::date в Postgres)
+func dateOf(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// startOfDay — аналог CURRENT_DATE в Postgres
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"partner_balance/internal/storage"
+	"partner_balance/internal/storage/storagetest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.BalanceStore {
+		return New()
+	})
+}
+
+func TestStore_OldSamplesAreHiddenAndDeleted(t *testing.T) {
+	s := New()
+	now := time.Date(2025, 4, 10, 12, 0, 0, 0, time.UTC)
+	s.Now = func() time.Time { return now.AddDate(0, 0, -10) }
+
+	assert.NoError(t, s.InsertPartner("Partner1", "net", true))
+	assert.NoError(t, s.InsertBalance("Partner1", 1, "net"))
+
+	s.Now = func() time.Time { return now }
+	assert.NoError(t, s.InsertBalance("Partner1", 2, "net"))
+
+	balances, err := s.GetBalances("Partner1", "net")
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{2}, balances)
+
+	deleted, err := s.DeleteBalancesBefore("net", now.AddDate(0, 0, -7))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	assert.Len(t, s.balances[partnerKey{"net", "Partner1"}], 1)
+}
+
+func TestLastCollectionRun_Empty(t *testing.T) {
+	_, err := New().LastCollectionRun("")
+	assert.ErrorIs(t, err, storage.ErrNoCollectionRuns)
+}
@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"partner_balance/internal/storage"
+)
+
+func (s *Store) SaveCollectionRun(run storage.CollectionRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run.Results = append([]storage.CollectionResult(nil), run.Results...)
+	for i := range s.runs {
+		if s.runs[i].ID == run.ID {
+			s.runs[i] = run
+			return nil
+		}
+	}
+	s.runs = append(s.runs, run)
+	return nil
+}
+
+func (s *Store) LastCollectionRun(network string) (storage.CollectionRun, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var (
+		last  storage.CollectionRun
+		found bool
+	)
+	for _, run := range s.runs {
+		if network != "" && !collectsNetwork(run, network) {
+			continue
+		}
+		if !found || !run.StartedAt.Before(last.StartedAt) {
+			last, found = run, true
+		}
+	}
+	if !found {
+		return storage.CollectionRun{}, storage.ErrNoCollectionRuns
+	}
+	return last, nil
+}
+
+// collectsNetwork сообщает, собирались ли в запуске партнёры сети
+func collectsNetwork(run storage.CollectionRun, network string) bool {
+	for _, res := range run.Results {
+		if res.Network == network {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package memory
+
+import (
+	"fmt"
+	"partner_balance/internal/storage"
+	"sort"
+	"time"
+)
+
+func (s *Store) InsertTopUp(topUp *storage.TopUp) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.active[partnerKey{topUp.Network, topUp.Partner}]; !ok {
+		return fmt.Errorf("%w: %s в сети %s", storage.ErrPartnerNotFound, topUp.Partner, topUp.Network)
+	}
+	for _, t := range s.topUps {
+		if t.Network == topUp.Network && t.Partner == topUp.Partner && t.DetectedAt.Equal(topUp.DetectedAt) {
+			return storage.ErrTopUpExists
+		}
+	}
+	topUp.ID = int64(len(s.topUps) + 1)
+	s.topUps = append(s.topUps, *topUp)
+	return nil
+}
+
+func (s *Store) GetTopUps(network string, partnerName string, from, to time.Time) ([]storage.TopUp, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var topUps []storage.TopUp
+	for _, t := range s.topUps {
+		if t.Network != network || (partnerName != "" && t.Partner != partnerName) {
+			continue
+		}
+		if !t.At.Before(from) && t.At.Before(to) {
+			topUps = append(topUps, t)
+		}
+	}
+	sort.SliceStable(topUps, func(i, j int) bool { return topUps[i].At.Before(topUps[j].At) })
+	return topUps, nil
+}
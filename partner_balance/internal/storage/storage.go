@@ -0,0 +1,247 @@
+package storage
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrPartnerNotFound возвращается, если партнёр не заведён в указанной сети.
+var ErrPartnerNotFound = errors.New("партнёр не найден")
+
+// ErrTopUpExists возвращается InsertTopUp, если пополнение партнёра на этом замере уже записано.
+var ErrTopUpExists = errors.New("пополнение уже записано")
+
+// ErrNoCollectionRuns возвращается LastCollectionRun, если сборов ещё не было.
+var ErrNoCollectionRuns = errors.New("запусков сбора ещё не было")
+
+// BalanceStore — хранилище партнёров и истории их балансов.
+// Реализации: postgres (db.Store) и in-memory (memory.Store) для тестов.
+type BalanceStore interface {
+	// InsertPartner добавляет партнёра в сеть или обновляет его активность.
+	InsertPartner(partnerName string, network string, isActive bool) error
+	// InsertBalance сохраняет текущий баланс партнёра в неизвестной валюте.
+	InsertBalance(partnerName string, balance float64, network string) error
+	// InsertBalanceWithCurrency сохраняет текущий баланс партнёра с кодом валюты (ISO 4217).
+	InsertBalanceWithCurrency(partnerName string, balance float64, currency string, network string) error
+	// InsertSnapshot сохраняет текущий баланс партнёра вместе с составляющими и телом ответа.
+	InsertSnapshot(partnerName string, network string, snap Snapshot) error
+	// GetSnapshots возвращает снимки партнёра начиная с since, от старых к новым.
+	GetSnapshots(partnerName string, network string, since time.Time) ([]Snapshot, error)
+	// GetBalances возвращает балансы партнёра за последние 3 дня, от новых к старым.
+	GetBalances(partnerName string, network string) ([]float64, error)
+	// GetSamples возвращает замеры партнёра начиная с since, от старых к новым.
+	GetSamples(partnerName string, network string, since time.Time) ([]Sample, error)
+	// BalanceHistory возвращает замеры партнёра за [from, to), сгруппированные по интервалам step.
+	// Границы интервалов считаются в часовом поясе from.
+	BalanceHistory(partnerName string, network string, from, to time.Time, step Step) ([]HistoryBucket, error)
+	// RollupDaily сворачивает сырые балансы сети, снятые раньше before, в дневные агрегаты.
+	// День определяется в часовом поясе before.
+	RollupDaily(network string, before time.Time) error
+	// GetDailyRollups возвращает дневные агрегаты партнёра начиная с дня from, по возрастанию дня.
+	GetDailyRollups(partnerName string, network string, from time.Time) ([]DailyRollup, error)
+	// DeleteBalancesBefore удаляет сырые балансы сети, снятые раньше before.
+	DeleteBalancesBefore(network string, before time.Time) (int64, error)
+	// DeleteRollupsBefore удаляет дневные агрегаты сети за дни раньше дня before.
+	DeleteRollupsBefore(network string, before time.Time) (int64, error)
+	// GetAlertStates возвращает сохранённые состояния правил тревоги всех партнёров сети.
+	GetAlertStates(network string) ([]AlertState, error)
+	// SaveAlert сохраняет состояние правила и, если event не nil, в той же транзакции
+	// записывает событие, заполняя его ID и CreatedAt.
+	SaveAlert(state AlertState, event *AlertEvent) error
+	// GetAlertEvents возвращает до limit событий сетей networks с ID больше afterID по возрастанию ID.
+	// Пустой networks — все сети.
+	GetAlertEvents(networks []string, afterID int64, limit int) ([]AlertEvent, error)
+	// LastAlertEventID возвращает ID последнего события, 0 если событий нет.
+	LastAlertEventID() (int64, error)
+	// InsertTopUp записывает обнаруженное пополнение, заполняя его ID.
+	// Пополнение партнёра с тем же DetectedAt не дублируется: возвращается ErrTopUpExists.
+	InsertTopUp(topUp *TopUp) error
+	// GetTopUps возвращает пополнения сети с оценкой времени в [from, to) по возрастанию времени.
+	// Пустой partnerName — все партнёры сети.
+	GetTopUps(network string, partnerName string, from, to time.Time) ([]TopUp, error)
+	// InsertFetchAttempt записывает попытку сбора баланса, заполняя её ID.
+	InsertFetchAttempt(attempt *FetchAttempt) error
+	// GetFetchAttempts возвращает попытки сбора сети начиная с since, от старых к новым.
+	// Пустой partnerName — все партнёры сети.
+	GetFetchAttempts(network string, partnerName string, since time.Time) ([]FetchAttempt, error)
+	// DeleteFetchAttemptsBefore удаляет попытки сбора сети, начатые раньше before.
+	DeleteFetchAttemptsBefore(network string, before time.Time) (int64, error)
+	// SaveCollectionRun сохраняет отчёт о запуске сбора балансов.
+	SaveCollectionRun(run CollectionRun) error
+	// LastCollectionRun возвращает последний по времени начала запуск сбора, в котором
+	// собиралась сеть network (пусто — любой), ErrNoCollectionRuns — если таких запусков не было.
+	LastCollectionRun(network string) (CollectionRun, error)
+}
+
+// Sample — один замер баланса
+type Sample struct {
+	CreatedAt time.Time
+	Balance   float64
+	Currency  string // код валюты, пусто — неизвестна
+}
+
+// Snapshot — замер баланса со всеми данными, которые вернул партнёр
+type Snapshot struct {
+	CreatedAt   time.Time // заполняется хранилищем при вставке
+	Balance     float64
+	Currency    string
+	Reserved    *float64 // nil — партнёр не возвращает
+	CreditLimit *float64
+	Bonus       *float64
+	Extra       map[string]float64
+	Raw         []byte // тело ответа; не-JSON хранится как JSON-строка
+}
+
+// Step — шаг группировки истории балансов
+type Step string
+
+const (
+	StepHour Step = "hour"
+	StepDay  Step = "day"
+	StepWeek Step = "week" // недели начинаются с понедельника
+)
+
+// Valid сообщает, поддерживается ли шаг группировки.
+func (s Step) Valid() bool {
+	return s == StepHour || s == StepDay || s == StepWeek
+}
+
+// Duration возвращает длительность шага.
+func (s Step) Duration() time.Duration {
+	switch s {
+	case StepHour:
+		return time.Hour
+	case StepWeek:
+		return 7 * 24 * time.Hour
+	default:
+		return 24 * time.Hour
+	}
+}
+
+// Truncate возвращает начало интервала шага, в который попадает t (в часовом поясе loc).
+func (s Step) Truncate(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	y, m, d := t.Date()
+	switch s {
+	case StepHour:
+		return time.Date(y, m, d, t.Hour(), 0, 0, 0, loc)
+	case StepWeek:
+		offset := (int(t.Weekday()) + 6) % 7 // дней с понедельника
+		return time.Date(y, m, d-offset, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(y, m, d, 0, 0, 0, 0, loc)
+	}
+}
+
+// HistoryBucket — замеры партнёра за один интервал истории
+type HistoryBucket struct {
+	Start   time.Time // начало интервала
+	Min     float64
+	Max     float64
+	Last    float64 // последний баланс в интервале
+	Samples int
+	Spend   float64 // сумма снижений баланса между соседними замерами
+	TopUp   float64 // сумма ростов баланса между соседними замерами
+}
+
+// DailyRollup — дневной агрегат балансов партнёра
+type DailyRollup struct {
+	Day     time.Time // дата агрегата (полночь UTC, как DATE в Postgres)
+	Min     float64
+	Max     float64
+	Last    float64 // последний баланс за день
+	Samples int     // сколько сырых замеров свернули
+}
+
+// AlertState — состояние одного правила тревоги партнёра
+type AlertState struct {
+	Network      string
+	Partner      string
+	Rule         string
+	Firing       bool      // правило сработало и ещё не восстановилось
+	Since        time.Time // с какого момента действует текущее состояние
+	LastNotified time.Time // когда отправлено последнее событие fire или remind
+	Reason       string    // причина последнего срабатывания
+}
+
+// AlertEventKind — тип события тревоги
+type AlertEventKind string
+
+const (
+	AlertFire    AlertEventKind = "fire"    // правило начало срабатывать
+	AlertRemind  AlertEventKind = "remind"  // правило всё ещё срабатывает
+	AlertResolve AlertEventKind = "resolve" // правило перестало срабатывать
+)
+
+// AlertEvent — событие тревоги; ID монотонно растёт
+type AlertEvent struct {
+	ID        int64
+	Network   string
+	Partner   string
+	Rule      string
+	Kind      AlertEventKind
+	Reason    string
+	Value     float64
+	Limit     float64
+	Since     time.Time // начало срабатывания, к которому относится событие
+	CreatedAt time.Time
+}
+
+// TopUp — пополнение баланса, обнаруженное по росту баланса между соседними замерами
+type TopUp struct {
+	ID          int64
+	Network     string
+	Partner     string
+	At          time.Time // оценка времени пополнения — середина интервала между замерами
+	Amount      float64   // оценка суммы: прирост баланса плюс расход за интервал
+	Delta       float64   // прирост баланса между замерами
+	PrevBalance float64
+	Balance     float64
+	DetectedAt  time.Time // время замера, на котором обнаружено пополнение
+}
+
+// FetchAttempt — одна попытка сбора баланса партнёра: успех со значением или ошибка с классом.
+// Неудачные попытки — явные пропуски в истории замеров.
+type FetchAttempt struct {
+	ID         int64
+	Network    string
+	Partner    string
+	StartedAt  time.Time
+	Latency    time.Duration
+	OK         bool
+	Balance    float64 // баланс при успехе
+	ErrorClass string  // класс ошибки, например timeout, http, network; пусто при успехе
+	HTTPStatus int     // статус ответа партнёра, 0 — ответа не было
+	Error      string  // текст ошибки
+}
+
+// CollectionRun — отчёт об одном запуске сбора балансов по расписанию
+type CollectionRun struct {
+	ID         string
+	Schedule   string // расписание, по которому запущен сбор
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Results    []CollectionResult // по сети и имени партнёра
+}
+
+// CollectionResult — итог сбора баланса одного партнёра в запуске
+type CollectionResult struct {
+	Network    string        `json:"network"`
+	Partner    string        `json:"partner"`
+	OK         bool          `json:"ok"`
+	Balance    float64       `json:"balance,omitempty"`
+	Duration   time.Duration `json:"duration_ns"`
+	ErrorClass string        `json:"error_class,omitempty"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// Failed возвращает число партнёров, баланс которых не собран.
+func (r CollectionRun) Failed() int {
+	n := 0
+	for _, res := range r.Results {
+		if !res.OK {
+			n++
+		}
+	}
+	return n
+}
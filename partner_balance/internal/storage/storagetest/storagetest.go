@@ -0,0 +1,498 @@
+// Package storagetest содержит общий набор тестов, который должна проходить
+// любая реализация storage.BalanceStore.
+package storagetest
+
+import (
+	"fmt"
+	"partner_balance/internal/storage"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var seq atomic.Int64
+
+// uniqueNetwork возвращает имя сети, не пересекающееся с данными других запусков
+func uniqueNetwork(prefix string) string {
+	return fmt.Sprintf("%s_%d_%d", prefix, time.Now().UnixNano(), seq.Add(1))
+}
+
+// Run прогоняет набор тестов против хранилища, создаваемого newStore.
+func Run(t *testing.T, newStore func(t *testing.T) storage.BalanceStore) {
+	t.Run("UnknownPartner", func(t *testing.T) {
+		s := newStore(t)
+		network := uniqueNetwork("conf")
+
+		err := s.InsertBalance("Ghost", 100, network)
+		assert.ErrorIs(t, err, storage.ErrPartnerNotFound)
+
+		_, err = s.GetBalances("Ghost", network)
+		assert.ErrorIs(t, err, storage.ErrPartnerNotFound)
+	})
+
+	t.Run("InsertPartnerIsIdempotent", func(t *testing.T) {
+		s := newStore(t)
+		network := uniqueNetwork("conf")
+
+		require.NoError(t, s.InsertPartner("Partner1", network, true))
+		require.NoError(t, s.InsertPartner("Partner1", network, false))
+		require.NoError(t, s.InsertPartner("Partner1", network, true))
+
+		balances, err := s.GetBalances("Partner1", network)
+		assert.NoError(t, err)
+		assert.Empty(t, balances)
+	})
+
+	t.Run("BalancesNewestFirst", func(t *testing.T) {
+		s := newStore(t)
+		network := uniqueNetwork("conf")
+		require.NoError(t, s.InsertPartner("Partner1", network, true))
+
+		for _, b := range []float64{1000, 1200, 1400} {
+			require.NoError(t, s.InsertBalance("Partner1", b, network))
+			time.Sleep(2 * time.Millisecond)
+		}
+
+		balances, err := s.GetBalances("Partner1", network)
+		assert.NoError(t, err)
+		assert.Equal(t, []float64{1400, 1200, 1000}, balances)
+	})
+
+	t.Run("SamplesOldestFirst", func(t *testing.T) {
+		s := newStore(t)
+		network := uniqueNetwork("conf")
+		require.NoError(t, s.InsertPartner("Partner1", network, true))
+
+		for _, b := range []float64{300, 200, 100} {
+			require.NoError(t, s.InsertBalance("Partner1", b, network))
+			time.Sleep(2 * time.Millisecond)
+		}
+
+		samples, err := s.GetSamples("Partner1", network, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		require.Len(t, samples, 3)
+		for i, b := range []float64{300, 200, 100} {
+			assert.Equal(t, b, samples[i].Balance)
+		}
+		assert.True(t, samples[0].CreatedAt.Before(samples[2].CreatedAt))
+
+		samples, err = s.GetSamples("Partner1", network, time.Now().Add(time.Hour))
+		assert.NoError(t, err)
+		assert.Empty(t, samples)
+
+		_, err = s.GetSamples("Ghost", network, time.Now())
+		assert.ErrorIs(t, err, storage.ErrPartnerNotFound)
+	})
+
+	t.Run("BalanceHistory", func(t *testing.T) {
+		s := newStore(t)
+		network := uniqueNetwork("conf")
+		require.NoError(t, s.InsertPartner("Partner1", network, true))
+
+		for _, b := range []float64{300, 200, 250, 150} {
+			require.NoError(t, s.InsertBalance("Partner1", b, network))
+			time.Sleep(2 * time.Millisecond)
+		}
+
+		now := time.Now()
+		buckets, err := s.BalanceHistory("Partner1", network, now.Add(-time.Hour), now.Add(time.Hour), storage.StepHour)
+		require.NoError(t, err)
+		require.NotEmpty(t, buckets)
+
+		// замеры могут попасть на границу часа, поэтому проверяем суммы по всем интервалам
+		var samples int
+		var spend, topUp float64
+		minBalance := buckets[0].Min
+		for _, b := range buckets {
+			samples += b.Samples
+			spend += b.Spend
+			topUp += b.TopUp
+			if b.Min < minBalance {
+				minBalance = b.Min
+			}
+		}
+		assert.Equal(t, 4, samples)
+		assert.Equal(t, 200.0, spend)
+		assert.Equal(t, 50.0, topUp)
+		assert.Equal(t, 150.0, minBalance)
+		assert.Equal(t, 150.0, buckets[len(buckets)-1].Last)
+
+		buckets, err = s.BalanceHistory("Partner1", network, now.Add(time.Hour), now.Add(2*time.Hour), storage.StepDay)
+		assert.NoError(t, err)
+		assert.Empty(t, buckets)
+	})
+
+	t.Run("AlertState", func(t *testing.T) {
+		s := newStore(t)
+		network := uniqueNetwork("conf")
+		other := uniqueNetwork("conf")
+		require.NoError(t, s.InsertPartner("Partner1", network, true))
+		require.NoError(t, s.InsertPartner("Partner1", other, true))
+
+		err := s.SaveAlert(storage.AlertState{Network: network, Partner: "Ghost", Rule: "min_balance"}, nil)
+		assert.ErrorIs(t, err, storage.ErrPartnerNotFound)
+
+		since := time.Now().Add(-time.Hour).Truncate(time.Second)
+		state := storage.AlertState{
+			Network: network, Partner: "Partner1", Rule: "min_balance",
+			Firing: true, Since: since, LastNotified: since, Reason: "низкий баланс",
+		}
+		fire := &storage.AlertEvent{
+			Network: network, Partner: "Partner1", Rule: "min_balance",
+			Kind: storage.AlertFire, Reason: "низкий баланс", Value: 50, Limit: 100, Since: since,
+		}
+		require.NoError(t, s.SaveAlert(state, fire))
+		assert.NotZero(t, fire.ID)
+		assert.False(t, fire.CreatedAt.IsZero())
+
+		states, err := s.GetAlertStates(network)
+		require.NoError(t, err)
+		require.Len(t, states, 1)
+		assert.True(t, states[0].Firing)
+		assert.Equal(t, "низкий баланс", states[0].Reason)
+		assert.WithinDuration(t, since, states[0].Since, time.Second)
+
+		// восстановление перезаписывает состояние и добавляет новое событие
+		state.Firing, state.Since = false, time.Now().Truncate(time.Second)
+		resolve := &storage.AlertEvent{
+			Network: network, Partner: "Partner1", Rule: "min_balance",
+			Kind: storage.AlertResolve, Since: since,
+		}
+		require.NoError(t, s.SaveAlert(state, resolve))
+		assert.Greater(t, resolve.ID, fire.ID)
+
+		states, err = s.GetAlertStates(network)
+		require.NoError(t, err)
+		require.Len(t, states, 1)
+		assert.False(t, states[0].Firing)
+
+		states, err = s.GetAlertStates(other)
+		assert.NoError(t, err)
+		assert.Empty(t, states)
+	})
+
+	t.Run("AlertEvents", func(t *testing.T) {
+		s := newStore(t)
+		netA := uniqueNetwork("conf")
+		netB := uniqueNetwork("conf")
+		require.NoError(t, s.InsertPartner("Partner1", netA, true))
+		require.NoError(t, s.InsertPartner("Partner1", netB, true))
+
+		start, err := s.LastAlertEventID()
+		require.NoError(t, err)
+
+		var ids []int64
+		for _, network := range []string{netA, netB, netA} {
+			event := &storage.AlertEvent{
+				Network: network, Partner: "Partner1", Rule: "min_balance",
+				Kind: storage.AlertFire, Since: time.Now(),
+			}
+			state := storage.AlertState{Network: network, Partner: "Partner1", Rule: "min_balance", Firing: true, Since: time.Now()}
+			require.NoError(t, s.SaveAlert(state, event))
+			ids = append(ids, event.ID)
+		}
+
+		last, err := s.LastAlertEventID()
+		require.NoError(t, err)
+		assert.Equal(t, ids[2], last)
+
+		events, err := s.GetAlertEvents([]string{netA}, start, 10)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		assert.Equal(t, ids[0], events[0].ID)
+		assert.Equal(t, ids[2], events[1].ID)
+		assert.Equal(t, netA, events[0].Network)
+		assert.Equal(t, storage.AlertFire, events[0].Kind)
+
+		events, err = s.GetAlertEvents([]string{netA, netB}, ids[0], 10)
+		require.NoError(t, err)
+		assert.Len(t, events, 2)
+
+		events, err = s.GetAlertEvents([]string{netA, netB}, start, 1)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, ids[0], events[0].ID)
+	})
+
+	t.Run("TopUps", func(t *testing.T) {
+		s := newStore(t)
+		network := uniqueNetwork("conf")
+		require.NoError(t, s.InsertPartner("Partner1", network, true))
+		require.NoError(t, s.InsertPartner("Partner2", network, true))
+
+		ghost := &storage.TopUp{Network: network, Partner: "Ghost"}
+		assert.ErrorIs(t, s.InsertTopUp(ghost), storage.ErrPartnerNotFound)
+
+		base := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+		for i, partner := range []string{"Partner1", "Partner2", "Partner1"} {
+			topUp := &storage.TopUp{
+				Network: network, Partner: partner,
+				At:     base.Add(time.Duration(i) * time.Hour),
+				Amount: 1050, Delta: 1000, PrevBalance: 100, Balance: 1100,
+				DetectedAt: base.Add(time.Duration(i)*time.Hour + 30*time.Minute),
+			}
+			require.NoError(t, s.InsertTopUp(topUp))
+			assert.NotZero(t, topUp.ID)
+		}
+
+		again := &storage.TopUp{Network: network, Partner: "Partner1", At: base, DetectedAt: base.Add(30 * time.Minute)}
+		assert.ErrorIs(t, s.InsertTopUp(again), storage.ErrTopUpExists, "пополнение на том же замере не дублируется")
+
+		all, err := s.GetTopUps(network, "", base, base.Add(24*time.Hour))
+		require.NoError(t, err)
+		require.Len(t, all, 3)
+		assert.Equal(t, "Partner2", all[1].Partner)
+		assert.Equal(t, 1050.0, all[0].Amount)
+		assert.Equal(t, 1100.0, all[0].Balance)
+		assert.WithinDuration(t, base, all[0].At, time.Second)
+
+		own, err := s.GetTopUps(network, "Partner1", base.Add(time.Minute), base.Add(24*time.Hour))
+		require.NoError(t, err)
+		require.Len(t, own, 1)
+		assert.WithinDuration(t, base.Add(2*time.Hour), own[0].At, time.Second)
+	})
+
+	t.Run("FetchAttempts", func(t *testing.T) {
+		s := newStore(t)
+		network := uniqueNetwork("conf")
+		require.NoError(t, s.InsertPartner("Partner1", network, true))
+		require.NoError(t, s.InsertPartner("Partner2", network, true))
+
+		ghost := &storage.FetchAttempt{Network: network, Partner: "Ghost"}
+		assert.ErrorIs(t, s.InsertFetchAttempt(ghost), storage.ErrPartnerNotFound)
+
+		base := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+		attempts := []storage.FetchAttempt{
+			{Partner: "Partner1", StartedAt: base, Latency: 120 * time.Millisecond, OK: true, Balance: 500},
+			{Partner: "Partner2", StartedAt: base.Add(time.Minute), Latency: 10 * time.Second, ErrorClass: "timeout", Error: "deadline exceeded"},
+			{Partner: "Partner1", StartedAt: base.Add(time.Hour), Latency: 300 * time.Millisecond, ErrorClass: "http", HTTPStatus: 503, Error: "503 Service Unavailable"},
+		}
+		for i := range attempts {
+			attempts[i].Network = network
+			require.NoError(t, s.InsertFetchAttempt(&attempts[i]))
+			assert.NotZero(t, attempts[i].ID)
+		}
+
+		all, err := s.GetFetchAttempts(network, "", base)
+		require.NoError(t, err)
+		require.Len(t, all, 3)
+		assert.Equal(t, "Partner2", all[1].Partner)
+		assert.True(t, all[0].OK)
+		assert.Equal(t, 500.0, all[0].Balance)
+		assert.Equal(t, 120*time.Millisecond, all[0].Latency)
+		assert.Equal(t, "timeout", all[1].ErrorClass)
+		assert.Zero(t, all[1].HTTPStatus)
+		assert.Equal(t, 503, all[2].HTTPStatus)
+		assert.Equal(t, "503 Service Unavailable", all[2].Error)
+
+		own, err := s.GetFetchAttempts(network, "Partner1", base.Add(time.Second))
+		require.NoError(t, err)
+		require.Len(t, own, 1)
+		assert.False(t, own[0].OK)
+		assert.WithinDuration(t, base.Add(time.Hour), own[0].StartedAt, time.Second)
+
+		deleted, err := s.DeleteFetchAttemptsBefore(network, base.Add(30*time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), deleted)
+		all, err = s.GetFetchAttempts(network, "", base)
+		require.NoError(t, err)
+		require.Len(t, all, 1)
+		assert.Equal(t, attempts[2].ID, all[0].ID)
+	})
+
+	t.Run("CollectionRuns", func(t *testing.T) {
+		s := newStore(t)
+		id := uniqueNetwork("run")
+		network, other := uniqueNetwork("run"), uniqueNetwork("run")
+
+		base := time.Now().Add(time.Hour).Truncate(time.Second)
+		older := storage.CollectionRun{ID: id + "_a", Schedule: "@every 1h", StartedAt: base, FinishedAt: base.Add(time.Second)}
+		require.NoError(t, s.SaveCollectionRun(older))
+		latest := storage.CollectionRun{
+			ID: id + "_b", Schedule: "0 * * * *",
+			StartedAt: base.Add(time.Minute), FinishedAt: base.Add(time.Minute + 3*time.Second),
+			Results: []storage.CollectionResult{
+				{Network: network, Partner: "Partner1", OK: true, Balance: 150.5, Duration: 250 * time.Millisecond},
+				{Network: network, Partner: "Partner2", Duration: 10 * time.Second, ErrorClass: "timeout", Error: "deadline exceeded"},
+			},
+		}
+		require.NoError(t, s.SaveCollectionRun(latest))
+
+		got, err := s.LastCollectionRun("")
+		require.NoError(t, err)
+		assert.Equal(t, latest.ID, got.ID)
+		assert.Equal(t, "0 * * * *", got.Schedule)
+		assert.WithinDuration(t, latest.StartedAt, got.StartedAt, time.Second)
+		assert.WithinDuration(t, latest.FinishedAt, got.FinishedAt, time.Second)
+		assert.Equal(t, latest.Results, got.Results)
+		assert.Equal(t, 1, got.Failed())
+
+		latest.Results = latest.Results[:1]
+		require.NoError(t, s.SaveCollectionRun(latest))
+		got, err = s.LastCollectionRun("")
+		require.NoError(t, err)
+		assert.Len(t, got.Results, 1, "повторное сохранение обновляет запуск")
+
+		newest := storage.CollectionRun{
+			ID: id + "_c", StartedAt: base.Add(2 * time.Minute), FinishedAt: base.Add(2*time.Minute + time.Second),
+			Results: []storage.CollectionResult{{Network: other, Partner: "Partner1", OK: true}},
+		}
+		require.NoError(t, s.SaveCollectionRun(newest))
+		got, err = s.LastCollectionRun("")
+		require.NoError(t, err)
+		assert.Equal(t, newest.ID, got.ID)
+		got, err = s.LastCollectionRun(network)
+		require.NoError(t, err)
+		assert.Equal(t, latest.ID, got.ID, "последний запуск, в котором собиралась сеть")
+		_, err = s.LastCollectionRun(uniqueNetwork("run"))
+		assert.ErrorIs(t, err, storage.ErrNoCollectionRuns)
+	})
+
+	t.Run("SampleCurrency", func(t *testing.T) {
+		s := newStore(t)
+		network := uniqueNetwork("conf")
+		require.NoError(t, s.InsertPartner("Partner1", network, true))
+
+		require.NoError(t, s.InsertBalance("Partner1", 100, network))
+		time.Sleep(2 * time.Millisecond)
+		require.NoError(t, s.InsertBalanceWithCurrency("Partner1", 90, "EUR", network))
+
+		samples, err := s.GetSamples("Partner1", network, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		require.Len(t, samples, 2)
+		assert.Equal(t, "", samples[0].Currency)
+		assert.Equal(t, "EUR", samples[1].Currency)
+		assert.Equal(t, 90.0, samples[1].Balance)
+
+		err = s.InsertBalanceWithCurrency("Ghost", 1, "USD", network)
+		assert.ErrorIs(t, err, storage.ErrPartnerNotFound)
+	})
+
+	t.Run("Snapshots", func(t *testing.T) {
+		s := newStore(t)
+		network := uniqueNetwork("conf")
+		require.NoError(t, s.InsertPartner("Partner1", network, true))
+
+		reserved, bonus := 25.5, 10.0
+		require.NoError(t, s.InsertSnapshot("Partner1", network, storage.Snapshot{
+			Balance:  100,
+			Currency: "USD",
+			Reserved: &reserved,
+			Bonus:    &bonus,
+			Extra:    map[string]float64{"balanceReal": 90},
+			Raw:      []byte(`{"balanceCommon": 100, "balanceReal": 90}`),
+		}))
+		time.Sleep(2 * time.Millisecond)
+		require.NoError(t, s.InsertBalance("Partner1", 80, network))
+
+		snaps, err := s.GetSnapshots("Partner1", network, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		require.Len(t, snaps, 2)
+
+		full := snaps[0]
+		assert.Equal(t, 100.0, full.Balance)
+		assert.Equal(t, "USD", full.Currency)
+		require.NotNil(t, full.Reserved)
+		assert.Equal(t, 25.5, *full.Reserved)
+		require.NotNil(t, full.Bonus)
+		assert.Equal(t, 10.0, *full.Bonus)
+		assert.Nil(t, full.CreditLimit)
+		assert.Equal(t, map[string]float64{"balanceReal": 90}, full.Extra)
+		assert.JSONEq(t, `{"balanceCommon": 100, "balanceReal": 90}`, string(full.Raw))
+		assert.False(t, full.CreatedAt.IsZero())
+
+		plain := snaps[1]
+		assert.Equal(t, 80.0, plain.Balance)
+		assert.Nil(t, plain.Reserved)
+		assert.Empty(t, plain.Extra)
+		assert.Empty(t, plain.Raw)
+
+		// снимки видны и как обычные замеры
+		samples, err := s.GetSamples("Partner1", network, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		require.Len(t, samples, 2)
+		assert.Equal(t, "USD", samples[0].Currency)
+
+		_, err = s.GetSnapshots("Ghost", network, time.Time{})
+		assert.ErrorIs(t, err, storage.ErrPartnerNotFound)
+	})
+
+	t.Run("NetworksAreIsolated", func(t *testing.T) {
+		s := newStore(t)
+		netA, netB := uniqueNetwork("conf_a"), uniqueNetwork("conf_b")
+		require.NoError(t, s.InsertPartner("Partner1", netA, true))
+		require.NoError(t, s.InsertPartner("Partner1", netB, true))
+
+		require.NoError(t, s.InsertBalance("Partner1", 10, netA))
+		require.NoError(t, s.InsertBalance("Partner1", 20, netB))
+
+		a, err := s.GetBalances("Partner1", netA)
+		assert.NoError(t, err)
+		assert.Equal(t, []float64{10}, a)
+
+		b, err := s.GetBalances("Partner1", netB)
+		assert.NoError(t, err)
+		assert.Equal(t, []float64{20}, b)
+	})
+
+	t.Run("DeleteBalancesBefore", func(t *testing.T) {
+		s := newStore(t)
+		network := uniqueNetwork("conf")
+		require.NoError(t, s.InsertPartner("Partner1", network, true))
+		require.NoError(t, s.InsertBalance("Partner1", 55.5, network))
+
+		deleted, err := s.DeleteBalancesBefore(network, time.Now().Add(-time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), deleted)
+
+		balances, err := s.GetBalances("Partner1", network)
+		assert.NoError(t, err)
+		assert.Equal(t, []float64{55.5}, balances)
+
+		deleted, err = s.DeleteBalancesBefore(network, time.Now().Add(time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+
+		balances, err = s.GetBalances("Partner1", network)
+		assert.NoError(t, err)
+		assert.Empty(t, balances)
+	})
+
+	t.Run("RollupDaily", func(t *testing.T) {
+		s := newStore(t)
+		network := uniqueNetwork("conf")
+		require.NoError(t, s.InsertPartner("Partner1", network, true))
+		for _, b := range []float64{100, 50, 80} {
+			require.NoError(t, s.InsertBalance("Partner1", b, network))
+			time.Sleep(2 * time.Millisecond)
+		}
+
+		now := time.Now().UTC()
+		require.NoError(t, s.RollupDaily(network, now.Add(48*time.Hour)))
+		// повторная свёртка не должна дублировать агрегаты
+		require.NoError(t, s.RollupDaily(network, now.Add(48*time.Hour)))
+
+		rollups, err := s.GetDailyRollups("Partner1", network, now.Add(-48*time.Hour))
+		require.NoError(t, err)
+		require.Len(t, rollups, 1)
+		assert.Equal(t, 50.0, rollups[0].Min)
+		assert.Equal(t, 100.0, rollups[0].Max)
+		assert.Equal(t, 80.0, rollups[0].Last)
+		assert.Equal(t, 3, rollups[0].Samples)
+
+		deleted, err := s.DeleteRollupsBefore(network, now.Add(-48*time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), deleted)
+
+		deleted, err = s.DeleteRollupsBefore(network, now.Add(72*time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+
+		rollups, err = s.GetDailyRollups("Partner1", network, now.Add(-48*time.Hour))
+		assert.NoError(t, err)
+		assert.Empty(t, rollups)
+	})
+}
@@ -2,6 +2,10 @@ package utils
 
 import (
 	"os"
+	"sort"
+	"strings"
+	"time"
+
 	"gopkg.in/yaml.v3"
 )
 
@@ -9,14 +13,373 @@ type PartnerConfig struct {
 	Token       string `yaml:"token"`
 	Description string `yaml:"description"`
 	IsActive    bool   `yaml:"is_active"`
+	// Provider — тип адаптера из реестра req (например "partner1").
+	// Если не задан, используется имя партнёра в нижнем регистре.
+	Provider string `yaml:"provider"`
+	// Schedule — собственное расписание сбора партнёра (cron из 5 полей или "@every 1h").
+	Schedule string `yaml:"schedule"`
+	// Request — описание запроса для декларативного провайдера "http".
+	Request *RequestConfig `yaml:"request"`
+	// Alerts — правила тревоги партнёра, перекрывают правила из секции alerts.
+	Alerts AlertRules `yaml:"alerts"`
+	// Currency — валюта аккаунта (ISO 4217), если не задана — currency.default.
+	Currency string `yaml:"currency"`
+	// Params — параметры конкретного адаптера, адаптер читает их через DecodeParams.
+	Params yaml.Node `yaml:"params"`
+	// HTTP — повторы, лимит частоты и число одновременных запросов к API партнёра.
+	HTTP HTTPConfig `yaml:"http"`
+	// Cache — срок свежести снимка баланса партнёра, перекрывает секцию cache.
+	Cache CacheConfig `yaml:"cache"`
+}
+
+// HTTPConfig — настройки общего HTTP-клиента req.Do для партнёра; нулевые значения — значения по умолчанию.
+type HTTPConfig struct {
+	TimeoutSeconds    int     `yaml:"timeout_seconds"`     // таймаут одной попытки, по умолчанию 10
+	MaxRetries        *int    `yaml:"max_retries"`         // повторов после первой попытки, по умолчанию 3, 0 — без повторов
+	BackoffBaseMillis int     `yaml:"backoff_base_ms"`     // первая пауза между попытками, по умолчанию 500
+	BackoffMaxSeconds int     `yaml:"backoff_max_seconds"` // предел паузы, по умолчанию 30; Retry-After больше предела — без повтора
+	RatePerMinute     float64 `yaml:"rate_per_minute"`     // не больше запросов в минуту, 0 — без ограничения
+	MaxConcurrent     int     `yaml:"max_concurrent"`      // одновременных запросов, 0 — без ограничения
+}
+
+// Значения HTTPConfig по умолчанию
+const (
+	DefaultHTTPTimeout    = 10 * time.Second
+	DefaultHTTPRetries    = 3
+	DefaultHTTPBackoff    = 500 * time.Millisecond
+	DefaultHTTPBackoffMax = 30 * time.Second
+)
+
+// Timeout возвращает таймаут одной попытки запроса.
+func (c HTTPConfig) Timeout() time.Duration {
+	if c.TimeoutSeconds <= 0 {
+		return DefaultHTTPTimeout
+	}
+	return time.Duration(c.TimeoutSeconds) * time.Second
+}
+
+// Retries возвращает число повторов после первой попытки.
+func (c HTTPConfig) Retries() int {
+	if c.MaxRetries == nil || *c.MaxRetries < 0 {
+		return DefaultHTTPRetries
+	}
+	return *c.MaxRetries
+}
+
+// Backoff возвращает первую паузу между попытками и её предел.
+func (c HTTPConfig) Backoff() (base, max time.Duration) {
+	base, max = DefaultHTTPBackoff, DefaultHTTPBackoffMax
+	if c.BackoffBaseMillis > 0 {
+		base = time.Duration(c.BackoffBaseMillis) * time.Millisecond
+	}
+	if c.BackoffMaxSeconds > 0 {
+		max = time.Duration(c.BackoffMaxSeconds) * time.Second
+	}
+	return base, max
+}
+
+// DecodeParams разбирает секцию params партнёра в структуру адаптера; пустая секция не меняет v.
+func (p PartnerConfig) DecodeParams(v any) error {
+	if p.Params.Kind == 0 {
+		return nil
+	}
+	return p.Params.Decode(v)
+}
+
+// WithParams возвращает копию партнёра с секцией params, собранной из структуры адаптера.
+func (p PartnerConfig) WithParams(v any) (PartnerConfig, error) {
+	var params yaml.Node
+	if err := params.Encode(v); err != nil {
+		return p, err
+	}
+	p.Params = params
+	return p, nil
+}
+
+// RequestConfig описывает HTTP/JSON запрос баланса для универсального провайдера.
+// URL, значения заголовков и тело — шаблоны text/template, в которых доступны
+// {{.Token}}, {{.Today}} и {{.Yesterday}} (даты в формате 2006-01-02).
+type RequestConfig struct {
+	Method    string            `yaml:"method"`     // HTTP-метод, по умолчанию GET
+	URL       string            `yaml:"url"`        // шаблон адреса
+	Headers   map[string]string `yaml:"headers"`    // дополнительные заголовки
+	Body      string            `yaml:"body"`       // шаблон тела запроса
+	TokenIn   string            `yaml:"token_in"`   // куда подставить токен: header, query или пусто (только через шаблоны)
+	TokenName string            `yaml:"token_name"` // имя заголовка или query-параметра с токеном
+	Path      string            `yaml:"path"`       // gjson-путь к балансу в ответе
+	ValueType string            `yaml:"value_type"` // number (по умолчанию) или string
+
+	// Необязательные gjson-пути к составляющим баланса (число или число в строке) и валюте
+	ReservedPath    string `yaml:"reserved_path"`
+	CreditLimitPath string `yaml:"credit_limit_path"`
+	BonusPath       string `yaml:"bonus_path"`
+	CurrencyPath    string `yaml:"currency_path"`
+}
+
+// ProviderType возвращает тип провайдера для партнёра с указанным именем.
+func (p PartnerConfig) ProviderType(partnerName string) string {
+	if p.Provider != "" {
+		return p.Provider
+	}
+	return strings.ToLower(partnerName)
+}
+
+// RetentionPolicy — сколько хранить сырые замеры и дневные агрегаты.
+type RetentionPolicy struct {
+	KeepDays       int `yaml:"keep_days"`        // сколько дней хранить сырые замеры
+	RollupKeepDays int `yaml:"rollup_keep_days"` // сколько дней хранить дневные агрегаты, 0 — не сворачивать
+}
+
+// RetentionOverride — политика хранения сети. Незаданное (nil) поле берётся из политики по умолчанию,
+// заданное, в том числе 0, перекрывает её: rollup_keep_days: 0 выключает свёртку для сети.
+type RetentionOverride struct {
+	KeepDays       *int `yaml:"keep_days"`
+	RollupKeepDays *int `yaml:"rollup_keep_days"`
+}
+
+// RetentionConfig — политика по умолчанию и переопределения по сетям.
+type RetentionConfig struct {
+	RetentionPolicy `yaml:",inline"`
+	Networks        map[string]RetentionOverride `yaml:"networks"`
+}
+
+// DefaultKeepDays — срок хранения сырых замеров, если он не задан в конфиге
+const DefaultKeepDays = 7
+
+// ScheduleConfig — расписание сбора балансов по умолчанию и переопределения по сетям.
+// Значения — cron-выражения из 5 полей или интервалы вида "@every 30m".
+type ScheduleConfig struct {
+	Default  string            `yaml:"default"`
+	Networks map[string]string `yaml:"networks"`
+	// MaxConcurrent — сколько партнёров опрашивается одновременно в одном сборе, по умолчанию 8.
+	MaxConcurrent int `yaml:"max_concurrent"`
+}
+
+// DefaultCollectConcurrency — число одновременных запросов сбора, если max_concurrent не задан
+const DefaultCollectConcurrency = 8
+
+// Concurrency возвращает, сколько партнёров опрашивается одновременно в одном сборе.
+func (c ScheduleConfig) Concurrency() int {
+	if c.MaxConcurrent <= 0 {
+		return DefaultCollectConcurrency
+	}
+	return c.MaxConcurrent
+}
+
+// DefaultSchedule — расписание сбора, если оно не задано в конфиге
+const DefaultSchedule = "0 4,10,16,22 * * *"
+
+// ForecastConfig — настройки прогноза окончания баланса.
+type ForecastConfig struct {
+	TopUpLeadHours int `yaml:"topup_lead_hours"` // за сколько часов до нуля нужно пополнить
+}
+
+// DefaultTopUpLead — запас до нуля, если topup_lead_hours не задан
+const DefaultTopUpLead = 24 * time.Hour
+
+// AlertRules — правила тревоги по балансу партнёра. Незаданное (nil) или нулевое правило не проверяется.
+type AlertRules struct {
+	MinBalance      *float64 `yaml:"min_balance"`      // баланс ниже абсолютного минимума
+	MinRunwayHours  *float64 `yaml:"min_runway_hours"` // баланса хватит меньше чем на столько часов
+	SpendMultiplier *float64 `yaml:"spend_multiplier"` // баланс ниже суточного расхода, умноженного на множитель
+	DropPercent     *float64 `yaml:"drop_percent"`     // баланс упал на столько процентов с прошлого замера
+}
+
+// DefaultSpendMultiplier — множитель расхода, если spend_multiplier не задан ни у партнёра, ни в секции alerts
+const DefaultSpendMultiplier = 1.3
+
+// merge возвращает правила r, дополненные заданными в override.
+func (r AlertRules) merge(override AlertRules) AlertRules {
+	if override.MinBalance != nil {
+		r.MinBalance = override.MinBalance
+	}
+	if override.MinRunwayHours != nil {
+		r.MinRunwayHours = override.MinRunwayHours
+	}
+	if override.SpendMultiplier != nil {
+		r.SpendMultiplier = override.SpendMultiplier
+	}
+	if override.DropPercent != nil {
+		r.DropPercent = override.DropPercent
+	}
+	return r
+}
+
+// AlertsConfig — правила тревоги по умолчанию и настройки уведомлений.
+type AlertsConfig struct {
+	AlertRules       `yaml:",inline"`
+	RemindEveryHours int `yaml:"remind_every_hours"` // как часто напоминать об открытой тревоге, 0 — не напоминать
+}
+
+// RemindEvery возвращает интервал напоминаний об открытой тревоге, 0 — напоминания выключены.
+func (c AlertsConfig) RemindEvery() time.Duration {
+	return time.Duration(c.RemindEveryHours) * time.Hour
+}
+
+// CurrencyConfig — валюты балансов и отчётов.
+type CurrencyConfig struct {
+	Default   string `yaml:"default"`    // валюта партнёров без собственной currency
+	Reporting string `yaml:"reporting"`  // валюта, в которой отчёты дублируют суммы; пусто — не пересчитывать
+	FXSource  string `yaml:"fx_source"`  // источник курсов из реестра fx, например "static"
+	RatesFile string `yaml:"rates_file"` // файл курсов для источника static
+}
+
+// BreakerConfig — размыкатель цепи партнёра: после FailureThreshold ошибок подряд запросы
+// к партнёру не выполняются OpenMinutes минут, затем пропускается одна пробная попытка.
+type BreakerConfig struct {
+	FailureThreshold int `yaml:"failure_threshold"` // ошибок подряд до размыкания, по умолчанию 3
+	OpenMinutes      int `yaml:"open_minutes"`      // сколько минут цепь разомкнута, по умолчанию 10
+}
+
+// Значения BreakerConfig по умолчанию
+const (
+	DefaultBreakerThreshold = 3
+	DefaultBreakerOpen      = 10 * time.Minute
+)
+
+// Threshold возвращает число ошибок подряд, после которого цепь размыкается.
+func (c BreakerConfig) Threshold() int {
+	if c.FailureThreshold <= 0 {
+		return DefaultBreakerThreshold
+	}
+	return c.FailureThreshold
+}
+
+// OpenFor возвращает, сколько цепь остаётся разомкнутой до пробной попытки.
+func (c BreakerConfig) OpenFor() time.Duration {
+	if c.OpenMinutes <= 0 {
+		return DefaultBreakerOpen
+	}
+	return time.Duration(c.OpenMinutes) * time.Minute
+}
+
+// CacheConfig — кэш снимков балансов, из которого строятся отчёты по запросу.
+// Кэш наполняет сбор по расписанию, устаревшие снимки запрашиваются у партнёра заново.
+type CacheConfig struct {
+	MaxAgeMinutes int `yaml:"max_age_minutes"` // сколько минут снимок считается свежим, по умолчанию 15
+}
+
+// DefaultCacheMaxAge — срок свежести снимка, если max_age_minutes не задан
+const DefaultCacheMaxAge = 15 * time.Minute
+
+// ReportConfig — отчёты по запросу (/stat, /balance).
+type ReportConfig struct {
+	// FetchTimeoutSeconds — сколько отчёт ждёт балансы партнёров, по умолчанию 15;
+	// не ответившие за это время партнёры отмечаются в отчёте.
+	FetchTimeoutSeconds int `yaml:"fetch_timeout_seconds"`
+	// TemplatesDir — каталог шаблонов <формат>.tmpl поверх встроенных: файл с именем встроенного
+	// формата заменяет его, новое имя добавляет формат; пусто — только встроенные шаблоны.
+	TemplatesDir string `yaml:"templates_dir"`
+	// Formats — разметка форматов по имени (html, markdown, plain), от неё зависят экранирование
+	// и parse_mode в Telegram; новые форматы без разметки рендерятся как plain.
+	Formats map[string]string `yaml:"formats"`
+}
+
+// DefaultReportFetchTimeout — ожидание балансов отчётом, если fetch_timeout_seconds не задан
+const DefaultReportFetchTimeout = 15 * time.Second
+
+// FetchTimeout возвращает, сколько отчёт ждёт балансы партнёров.
+func (c ReportConfig) FetchTimeout() time.Duration {
+	if c.FetchTimeoutSeconds <= 0 {
+		return DefaultReportFetchTimeout
+	}
+	return time.Duration(c.FetchTimeoutSeconds) * time.Second
 }
 
 type Config struct {
-	Networks map[string]map[string]PartnerConfig `yaml:"networks"`
+	Networks  map[string]map[string]PartnerConfig `yaml:"networks"`
+	Retention RetentionConfig                     `yaml:"retention"`
+	Schedule  ScheduleConfig                      `yaml:"schedule"`
+	Forecast  ForecastConfig                      `yaml:"forecast"`
+	Alerts    AlertsConfig                        `yaml:"alerts"`
+	Currency  CurrencyConfig                      `yaml:"currency"`
+	Breaker   BreakerConfig                       `yaml:"breaker"`
+	Cache     CacheConfig                         `yaml:"cache"`
+	Report    ReportConfig                        `yaml:"report"`
+}
+
+// CurrencyFor возвращает валюту партнёра: партнёр > currency.default; пусто — неизвестна.
+func (c Config) CurrencyFor(network string, partnerName string) string {
+	if cur := c.Networks[network][partnerName].Currency; cur != "" {
+		return strings.ToUpper(cur)
+	}
+	return strings.ToUpper(c.Currency.Default)
+}
+
+// CacheMaxAgeFor возвращает срок свежести снимка баланса партнёра: партнёр > секция cache > 15 минут.
+func (c Config) CacheMaxAgeFor(network string, partnerName string) time.Duration {
+	if m := c.Networks[network][partnerName].Cache.MaxAgeMinutes; m > 0 {
+		return time.Duration(m) * time.Minute
+	}
+	if c.Cache.MaxAgeMinutes > 0 {
+		return time.Duration(c.Cache.MaxAgeMinutes) * time.Minute
+	}
+	return DefaultCacheMaxAge
+}
+
+// AlertRulesFor возвращает правила тревоги партнёра: партнёр > секция alerts.
+// Незаданный нигде spend_multiplier равен DefaultSpendMultiplier, чтобы выключить правило, задайте 0.
+func (c Config) AlertRulesFor(network string, partnerName string) AlertRules {
+	rules := c.Alerts.AlertRules.merge(c.Networks[network][partnerName].Alerts)
+	if rules.SpendMultiplier == nil {
+		multiplier := DefaultSpendMultiplier
+		rules.SpendMultiplier = &multiplier
+	}
+	return rules
+}
+
+// TopUpLead возвращает запас времени до нуля, за который нужно пополнить аккаунт.
+func (c Config) TopUpLead() time.Duration {
+	if c.Forecast.TopUpLeadHours > 0 {
+		return time.Duration(c.Forecast.TopUpLeadHours) * time.Hour
+	}
+	return DefaultTopUpLead
+}
+
+// ScheduleFor возвращает расписание сбора партнёра: партнёр > сеть > default > DefaultSchedule.
+func (c Config) ScheduleFor(network string, partnerName string) string {
+	if spec := c.Networks[network][partnerName].Schedule; spec != "" {
+		return spec
+	}
+	if spec := c.Schedule.Networks[network]; spec != "" {
+		return spec
+	}
+	if c.Schedule.Default != "" {
+		return c.Schedule.Default
+	}
+	return DefaultSchedule
+}
+
+// RetentionFor возвращает итоговую политику хранения для сети:
+// заданные значения сети перекрывают значения по умолчанию, незаданный или нулевой keep_days равен DefaultKeepDays.
+func (c Config) RetentionFor(network string) RetentionPolicy {
+	policy := c.Retention.RetentionPolicy
+	if override, ok := c.Retention.Networks[network]; ok {
+		if override.KeepDays != nil {
+			policy.KeepDays = *override.KeepDays
+		}
+		if override.RollupKeepDays != nil {
+			policy.RollupKeepDays = *override.RollupKeepDays
+		}
+	}
+	if policy.KeepDays <= 0 {
+		policy.KeepDays = DefaultKeepDays
+	}
+	return policy
 }
 
 var AppConfig Config
 
+// NetworkNames возвращает отсортированный список всех сетей из конфига.
+func NetworkNames() []string {
+	names := make([]string, 0, len(AppConfig.Networks))
+	for name := range AppConfig.Networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func LoadConfig(path string) error {
 	file, err := os.ReadFile(path)
 	if err != nil {
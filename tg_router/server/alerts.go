@@ -0,0 +1,148 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"tg_router/gateway"
+	"tg_router/logger"
+	"tg_router/tg"
+	"tg_router/types"
+	"time"
+)
+
+const (
+	alertBackoffMin = time.Second // первая задержка переподключения
+	alertBackoffMax = time.Minute // максимальная задержка переподключения
+	alertSendTries  = 3           // попыток отправить тревогу в телегу
+)
+
+// alertCursor хранит ID последнего доставленного события тревоги для каждого треда,
+// чтобы после перезапуска продолжить поток с того же места.
+type alertCursor struct {
+	mu   sync.Mutex
+	path string
+	ids  map[string]int64
+}
+
+// loadAlertCursor читает сохранённые позиции; отсутствующий файл — пустые позиции
+func loadAlertCursor(path string) (*alertCursor, error) {
+	c := &alertCursor{path: path, ids: make(map[string]int64)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &c.ids); err != nil {
+		return nil, fmt.Errorf("ошибка чтения %s: %v", path, err)
+	}
+	return c, nil
+}
+
+func (c *alertCursor) get(key string) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ids[key]
+}
+
+// set запоминает позицию и сохраняет файл через временный, чтобы не оставить его недописанным
+func (c *alertCursor) set(key string, id int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ids[key] = id
+
+	data, err := json.MarshalIndent(c.ids, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+func threadKey(thread types.Thread) string {
+	return fmt.Sprintf("%d/%d/%s", thread.ChatID, thread.ThreadID, thread.Network)
+}
+
+// subscribeAlerts держит подписку на тревоги сети треда и переподключается с экспоненциальной задержкой
+func subscribeAlerts(ctx context.Context, bot *tg.TelegramBot, thread types.Thread, cursor *alertCursor) {
+	backoff := alertBackoffMin
+	for ctx.Err() == nil {
+		delivered, err := streamAlerts(ctx, bot, thread, cursor)
+		if ctx.Err() != nil {
+			return
+		}
+		if delivered {
+			backoff = alertBackoffMin
+		}
+		logger.Log.Warnf("Поток тревог сети %s (чат %d) прерван: %v, переподключение через %s", thread.Network, thread.ChatID, err, backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, alertBackoffMax)
+	}
+}
+
+// streamAlerts читает поток тревог до ошибки и сообщает, было ли доставлено хотя бы одно событие.
+// Позиция сдвигается только после отправки события в телегу; неотправленное событие прерывает поток,
+// и переподключение начинается с него же.
+func streamAlerts(ctx context.Context, bot *tg.TelegramBot, thread types.Thread, cursor *alertCursor) (bool, error) {
+	key := threadKey(thread)
+	stream, err := bot.StatClient.SubscribeAlerts(ctx, &gateway.SubscribeAlertsRequest{
+		Networks: []string{thread.Network},
+		AfterId:  cursor.get(key),
+	})
+	if err != nil {
+		return false, err
+	}
+	logger.Log.Infof("Подписка на тревоги сети %s (чат %d) с события %d", thread.Network, thread.ChatID, cursor.get(key))
+
+	delivered := false
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			return delivered, err
+		}
+		if err := sendAlert(ctx, bot, thread, event); err != nil {
+			return delivered, err
+		}
+		delivered = true
+		if err := cursor.set(key, event.GetId()); err != nil {
+			logger.Log.Errorf("Ошибка сохранения позиции потока тревог: %v", err)
+		}
+	}
+}
+
+// sendAlert отправляет тревогу в тред, повторяя при ошибке alertSendTries раз.
+// Ошибка — событие не доставлено, в том числе из-за остановки бота.
+func sendAlert(ctx context.Context, bot *tg.TelegramBot, thread types.Thread, event *gateway.AlertEvent) error {
+	text := tg.FormatAlertEvent(event)
+	for try := 1; ; try++ {
+		err := bot.SendMessage(thread.ChatID, thread.ThreadID, text)
+		if err == nil {
+			return nil
+		}
+		if try == alertSendTries {
+			return fmt.Errorf("тревога %d не отправлена в чат %d: %w", event.GetId(), thread.ChatID, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(try) * alertBackoffMin):
+		}
+	}
+}
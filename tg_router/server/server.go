@@ -2,7 +2,9 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"strings"
 	"tg_router/gateway"
 	"tg_router/logger"
 	"tg_router/tg"
@@ -44,6 +46,11 @@ func Run() error {
 		return err
 	}
 
+	if err := checkFormats(ctx, statClient, threads); err != nil {
+		logger.Log.Errorf("Ошибка конфигурации чатов: %v", err)
+		return err
+	}
+
 	bot, err := tg.NewTelegramBot(apiToken, threads, statClient)
 	if err != nil {
 		logger.Log.Errorf("Ошибка запуска бота %v", err)
@@ -51,6 +58,20 @@ func Run() error {
 	}
 	go bot.StartListening(ctx, "Multibot запущен")
 
+	// Подписка на тревоги для каждого треда
+	cursorPath := os.Getenv("ALERTS_STATE_FILE")
+	if cursorPath == "" {
+		cursorPath = "data/alerts_state.json"
+	}
+	cursor, err := loadAlertCursor(cursorPath)
+	if err != nil {
+		logger.Log.Errorf("Ошибка загрузки позиций потока тревог: %v", err)
+		return err
+	}
+	for _, thread := range threads.Threads {
+		go subscribeAlerts(ctx, bot, thread, cursor)
+	}
+
 	// Запуск планировщика
 	scheduler(bot, ctx)
 	return nil
@@ -71,19 +92,65 @@ func LoadThreadsFromFile(filename string) (types.Config, error) {
 	return config, nil
 }
 
+// checkFormats проверяет, что форматы отчётов тредов есть в partner_balance; пустой формат — формат по умолчанию
+func checkFormats(ctx context.Context, client gateway.StatServiceClient, threads types.Config) error {
+	reqCtx, cancel := context.WithTimeout(ctx, tg.RequestTimeout)
+	defer cancel()
+	resp, err := client.ListReportFormats(reqCtx, &gateway.ListReportFormatsRequest{}, grpc.WaitForReady(true))
+	if err != nil {
+		return fmt.Errorf("не удалось получить форматы отчётов: %w", err)
+	}
+	known := make(map[string]bool, len(resp.GetFormats()))
+	names := make([]string, 0, len(resp.GetFormats()))
+	for _, format := range resp.GetFormats() {
+		known[format.GetName()] = true
+		names = append(names, format.GetName())
+	}
+	for _, thread := range threads.Threads {
+		if thread.Format != "" && !known[thread.Format] {
+			return fmt.Errorf("тред %s (чат %d): неизвестный формат отчёта %q, доступны: %s",
+				thread.Description, thread.ChatID, thread.Format, strings.Join(names, ", "))
+		}
+	}
+	return nil
+}
+
 func scheduler(bot *tg.TelegramBot, ctx context.Context) {
 	c := cron.New(cron.WithLocation(time.Local))
 	c.AddFunc("15 10,17 * * *", func() {
 		for _, thread := range bot.Threads.Threads {
-			req := &gateway.StatRequest{
+			req := &gateway.NetworkRequest{
 				Network: thread.Network,
+				Format:  thread.Format,
 			}
-			resp, err := bot.StatClient.Stat(ctx, req)
+			reqCtx, cancel := context.WithTimeout(ctx, tg.RequestTimeout)
+			resp, err := bot.StatClient.GetSpendStats(reqCtx, req)
+			cancel()
 			if err != nil {
 				logger.Log.Errorf("Ошибка при получении статистики: %v", err)
-				return
+				continue
+			}
+			if err := bot.SendReport(thread.ChatID, thread.ThreadID, resp.GetText(), resp.GetParseMode()); err != nil {
+				logger.Log.Errorf("Ошибка отправки статистики в чат %d: %v", thread.ChatID, err)
+			}
+		}
+	})
+	// еженедельный отчёт о пополнениях для сверки с финансами
+	c.AddFunc("0 10 * * 1", func() {
+		for _, thread := range bot.Threads.Threads {
+			req := &gateway.TopUpsRequest{
+				Network: thread.Network,
+			}
+			reqCtx, cancel := context.WithTimeout(ctx, tg.RequestTimeout)
+			resp, err := bot.StatClient.GetTopUps(reqCtx, req)
+			cancel()
+			if err != nil {
+				logger.Log.Errorf("Ошибка при получении пополнений: %v", err)
+				continue
+			}
+			if err := bot.SendMessage(thread.ChatID, thread.ThreadID, tg.FormatTopUps(resp)); err != nil {
+				logger.Log.Errorf("Ошибка отправки отчёта о пополнениях в чат %d: %v", thread.ChatID, err)
 			}
-			bot.SendMessage(thread.ChatID, thread.ThreadID, resp.Text)
 		}
 	})
 	c.Start()
@@ -0,0 +1,67 @@
+package tg
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+	"tg_router/gateway"
+	"time"
+)
+
+// Отчёты /stat и /balance рендерит partner_balance шаблоном из threads.yaml (поле format)
+// и возвращает вместе с текстом parse_mode Telegram.
+
+// FormatTopUps рендерит ответ GetTopUps в HTML для Telegram: итоги по партнёрам и список пополнений
+func FormatTopUps(reply *gateway.TopUpsReply) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Пополнения %s — %s\n",
+		reply.GetFrom().AsTime().In(time.Local).Format("02-01-2006"),
+		reply.GetTo().AsTime().In(time.Local).Format("02-01-2006")))
+	if len(reply.GetSummaries()) == 0 {
+		sb.WriteString("пополнений не было\n")
+		return sb.String()
+	}
+
+	byPartner := make(map[string][]*gateway.TopUp)
+	for _, t := range reply.GetTopUps() {
+		byPartner[t.GetPartner()] = append(byPartner[t.GetPartner()], t)
+	}
+	for _, sum := range reply.GetSummaries() {
+		sb.WriteString(fmt.Sprintf("\n<b>%s</b>: %d на %.2f\n", html.EscapeString(sum.GetPartner()), sum.GetCount(), roundTo(sum.GetTotal(), 2)))
+		for _, t := range byPartner[sum.GetPartner()] {
+			sb.WriteString(fmt.Sprintf("%s  +%.2f\n", t.GetAt().AsTime().In(time.Local).Format("02-01 15:04"), roundTo(t.GetAmount(), 2)))
+		}
+	}
+	return sb.String()
+}
+
+// FormatAlertEvent рендерит событие тревоги в HTML для Telegram
+func FormatAlertEvent(e *gateway.AlertEvent) string {
+	partner := fmt.Sprintf("<b>%s</b> (%s)", html.EscapeString(e.GetPartner()), html.EscapeString(e.GetNetwork()))
+	since := e.GetSince().AsTime().In(time.Local)
+	reason := html.EscapeString(e.GetReason())
+
+	switch e.GetKind() {
+	case "resolve":
+		lasted := e.GetCreatedAt().AsTime().Sub(since).Round(time.Minute)
+		return fmt.Sprintf("🟢 %s: восстановлено, тревога длилась %s\n%s", partner, formatDuration(lasted), reason)
+	case "remind":
+		return fmt.Sprintf("🔴 %s: всё ещё %s\nс %s", partner, reason, since.Format("02-01 15:04"))
+	default:
+		return fmt.Sprintf("🔴 %s: %s", partner, reason)
+	}
+}
+
+// formatDuration — длительность в часах и минутах
+func formatDuration(d time.Duration) string {
+	h, m := int(d.Hours()), int(d.Minutes())%60
+	if h == 0 {
+		return fmt.Sprintf("%d мин", m)
+	}
+	return fmt.Sprintf("%d ч %d мин", h, m)
+}
+
+func roundTo(x float64, n int) float64 {
+	return math.Round(x*math.Pow(10, float64(n))) / math.Pow(10, float64(n))
+}
@@ -9,10 +9,15 @@ import (
 	"tg_router/gateway"
 	"tg_router/logger"
 	"tg_router/types"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
+// RequestTimeout — сколько ждать ответа partner_balance на запрос отчёта. Сервис сам ограничивает
+// ожидание партнёров и возвращает частичный отчёт, таймаут лишь не даёт запросу висеть бесконечно.
+const RequestTimeout = 30 * time.Second
+
 // Структура бота
 type TelegramBot struct {
 	Bot        *tgbotapi.BotAPI
@@ -86,15 +91,51 @@ func (t *TelegramBot) handleUpdate(ctx context.Context, update tgbotapi.Update,
 	switch command {
 	case "stat":
 		go func() {
-			req := &gateway.StatRequest{
+			req := &gateway.NetworkRequest{
 				Network: thread.Network,
+				Format:  thread.Format,
 			}
-			resp, err := t.StatClient.Stat(ctx, req)
+			reqCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+			defer cancel()
+			resp, err := t.StatClient.GetSpendStats(reqCtx, req)
 			if err != nil {
 				logger.Log.Errorf("[%s] Ошибка при получении статистики: %v", botName, err)
 				return
 			}
-			if err := t.SendMessage(chatID, thread.ThreadID, resp.Text); err != nil {
+			if err := t.SendReport(chatID, thread.ThreadID, resp.GetText(), resp.GetParseMode()); err != nil {
+				logger.Log.Errorf("[%s] Ошибка отправки ответа: %v", botName, err)
+			}
+		}()
+	case "balance":
+		go func() {
+			req := &gateway.NetworkRequest{
+				Network: thread.Network,
+				Format:  thread.Format,
+			}
+			reqCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+			defer cancel()
+			resp, err := t.StatClient.GetBalances(reqCtx, req)
+			if err != nil {
+				logger.Log.Errorf("[%s] Ошибка при получении балансов: %v", botName, err)
+				return
+			}
+			if err := t.SendReport(chatID, thread.ThreadID, resp.GetText(), resp.GetParseMode()); err != nil {
+				logger.Log.Errorf("[%s] Ошибка отправки ответа: %v", botName, err)
+			}
+		}()
+	case "topups":
+		go func() {
+			req := &gateway.TopUpsRequest{
+				Network: thread.Network,
+			}
+			reqCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+			defer cancel()
+			resp, err := t.StatClient.GetTopUps(reqCtx, req)
+			if err != nil {
+				logger.Log.Errorf("[%s] Ошибка при получении пополнений: %v", botName, err)
+				return
+			}
+			if err := t.SendMessage(chatID, thread.ThreadID, FormatTopUps(resp)); err != nil {
 				logger.Log.Errorf("[%s] Ошибка отправки ответа: %v", botName, err)
 			}
 		}()
@@ -106,6 +147,16 @@ func (t *TelegramBot) handleUpdate(ctx context.Context, update tgbotapi.Update,
 
 // SendMessage отправляет сообщение в телегу.
 func (t *TelegramBot) SendMessage(chatID int64, threadID int64, msgText string) error {
+	return t.send(chatID, threadID, msgText, "HTML")
+}
+
+// SendReport отправляет отчёт, отрендеренный partner_balance, с parse_mode из его ответа
+func (t *TelegramBot) SendReport(chatID int64, threadID int64, msgText string, parseMode string) error {
+	return t.send(chatID, threadID, msgText, parseMode)
+}
+
+// send отправляет сообщение с разметкой parseMode; пустой parseMode — текст без разметки
+func (t *TelegramBot) send(chatID int64, threadID int64, msgText string, parseMode string) error {
 
 	if len(bytes.TrimSpace([]byte(msgText))) == 0 {
 		logger.Log.Infof("[TelegramBot] SendMessage: пустое сообщение, отправка пропущена")
@@ -116,7 +167,9 @@ func (t *TelegramBot) SendMessage(chatID int64, threadID int64, msgText string)
 		"chat_id":           chatID,
 		"message_thread_id": threadID,
 		"text":              msgText,
-		"parse_mode":        "HTML",
+	}
+	if parseMode != "" {
+		payload["parse_mode"] = parseMode
 	}
 	data, err := json.Marshal(payload)
 	if err != nil {
@@ -6,6 +6,8 @@ type Thread struct {
 	Description 	string	`yaml:"description"`
 	ChatID      	int64  	`yaml:"chat_id"`
 	ThreadID    	int64  	`yaml:"thread_id"`
+	// Format — шаблон отчётов /stat и /balance: telegram_html (по умолчанию), markdown, plain, csv
+	Format      	string 	`yaml:"format"`
 }
 
 // массив конфигураций